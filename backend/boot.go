@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/pudongping/wx-graph-crawl/backend/handlers"
+	"github.com/pudongping/wx-graph-crawl/backend/service"
 )
 
 type Boot struct {
@@ -18,6 +19,13 @@ func NewBoot() *Boot {
 		handlers.NewUserHandler(),
 		handlers.NewFileHandler(),
 		handlers.NewImageHandler(),
+		handlers.NewAlbumHandler(),
+		handlers.NewAlbumSubscriptionHandler(),
+		handlers.NewIntakeHandler(),
+		handlers.NewDuplicateImageHandler(),
+		handlers.NewBundleHandler(),
+		handlers.NewDataTransferHandler(),
+		handlers.NewHistoryHandler(),
 	}
 
 	return &Boot{
@@ -37,6 +45,18 @@ func (b *Boot) SetContext(ctx context.Context) {
 
 }
 
+// Startup 启动后台任务，需要在数据库初始化之后调用
+func (b *Boot) Startup(ctx context.Context) {
+	service.DefaultAlbumSubscriptionScheduler().Start(ctx)
+	service.NewIntakeService().RegisterFileDrop(ctx)
+}
+
+// Shutdown 停止后台任务
+func (b *Boot) Shutdown() {
+	service.DefaultAlbumSubscriptionScheduler().Stop()
+	service.DefaultClipboardWatcher().Stop()
+}
+
 func (b *Boot) Binds() []interface{} {
 	return b.bindHandlers
 }
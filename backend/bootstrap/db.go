@@ -59,35 +59,16 @@ func CloseDB() error {
 	return nil
 }
 
-// initTables 初始化所有数据表
-func initTables(db *sqlx.DB) error {
-	var err error
+// initTables 执行数据库迁移，创建或升级所有数据表
+func initTables(db *sqlx.DB) (err error) {
 	defer func() {
 		if err != nil {
 			_ = db.Close() // 关闭数据库连接
 		}
 	}()
 
-	// 创建系统配置表
-	if err = createSystemConfigsTable(db); err != nil {
-		return errors.Wrap(err, "创建系统配置表失败")
+	if _, err = Migrate(db); err != nil {
+		return err
 	}
-
 	return nil
 }
-
-// createSystemConfigsTable 创建系统配置表
-func createSystemConfigsTable(db *sqlx.DB) error {
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS system_configs (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			key TEXT NOT NULL UNIQUE,
-			content TEXT NOT NULL DEFAULT '',
-			version INTEGER NOT NULL DEFAULT 1,
-			created_at INTEGER NOT NULL DEFAULT 0,
-			updated_at INTEGER NOT NULL DEFAULT 0
-		);
-		CREATE INDEX IF NOT EXISTS idx_version_updated_at ON system_configs (version, updated_at);
-	`)
-	return err
-}
@@ -0,0 +1,150 @@
+package bootstrap
+
+import (
+	"embed"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// migrationFS 数据库迁移脚本，文件名为“版本号_说明.sql”，版本号从 1 开始连续递增
+// 已经发布的迁移脚本不能再修改，修改表结构时新增一个迁移脚本
+// 引入迁移之前创建的数据库中已经有前几个版本的表，所以这几个脚本都使用 IF NOT EXISTS
+//
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// migration 一个迁移脚本
+type migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// SchemaVersion 返回数据库当前的表结构版本，还没有迁移过时为 0
+func SchemaVersion(db *sqlx.DB) (version int, err error) {
+	if err = createSchemaMigrationsTable(db); err != nil {
+		return 0, err
+	}
+	err = db.Get(&version, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations")
+	return version, errors.Wrap(err, "查询数据库版本失败")
+}
+
+// LatestSchemaVersion 返回当前程序支持的最新表结构版本
+func LatestSchemaVersion() int {
+	migrations, err := loadMigrations(migrationFS)
+	if err != nil || len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// Migrate 按版本号依次执行还没有执行过的迁移脚本，每个迁移脚本在单独的事务中执行
+// 数据库版本高于当前程序支持的版本时（如用新版本打开过），拒绝启动，避免旧程序写坏数据
+func Migrate(db *sqlx.DB) (applied int, err error) {
+	return migrate(db, migrationFS)
+}
+
+func migrate(db *sqlx.DB, fsys fs.FS) (applied int, err error) {
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		return 0, err
+	}
+	current, err := SchemaVersion(db)
+	if err != nil {
+		return 0, err
+	}
+	latest := 0
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+	if current > latest {
+		return 0, errors.Errorf("数据库版本 %d 高于当前程序支持的版本 %d，请升级到最新版本后再使用", current, latest)
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err = applyMigration(db, m); err != nil {
+			return applied, err
+		}
+		applied++
+		zap.L().Info("数据库迁移完成", zap.Int("version", m.Version), zap.String("name", m.Name))
+	}
+	return applied, nil
+}
+
+// applyMigration 在一个事务中执行迁移脚本并记录版本，失败时整个迁移回滚
+func applyMigration(db *sqlx.DB, m migration) (err error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return errors.Wrapf(err, "数据库迁移 %d_%s 开始事务失败", m.Version, m.Name)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(m.SQL); err != nil {
+		return errors.Wrapf(err, "执行数据库迁移 %d_%s 失败", m.Version, m.Name)
+	}
+	if _, err = tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", m.Version, m.Name, time.Now().Unix()); err != nil {
+		return errors.Wrapf(err, "记录数据库迁移 %d_%s 失败", m.Version, m.Name)
+	}
+	if err = tx.Commit(); err != nil {
+		return errors.Wrapf(err, "提交数据库迁移 %d_%s 失败", m.Version, m.Name)
+	}
+	return nil
+}
+
+// loadMigrations 读取并按版本号排列迁移脚本，版本号必须从 1 开始连续递增
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	files, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, errors.Wrap(err, "读取数据库迁移脚本失败")
+	}
+	migrations := make([]migration, 0, len(files))
+	for _, file := range files {
+		name := strings.TrimSuffix(path.Base(file), ".sql")
+		prefix, desc, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, errors.Errorf("数据库迁移脚本 %s 的文件名不正确，应该为“版本号_说明.sql”", file)
+		}
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "读取数据库迁移脚本 %s 失败", file)
+		}
+		migrations = append(migrations, migration{Version: version, Name: desc, SQL: string(content)})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, errors.Errorf("数据库迁移脚本的版本号必须从 1 开始连续递增，%d_%s 的版本号应该为 %d", m.Version, m.Name, i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// createSchemaMigrationsTable 创建记录已执行迁移的表
+func createSchemaMigrationsTable(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL DEFAULT '',
+			applied_at INTEGER NOT NULL DEFAULT 0
+		);
+	`)
+	return errors.Wrap(err, "创建数据库迁移表失败")
+}
@@ -0,0 +1,105 @@
+package bootstrap
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func openMigrateTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	db, err := sqlx.Connect("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("连接数据库失败: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func tableExists(t *testing.T, db *sqlx.DB, table string) bool {
+	t.Helper()
+	var count int
+	if err := db.Get(&count, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table); err != nil {
+		t.Fatal(err)
+	}
+	return count > 0
+}
+
+func TestMigrateEmptyDB(t *testing.T) {
+	db := openMigrateTestDB(t)
+	latest := LatestSchemaVersion()
+	applied, err := Migrate(db)
+	if err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	if applied != latest || latest == 0 {
+		t.Errorf("执行了 %d 个迁移，应该为 %d", applied, latest)
+	}
+	for _, table := range []string{"system_configs", "album_subscriptions", "album_subscription_articles", "album_subscription_runs", "crop_jobs", "image_hashes"} {
+		if !tableExists(t, db, table) {
+			t.Errorf("迁移后没有 %s 表", table)
+		}
+	}
+
+	// 已经是最新版本时不会再执行
+	if applied, err = Migrate(db); err != nil || applied != 0 {
+		t.Errorf("重复迁移执行了 %d 个迁移: %v", applied, err)
+	}
+	if version, _ := SchemaVersion(db); version != latest {
+		t.Errorf("数据库版本为 %d，应该为 %d", version, latest)
+	}
+}
+
+func TestMigrateExistingDB(t *testing.T) {
+	db := openMigrateTestDB(t)
+	// 引入迁移之前创建的数据库，已经有表和数据，但没有迁移记录
+	db.MustExec(`
+		CREATE TABLE system_configs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			key TEXT NOT NULL UNIQUE,
+			content TEXT NOT NULL DEFAULT '',
+			version INTEGER NOT NULL DEFAULT 1,
+			created_at INTEGER NOT NULL DEFAULT 0,
+			updated_at INTEGER NOT NULL DEFAULT 0
+		);
+		INSERT INTO system_configs (key, content) VALUES ('preference', '{}');
+	`)
+	if _, err := Migrate(db); err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	var content string
+	if err := db.Get(&content, "SELECT content FROM system_configs WHERE key = 'preference'"); err != nil || content != "{}" {
+		t.Errorf("迁移后原来的数据丢失: %q, %v", content, err)
+	}
+
+	// 数据库版本高于程序支持的版本时拒绝启动
+	db.MustExec("INSERT INTO schema_migrations (version, name) VALUES (?, 'future')", LatestSchemaVersion()+1)
+	if _, err := Migrate(db); err == nil {
+		t.Error("数据库版本更新时应该返回错误")
+	}
+}
+
+func TestMigrateRollback(t *testing.T) {
+	db := openMigrateTestDB(t)
+	fsys := fstest.MapFS{
+		"migrations/0001_a.sql": {Data: []byte("CREATE TABLE a (id INTEGER);")},
+		"migrations/0002_b.sql": {Data: []byte("CREATE TABLE b (id INTEGER); INSERT INTO missing VALUES (1);")},
+	}
+	applied, err := migrate(db, fsys)
+	if err == nil || applied != 1 {
+		t.Fatalf("第二个迁移应该失败，执行了 %d 个迁移: %v", applied, err)
+	}
+	if version, _ := SchemaVersion(db); version != 1 {
+		t.Errorf("数据库版本为 %d，应该为 1", version)
+	}
+	if !tableExists(t, db, "a") || tableExists(t, db, "b") {
+		t.Error("失败的迁移应该整个回滚")
+	}
+
+	fsys["migrations/0004_c.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	if _, err = migrate(db, fsys); err == nil {
+		t.Error("版本号不连续时应该返回错误")
+	}
+}
@@ -0,0 +1,43 @@
+-- 专辑订阅表、已同步文章表和同步记录表
+CREATE TABLE IF NOT EXISTS album_subscriptions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	album_url TEXT NOT NULL DEFAULT '',
+	biz TEXT NOT NULL DEFAULT '',
+	album_id TEXT NOT NULL DEFAULT '',
+	title TEXT NOT NULL DEFAULT '',
+	nick_name TEXT NOT NULL DEFAULT '',
+	interval_minutes INTEGER NOT NULL DEFAULT 0,
+	enabled INTEGER NOT NULL DEFAULT 1,
+	crawl_existing INTEGER NOT NULL DEFAULT 0,
+	last_synced_at INTEGER NOT NULL DEFAULT 0,
+	next_sync_at INTEGER NOT NULL DEFAULT 0,
+	created_at INTEGER NOT NULL DEFAULT 0,
+	updated_at INTEGER NOT NULL DEFAULT 0,
+	UNIQUE (biz, album_id)
+);
+CREATE INDEX IF NOT EXISTS idx_enabled_next_sync_at ON album_subscriptions (enabled, next_sync_at);
+
+CREATE TABLE IF NOT EXISTS album_subscription_articles (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	subscription_id INTEGER NOT NULL DEFAULT 0,
+	article_key TEXT NOT NULL DEFAULT '',
+	url TEXT NOT NULL DEFAULT '',
+	msgid TEXT NOT NULL DEFAULT '',
+	title TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL DEFAULT 0,
+	UNIQUE (subscription_id, article_key)
+);
+
+CREATE TABLE IF NOT EXISTS album_subscription_runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	subscription_id INTEGER NOT NULL DEFAULT 0,
+	status TEXT NOT NULL DEFAULT '',
+	article_count INTEGER NOT NULL DEFAULT 0,
+	new_article_count INTEGER NOT NULL DEFAULT 0,
+	crawl_img_count INTEGER NOT NULL DEFAULT 0,
+	save_path TEXT NOT NULL DEFAULT '',
+	err_content TEXT NOT NULL DEFAULT '',
+	started_at INTEGER NOT NULL DEFAULT 0,
+	finished_at INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_subscription_id_started_at ON album_subscription_runs (subscription_id, started_at);
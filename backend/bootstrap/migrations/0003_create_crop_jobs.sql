@@ -0,0 +1,12 @@
+-- 裁剪任务表，用于撤销裁剪
+CREATE TABLE IF NOT EXISTS crop_jobs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	job_id TEXT NOT NULL UNIQUE,
+	root_dir TEXT NOT NULL DEFAULT '',
+	mode TEXT NOT NULL DEFAULT '',
+	output_dir TEXT NOT NULL DEFAULT '',
+	journal_dir TEXT NOT NULL DEFAULT '',
+	image_count INTEGER NOT NULL DEFAULT 0,
+	created_at INTEGER NOT NULL DEFAULT 0,
+	undone_at INTEGER NOT NULL DEFAULT 0
+);
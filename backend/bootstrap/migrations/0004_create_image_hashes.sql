@@ -0,0 +1,14 @@
+-- 图片感知哈希表，用于查找相似图片
+CREATE TABLE IF NOT EXISTS image_hashes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	path TEXT NOT NULL UNIQUE,
+	size INTEGER NOT NULL DEFAULT 0,
+	mod_time INTEGER NOT NULL DEFAULT 0,
+	width INTEGER NOT NULL DEFAULT 0,
+	height INTEGER NOT NULL DEFAULT 0,
+	ahash TEXT NOT NULL DEFAULT '',
+	dhash TEXT NOT NULL DEFAULT '',
+	phash TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL DEFAULT 0,
+	updated_at INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1,13 @@
+-- 系统配置修改记录表，保存每个版本的完整内容，用于查看和回滚
+CREATE TABLE system_config_histories (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	key TEXT NOT NULL DEFAULT '',
+	version INTEGER NOT NULL DEFAULT 0,
+	content TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL DEFAULT 0,
+	UNIQUE (key, version)
+);
+
+-- 已有的配置作为第一条修改记录
+INSERT INTO system_config_histories (key, version, content, created_at)
+SELECT key, version, content, updated_at FROM system_configs;
@@ -0,0 +1,35 @@
+-- 抓取记录表，每次抓取一条记录，保存请求参数和统计结果，可以重新执行
+CREATE TABLE crawl_runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	status TEXT NOT NULL DEFAULT '',
+	save_path TEXT NOT NULL DEFAULT '',
+	requested_urls TEXT NOT NULL DEFAULT '[]',
+	options TEXT NOT NULL DEFAULT '{}',
+	url_count INTEGER NOT NULL DEFAULT 0,
+	success_count INTEGER NOT NULL DEFAULT 0,
+	failed_count INTEGER NOT NULL DEFAULT 0,
+	img_count INTEGER NOT NULL DEFAULT 0,
+	processed_img_count INTEGER NOT NULL DEFAULT 0,
+	err_content TEXT NOT NULL DEFAULT '',
+	duration_ms INTEGER NOT NULL DEFAULT 0,
+	started_at INTEGER NOT NULL DEFAULT 0,
+	finished_at INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX idx_crawl_runs_started_at ON crawl_runs (started_at);
+
+-- 每次抓取中每篇文章的结果
+CREATE TABLE crawl_run_articles (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	run_id INTEGER NOT NULL DEFAULT 0,
+	article_key TEXT NOT NULL DEFAULT '',
+	url TEXT NOT NULL DEFAULT '',
+	title TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL DEFAULT '',
+	img_count INTEGER NOT NULL DEFAULT 0,
+	img_dir TEXT NOT NULL DEFAULT '',
+	files TEXT NOT NULL DEFAULT '[]',
+	err_content TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX idx_crawl_run_articles_run_id ON crawl_run_articles (run_id);
+CREATE INDEX idx_crawl_run_articles_article_key ON crawl_run_articles (article_key);
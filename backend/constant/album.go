@@ -0,0 +1,21 @@
+package constant
+
+import (
+	"time"
+)
+
+const (
+	AlbumDefaultPageSize        = 10                                        // 专辑分页接口每次请求默认获取的文章数量
+	AlbumDefaultRequestInterval = 2 * time.Second                           // 专辑分页请求的默认间隔，避免频率限制
+	AlbumDefaultMaxPages        = 0                                         // 专辑分页请求的默认最大次数，0表示不限制
+	AlbumAPIBaseURL             = "https://mp.weixin.qq.com/mp/appmsgalbum" // 专辑接口基础地址
+)
+
+const (
+	AlbumSubscriptionDefaultIntervalMinutes = 60               // 专辑订阅默认的同步间隔（分钟）
+	AlbumSubscriptionMinIntervalMinutes     = 10               // 专辑订阅最小的同步间隔（分钟），避免频率限制
+	AlbumSubscriptionCheckInterval          = time.Minute      // 调度器检查到期订阅的间隔
+	AlbumSubscriptionCrawlTimeoutSeconds    = 5                // 偏好设置中没有下载超时时间时使用的默认值（秒）
+	AlbumSubscriptionCrawlQueueSize         = 100              // 待抓取任务队列的容量
+	AlbumSubscriptionSaveDirTimeLayout      = "20060102150405" // 每次自动抓取时保存目录的时间格式
+)
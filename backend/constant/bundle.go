@@ -0,0 +1,9 @@
+package constant
+
+const (
+	BundleDirName          = "_bundles"    // 发布包默认的输出目录名，遍历图片时会跳过
+	BundleMaxImages        = 20            // 小绿书一篇最多 20 张图片，每个发布包的图片数量不超过此值
+	BundleCaptionFileName  = "caption.txt" // 发布包中的文案文件名
+	BundleCaptionMaxRunes  = 1000          // 文案最多保留的字数，超过时截断
+	BundleDefaultCoverRule = "first"       // 默认使用第一张图片作为封面
+)
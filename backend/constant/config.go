@@ -1,7 +1,25 @@
 package constant
 
+import (
+	"time"
+)
+
 const (
 	WXMPTWDomain        = "mp.weixin.qq.com/s" // 微信公众号小绿书通用URL
 	TextContentFileName = "content.txt"        // 采集的微信公众号小绿书文本内容写入的文件名
 	TextContentFileDir  = "content"            // 采集的微信公众号小绿书文本内容写入的目录名
+	CrawlManifestFile   = "wxgc_manifest.json" // 抓取记录文件名，保存每篇文章的标题、文案和图片顺序
+	CrawlManifestVer    = 1                    // 抓取记录文件的格式版本
+	FailedDownloadDir   = "failed_downloads"   // 抓取失败记录的目录名
+)
+
+const (
+	DataExportFormatVersion = 2                  // 导出文件的格式版本，2 开始包含抓取记录
+	DataExportZipEntry      = "wxgc_export.json" // zip 格式的导出文件中保存数据的文件名
+)
+
+const (
+	IntakeEventFileDrop    = "intake:file-drop" // 拖拽文件导入URL后推送给前端的事件名
+	IntakeEventClipboard   = "intake:clipboard" // 剪贴板中发现新的文章链接后推送给前端的事件名
+	ClipboardWatchInterval = time.Second        // 剪贴板检查间隔
 )
@@ -0,0 +1,26 @@
+package constant
+
+const (
+	CropBackupDirName   = ".wxgc_backup"   // 裁剪时保存原图和裁剪日志、打乱时保存打乱日志的目录名，遍历图片时会跳过
+	CropJournalFileName = "journal.jsonl"  // 裁剪日志文件名，每行记录一张图片的原图备份或输出路径
+	CropJobIDTimeLayout = "20060102150405" // 裁剪任务ID中的时间格式
+)
+
+const (
+	CropPreviewDefaultLimit  = 1   // 预览目录时默认预览的图片数量
+	CropPreviewMaxLimit      = 20  // 预览目录时最多预览的图片数量
+	CropPreviewThumbnailSize = 320 // 预览缩略图的最大宽高（像素）
+	CropPreviewJPEGQuality   = 80  // 预览缩略图的 JPEG 质量
+)
+
+const (
+	WatermarkStripSearchRatio       = 0.25 // 自动识别水印条时，最多检查图片底部多大比例的区域
+	WatermarkStripMaxRatio          = 0.2  // 水印条最多占图片高度的比例，超过时认为识别不准确
+	WatermarkStripMaxTextRatio      = 0.06 // 水印文字最多占图片高度的比例
+	WatermarkStripMinTextRows       = 4    // 水印文字的最小高度（像素）
+	WatermarkStripSampleWidth       = 480  // 每行最多采样的像素数量，宽图会按间隔采样
+	WatermarkStripLumaTolerance     = 24   // 与水印条背景亮度相差在此范围内的像素视为背景
+	WatermarkStripUniformStdDev     = 10.0 // 亮度标准差小于此值的行视为纯色行
+	WatermarkStripUniformRowRatio   = 0.99 // 背景像素占比不小于此值的行视为纯色行
+	WatermarkStripTextRowMinBgRatio = 0.6  // 背景像素占比不小于此值（且不是纯色行）的行视为文字行
+)
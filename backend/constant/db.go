@@ -2,4 +2,19 @@ package constant
 
 const (
 	SystemConfigKeyPreferenceInfo = "preference_info" // 系统配置表中保存用户偏好设置的key
+	SettingHistoryMaxRecords      = 50                // 每个配置项最多保留的修改记录数量
+)
+
+const (
+	SettingDownloadTimeoutDefault    = 5   // 默认下载超时时间（秒）
+	SettingDownloadTimeoutMin        = 1   // 下载超时时间的最小值（秒）
+	SettingDownloadTimeoutMax        = 500 // 下载超时时间的最大值（秒）
+	SettingCropImgBottomPixelDefault = 65  // 默认裁剪图片底部的像素
+	SettingCropImgBottomPixelMin     = 1   // 裁剪图片底部像素的最小值
+	SettingCropImgBottomPixelMax     = 500 // 裁剪图片底部像素的最大值
+)
+
+const (
+	CrawlRunDefaultPageSize = 20  // 抓取记录每页默认的数量
+	CrawlRunMaxPageSize     = 100 // 抓取记录每页最多的数量
 )
@@ -0,0 +1,8 @@
+package constant
+
+const (
+	DuplicateImageDefaultAlgorithm = "phash"       // 查找相似图片时默认使用的哈希算法
+	DuplicateImageDefaultThreshold = 8             // 默认的相似阈值，64位哈希中不同的位数不大于此值时视为相似
+	DuplicateImageMaxThreshold     = 32            // 相似阈值的最大值，超过时几乎所有图片都会被视为相似
+	DuplicateImageDirName          = "_duplicates" // 移动相似图片时默认的目标目录名，遍历图片时会跳过
+)
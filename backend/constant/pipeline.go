@@ -0,0 +1,5 @@
+package constant
+
+const (
+	ImagePipelineDefaultJPEGQuality = 85 // 转换为 JPEG 或缩小 JPEG 图片时，没有设置质量时使用的默认值
+)
@@ -0,0 +1,14 @@
+package constant
+
+const (
+	ShuffleJournalPrefix = "shuffle_"       // 打乱日志的文件名前缀，后面是打乱任务ID
+	ShuffleTmpPrefix     = ".wxgc_shuffle_" // 打乱过程中临时文件名的前缀
+)
+
+const (
+	ShuffleDefaultOrder            = "random"   // 默认的排列方式
+	ShuffleDefaultSplitStrategy    = "balanced" // 默认的拆分方式
+	ShuffleDefaultSplitDirTemplate = "{count}_{index}"
+	// 拆分后子目录名模板中的变量：{name} 原目录名，{count} 原目录中的图片数量，{total} 拆分后的子目录数量，{index} 子目录序号（从 1 开始）
+	ShuffleSplitDirIndexVar = "{index}"
+)
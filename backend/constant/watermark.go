@@ -0,0 +1,9 @@
+package constant
+
+const (
+	WatermarkDefaultPosition = "bottom_right" // 水印默认位置
+	WatermarkDefaultMargin   = 0.03           // 水印与图片边缘的默认距离，占图片宽度的比例
+	WatermarkDefaultOpacity  = 0.8            // 水印默认不透明度
+	WatermarkDefaultScale    = 0.25           // 水印宽度默认占图片宽度的比例
+	WatermarkFontSize        = 96             // 渲染文字水印时的字号，添加到图片上时会按比例缩放
+)
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pudongping/wx-graph-crawl/backend/service"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"go.uber.org/zap"
+)
+
+var _ ContextSetter = (*AlbumHandler)(nil)
+
+type AlbumHandler struct {
+	ctx context.Context
+}
+
+func NewAlbumHandler() *AlbumHandler {
+	return &AlbumHandler{}
+}
+
+func (h *AlbumHandler) SetContext(ctx context.Context) {
+	h.ctx = ctx
+}
+
+// GetAlbumArticles 获取专辑中的文章列表，支持分页参数和断点续传
+func (h *AlbumHandler) GetAlbumArticles(req types.GetAlbumArticlesRequest) (res types.GetAlbumArticlesResponse, err error) {
+	zap.L().Info("开始获取专辑文章", zap.String("请求参数", fmt.Sprintf("%+v", req)))
+	res, err = service.NewAlbumService(req.Paging).GetAlbumArticles(h.ctx, req)
+	zap.L().Info("获取专辑文章结束", zap.Int("文章数量", len(res.Articles)), zap.Bool("是否提前结束", res.Truncated))
+	return
+}
+
+// ExportAlbumArticles 导出专辑文章列表，支持 csv、json、xlsx 格式
+func (h *AlbumHandler) ExportAlbumArticles(req types.ExportAlbumArticlesRequest) (res types.ExportAlbumArticlesResponse, err error) {
+	zap.L().Info("开始导出专辑文章", zap.String("导出目录", req.ExportDir), zap.Any("导出格式", req.Formats))
+	res, err = service.NewAlbumExportService("").ExportAlbumArticles(h.ctx, req)
+	zap.L().Info("导出专辑文章结束", zap.Strings("导出文件", res.FilePaths))
+	return
+}
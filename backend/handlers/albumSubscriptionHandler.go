@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/pudongping/wx-graph-crawl/backend/service"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"go.uber.org/zap"
+)
+
+var _ ContextSetter = (*AlbumSubscriptionHandler)(nil)
+
+type AlbumSubscriptionHandler struct {
+	ctx context.Context
+}
+
+func NewAlbumSubscriptionHandler() *AlbumSubscriptionHandler {
+	return &AlbumSubscriptionHandler{}
+}
+
+func (h *AlbumSubscriptionHandler) SetContext(ctx context.Context) {
+	h.ctx = ctx
+}
+
+// CreateSubscription 订阅专辑，后台会按同步间隔自动抓取新发布的文章
+func (h *AlbumSubscriptionHandler) CreateSubscription(req types.CreateAlbumSubscriptionRequest) (res types.AlbumSubscription, err error) {
+	zap.L().Info("开始订阅专辑", zap.Any("req", req))
+	res, err = service.NewAlbumSubscriptionService().CreateSubscription(h.ctx, req)
+	if err != nil {
+		zap.L().Error("订阅专辑失败", zap.Error(err))
+	}
+	return
+}
+
+// ListSubscriptions 获取全部专辑订阅
+func (h *AlbumSubscriptionHandler) ListSubscriptions() ([]types.AlbumSubscription, error) {
+	return service.NewAlbumSubscriptionService().ListSubscriptions(h.ctx)
+}
+
+// UpdateSubscription 修改专辑订阅的同步间隔或启用状态
+func (h *AlbumSubscriptionHandler) UpdateSubscription(req types.UpdateAlbumSubscriptionRequest) (res types.AlbumSubscription, err error) {
+	zap.L().Info("开始修改专辑订阅", zap.Any("req", req))
+	res, err = service.NewAlbumSubscriptionService().UpdateSubscription(h.ctx, req)
+	if err != nil {
+		zap.L().Error("修改专辑订阅失败", zap.Error(err))
+	}
+	return
+}
+
+// DeleteSubscription 取消专辑订阅
+func (h *AlbumSubscriptionHandler) DeleteSubscription(id int64) error {
+	zap.L().Info("开始取消专辑订阅", zap.Int64("id", id))
+	return service.NewAlbumSubscriptionService().DeleteSubscription(h.ctx, id)
+}
+
+// SyncSubscriptionNow 立即同步专辑订阅
+func (h *AlbumSubscriptionHandler) SyncSubscriptionNow(id int64) error {
+	zap.L().Info("开始手动同步专辑订阅", zap.Int64("id", id))
+	return service.DefaultAlbumSubscriptionScheduler().SyncNow(h.ctx, id)
+}
+
+// ListSubscriptionRuns 查询专辑订阅的同步记录
+func (h *AlbumSubscriptionHandler) ListSubscriptionRuns(req types.ListAlbumSubscriptionRunsRequest) ([]types.AlbumSubscriptionRun, error) {
+	return service.NewAlbumSubscriptionService().ListRuns(h.ctx, req)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pudongping/wx-graph-crawl/backend/service"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"go.uber.org/zap"
+)
+
+var _ ContextSetter = (*BundleHandler)(nil)
+
+type BundleHandler struct {
+	ctx context.Context
+}
+
+func NewBundleHandler() *BundleHandler {
+	return &BundleHandler{}
+}
+
+func (h *BundleHandler) SetContext(ctx context.Context) {
+	h.ctx = ctx
+}
+
+// ListBundleArticles 列出图片保存目录的抓取记录中可以制作发布包的文章
+func (h *BundleHandler) ListBundleArticles(imgSavePath string) ([]types.BundleArticle, error) {
+	articles, err := service.NewBundleService().ListArticles(imgSavePath)
+	if err != nil {
+		zap.L().Error("读取抓取记录失败", zap.String("imgSavePath", imgSavePath), zap.Error(err))
+	}
+	return articles, err
+}
+
+// BuildBundles 按抓取记录制作小绿书发布包
+func (h *BundleHandler) BuildBundles(req types.BuildBundlesRequest) (res types.BuildBundlesResponse, err error) {
+	zap.L().Info("开始制作发布包", zap.String("请求参数", fmt.Sprintf("%+v", req)))
+	res, err = service.NewBundleService().BuildBundles(h.ctx, req)
+	if err != nil {
+		zap.L().Error("制作发布包失败", zap.Error(err))
+		return
+	}
+	zap.L().Info("制作发布包结束", zap.Int("bundles", len(res.Bundles)), zap.String("outputDir", res.OutputDir))
+	return
+}
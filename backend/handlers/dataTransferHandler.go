@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pudongping/wx-graph-crawl/backend/service"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"go.uber.org/zap"
+)
+
+var _ ContextSetter = (*DataTransferHandler)(nil)
+
+type DataTransferHandler struct {
+	ctx context.Context
+}
+
+func NewDataTransferHandler() *DataTransferHandler {
+	return &DataTransferHandler{}
+}
+
+func (h *DataTransferHandler) SetContext(ctx context.Context) {
+	h.ctx = ctx
+}
+
+// ExportData 导出偏好设置、专辑订阅、同步记录和抓取失败记录，用于迁移到另一台电脑
+func (h *DataTransferHandler) ExportData(req types.ExportDataRequest) (res types.ExportDataResponse, err error) {
+	zap.L().Info("开始导出数据", zap.String("请求参数", fmt.Sprintf("%+v", req)))
+	res, err = service.NewDataTransferService().Export(h.ctx, req)
+	if err != nil {
+		zap.L().Error("导出数据失败", zap.Error(err))
+		return
+	}
+	zap.L().Info("导出数据结束", zap.String("返回结果", fmt.Sprintf("%+v", res)))
+	return
+}
+
+// ImportData 导入导出的数据，可以选择合并或替换本机的数据
+func (h *DataTransferHandler) ImportData(req types.ImportDataRequest) (res types.ImportDataResponse, err error) {
+	zap.L().Info("开始导入数据", zap.String("请求参数", fmt.Sprintf("%+v", req)))
+	res, err = service.NewDataTransferService().Import(h.ctx, req)
+	if err != nil {
+		zap.L().Error("导入数据失败", zap.Error(err))
+		return
+	}
+	zap.L().Info("导入数据结束", zap.String("返回结果", fmt.Sprintf("%+v", res)))
+	return
+}
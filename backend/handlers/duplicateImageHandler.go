@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pudongping/wx-graph-crawl/backend/service"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"go.uber.org/zap"
+)
+
+var _ ContextSetter = (*DuplicateImageHandler)(nil)
+
+type DuplicateImageHandler struct {
+	ctx context.Context
+}
+
+func NewDuplicateImageHandler() *DuplicateImageHandler {
+	return &DuplicateImageHandler{}
+}
+
+func (h *DuplicateImageHandler) SetContext(ctx context.Context) {
+	h.ctx = ctx
+}
+
+// FindDuplicateImages 查找目录中的相似图片，按请求中的处理方式只列出、删除或移动到指定目录
+func (h *DuplicateImageHandler) FindDuplicateImages(req types.FindDuplicateImagesRequest) (res types.FindDuplicateImagesResponse, err error) {
+	zap.L().Info("开始查找相似图片", zap.String("请求参数", fmt.Sprintf("%+v", req)))
+	res, err = service.NewDuplicateImageService().FindDuplicates(h.ctx, req)
+	if err != nil {
+		zap.L().Error("查找相似图片失败", zap.Error(err))
+		return
+	}
+	zap.L().Info("查找相似图片结束", zap.Int("groups", len(res.Groups)), zap.Int("handled", res.HandledCount))
+	return
+}
+
+// ReportDuplicateImages 只列出相似图片，不修改文件
+func (h *DuplicateImageHandler) ReportDuplicateImages(imgSavePath string, threshold int) (types.FindDuplicateImagesResponse, error) {
+	return h.FindDuplicateImages(types.FindDuplicateImagesRequest{ImgSavePath: imgSavePath, Threshold: threshold, Action: types.DuplicateImageReport})
+}
+
+// DeleteDuplicateImages 删除相似图片，每组只保留分辨率最大的一张
+func (h *DuplicateImageHandler) DeleteDuplicateImages(imgSavePath string, threshold int) (types.FindDuplicateImagesResponse, error) {
+	return h.FindDuplicateImages(types.FindDuplicateImagesRequest{ImgSavePath: imgSavePath, Threshold: threshold, Action: types.DuplicateImageDelete})
+}
+
+// MoveDuplicateImages 将相似图片移动到 moveDir 中，moveDir 为空时移动到图片目录下的 _duplicates 中
+func (h *DuplicateImageHandler) MoveDuplicateImages(imgSavePath string, threshold int, moveDir string) (types.FindDuplicateImagesResponse, error) {
+	return h.FindDuplicateImages(types.FindDuplicateImagesRequest{ImgSavePath: imgSavePath, Threshold: threshold, Action: types.DuplicateImageMove, MoveDir: moveDir})
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pudongping/wx-graph-crawl/backend/service"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"go.uber.org/zap"
+)
+
+var _ ContextSetter = (*HistoryHandler)(nil)
+
+type HistoryHandler struct {
+	ctx context.Context
+}
+
+func NewHistoryHandler() *HistoryHandler {
+	return &HistoryHandler{}
+}
+
+func (h *HistoryHandler) SetContext(ctx context.Context) {
+	h.ctx = ctx
+}
+
+// ListRuns 分页查询抓取记录
+func (h *HistoryHandler) ListRuns(filter types.CrawlRunFilter, page types.PageRequest) (res types.ListCrawlRunsResponse, err error) {
+	res, err = service.NewCrawlHistoryService().ListRuns(h.ctx, filter, page)
+	if err != nil {
+		zap.L().Error("查询抓取记录失败", zap.Error(err))
+	}
+	return
+}
+
+// GetRun 查询抓取记录的详情，返回的 Request 可以直接用于重新抓取
+func (h *HistoryHandler) GetRun(id int64) (res types.CrawlRunDetail, err error) {
+	res, err = service.NewCrawlHistoryService().GetRun(h.ctx, id)
+	if err != nil {
+		zap.L().Error("查询抓取记录详情失败", zap.Int64("id", id), zap.Error(err))
+	}
+	return
+}
+
+// DeleteRun 删除抓取记录，deleteFiles 为 true 时同时删除这次抓取生成的文件
+func (h *HistoryHandler) DeleteRun(id int64, deleteFiles bool) (res types.DeleteCrawlRunResponse, err error) {
+	zap.L().Info("开始删除抓取记录", zap.Int64("id", id), zap.Bool("deleteFiles", deleteFiles))
+	res, err = service.NewCrawlHistoryService().DeleteRun(h.ctx, id, deleteFiles)
+	if err != nil {
+		zap.L().Error("删除抓取记录失败", zap.Error(err))
+		return
+	}
+	zap.L().Info("删除抓取记录结束", zap.String("返回结果", fmt.Sprintf("%+v", res)))
+	return
+}
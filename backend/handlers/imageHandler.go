@@ -37,9 +37,85 @@ func (h *ImageHandler) Cropping(req types.CroppingRequest) (res types.CroppingRe
 	return
 }
 
+// ProcessImages 按处理步骤处理目录中的全部图片
+func (h *ImageHandler) ProcessImages(req types.ImagePipelineRequest) (res types.ImagePipelineResponse, err error) {
+	zap.L().Info("开始处理图片", zap.String("请求参数", fmt.Sprintf("%+v", req)))
+	res, err = service.NewImageService().ProcessImages(h.ctx, req)
+	zap.L().Info("处理图片结束", zap.String("返回结果", fmt.Sprintf("%+v", res)))
+	return
+}
+
+// ResizeImages 将最长边超过 maxDimension 的图片等比缩小
+func (h *ImageHandler) ResizeImages(imgSavePath string, maxDimension int) (types.ImagePipelineResponse, error) {
+	return h.ProcessImages(types.ImagePipelineRequest{
+		ImgSavePath: imgSavePath,
+		Options:     types.ImagePipelineOptions{MaxDimension: maxDimension},
+	})
+}
+
+// RecompressImages 按指定的 JPEG 质量和 PNG 压缩级别重新压缩图片
+func (h *ImageHandler) RecompressImages(imgSavePath string, jpegQuality int, pngCompression types.PNGCompression) (types.ImagePipelineResponse, error) {
+	return h.ProcessImages(types.ImagePipelineRequest{
+		ImgSavePath: imgSavePath,
+		Options:     types.ImagePipelineOptions{JPEGQuality: jpegQuality, PNGCompression: pngCompression},
+	})
+}
+
+// ConvertImagesToJPEG 将指定格式（如 webp、png）的图片转换为 JPEG
+func (h *ImageHandler) ConvertImagesToJPEG(imgSavePath string, formats []types.ImageFormat) (types.ImagePipelineResponse, error) {
+	return h.ProcessImages(types.ImagePipelineRequest{
+		ImgSavePath: imgSavePath,
+		Options:     types.ImagePipelineOptions{ConvertToJPEG: formats},
+	})
+}
+
+// StripImageMetadata 去掉图片的 EXIF 等元数据，autoOrient 为 true 时先按 EXIF 方向旋转图片
+func (h *ImageHandler) StripImageMetadata(imgSavePath string, autoOrient bool) (types.ImagePipelineResponse, error) {
+	return h.ProcessImages(types.ImagePipelineRequest{
+		ImgSavePath: imgSavePath,
+		Options:     types.ImagePipelineOptions{AutoOrient: autoOrient, StripMetadata: true},
+	})
+}
+
+// Watermarking 给目录中的图片添加文字或图片水印
+func (h *ImageHandler) Watermarking(req types.WatermarkingRequest) (res types.WatermarkingResponse, err error) {
+	zap.L().Info("开始添加水印", zap.String("请求参数", fmt.Sprintf("%+v", req)))
+	res, err = service.NewImageService().Watermarking(h.ctx, req)
+	zap.L().Info("添加水印结束", zap.String("返回结果", fmt.Sprintf("%+v", res)))
+	return
+}
+
+// PreviewCrop 预览裁剪效果，path 可以是一张图片或图片目录，返回裁剪前后的缩略图
+func (h *ImageHandler) PreviewCrop(path string, req types.PreviewCropRequest) (res types.PreviewCropResponse, err error) {
+	zap.L().Info("开始预览裁剪", zap.String("path", path), zap.String("请求参数", fmt.Sprintf("%+v", req)))
+	res, err = service.NewImageService().PreviewCrop(h.ctx, path, req)
+	if err != nil {
+		zap.L().Error("预览裁剪失败", zap.Error(err))
+	}
+	return
+}
+
+// UndoCrop 撤销裁剪，恢复备份的原图或删除输出目录中的图片
+func (h *ImageHandler) UndoCrop(jobID string) (res types.UndoCropResponse, err error) {
+	zap.L().Info("开始撤销裁剪", zap.String("jobID", jobID))
+	res, err = service.NewCropJobService().UndoCrop(h.ctx, jobID)
+	if err != nil {
+		zap.L().Error("撤销裁剪失败", zap.Error(err))
+	}
+	return
+}
+
 func (h *ImageHandler) Shuffling(req types.ShufflingRequest) (res types.ShufflingResponse, err error) {
 	zap.L().Info("开始移动图片", zap.String("请求参数", fmt.Sprintf("%+v", req)))
 	res, err = service.NewImageService().Shuffling(h.ctx, req)
-	zap.L().Info("移动图片结束", zap.String("返回结果", fmt.Sprintf("%+v", res)))
+	zap.L().Info("移动图片结束", zap.Int("moves", len(res.Moves)), zap.String("journal", res.JournalPath), zap.String("castTime", res.CastTimeStr))
+	return
+}
+
+// UndoShuffle 撤销打乱，journalPath 为打乱时返回的打乱日志路径
+func (h *ImageHandler) UndoShuffle(journalPath string) (res types.UndoShuffleResponse, err error) {
+	zap.L().Info("开始撤销打乱", zap.String("journalPath", journalPath))
+	res, err = service.NewImageService().UndoShuffle(h.ctx, journalPath)
+	zap.L().Info("撤销打乱结束", zap.String("返回结果", fmt.Sprintf("%+v", res)))
 	return
 }
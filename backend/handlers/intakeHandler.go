@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/pudongping/wx-graph-crawl/backend/service"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"go.uber.org/zap"
+)
+
+var _ ContextSetter = (*IntakeHandler)(nil)
+
+type IntakeHandler struct {
+	ctx context.Context
+}
+
+func NewIntakeHandler() *IntakeHandler {
+	return &IntakeHandler{}
+}
+
+func (h *IntakeHandler) SetContext(ctx context.Context) {
+	h.ctx = ctx
+}
+
+// ImportDroppedFiles 导入拖拽的 txt、csv、json、html、md 文件中的文章地址
+func (h *IntakeHandler) ImportDroppedFiles(paths []string) types.IntakeEvent {
+	zap.L().Info("导入拖拽的文件", zap.Strings("paths", paths))
+	return service.NewIntakeService().ImportDroppedFiles(paths)
+}
+
+// MergePendingURLs 将新的文章地址合并到待抓取列表，跳过已在列表中或已经抓取过的文章
+func (h *IntakeHandler) MergePendingURLs(req types.MergePendingURLsRequest) types.MergePendingURLsResponse {
+	return service.NewIntakeService().MergePendingURLs(req)
+}
+
+// SetClipboardWatch 开启或关闭剪贴板监听
+func (h *IntakeHandler) SetClipboardWatch(enabled bool) error {
+	zap.L().Info("设置剪贴板监听", zap.Bool("enabled", enabled))
+	if enabled {
+		service.DefaultClipboardWatcher().Start(h.ctx)
+	} else {
+		service.DefaultClipboardWatcher().Stop()
+	}
+	return nil
+}
+
+// GetClipboardWatch 是否正在监听剪贴板
+func (h *IntakeHandler) GetClipboardWatch() bool {
+	return service.DefaultClipboardWatcher().Enabled()
+}
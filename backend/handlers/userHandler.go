@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 
+	"github.com/pkg/errors"
 	"github.com/pudongping/wx-graph-crawl/backend/service"
 	"github.com/pudongping/wx-graph-crawl/backend/types"
 )
@@ -22,11 +23,32 @@ func (h *UserHandler) SetContext(ctx context.Context) {
 }
 
 // SetPreferenceInfo 设置用户偏好信息
+// 校验失败时不返回错误，而是在 field_errors 中返回每个设置项的错误，方便前端显示在对应的表单项下
 func (h *UserHandler) SetPreferenceInfo(req types.SetPreferenceInfoRequest) (types.SetPreferenceInfoResponse, error) {
-	return service.NewUserService().SetPreferenceInfo(h.ctx, req)
+	res, err := service.NewUserService().SetPreferenceInfo(h.ctx, req)
+	var validationErr *types.SettingsValidationError
+	if errors.As(err, &validationErr) {
+		return res, nil
+	}
+	return res, err
 }
 
 // GetPreferenceInfo 获取用户偏好信息
 func (h *UserHandler) GetPreferenceInfo() (*types.GetPreferenceInfoResponse, error) {
 	return service.NewUserService().GetPreferenceInfo()
 }
+
+// GetSettingsSchema 获取全部设置项的类型、默认值、范围和说明
+func (h *UserHandler) GetSettingsSchema() []types.SettingDefinition {
+	return service.SettingsSchema()
+}
+
+// ListPreferenceHistory 获取偏好设置的修改记录
+func (h *UserHandler) ListPreferenceHistory() ([]types.SettingHistoryItem, error) {
+	return service.NewUserService().ListPreferenceHistory(h.ctx)
+}
+
+// RollbackPreferenceInfo 把偏好设置恢复为指定版本
+func (h *UserHandler) RollbackPreferenceInfo(version int) (types.RollbackPreferenceInfoResponse, error) {
+	return service.NewUserService().RollbackPreferenceInfo(h.ctx, version)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pudongping/wx-graph-crawl/backend/global"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"github.com/pudongping/wx-graph-crawl/backend/utils"
+	"go.uber.org/zap"
+)
+
+// utf8BOM 写在CSV文件开头，避免Excel打开中文内容时乱码
+const utf8BOM = "\xEF\xBB\xBF"
+
+// albumExportHeader 专辑文章列表导出的表头
+var albumExportHeader = []string{"序号", "标题", "地址", "发布时间", "msgid", "封面", "阅读数"}
+
+// AlbumExportService 专辑文章列表导出服务
+type AlbumExportService struct {
+	ExportDir string // 导出目录
+}
+
+func NewAlbumExportService(exportDir string) *AlbumExportService {
+	return &AlbumExportService{
+		ExportDir: exportDir,
+	}
+}
+
+// ExportAlbumArticles 导出专辑文章列表，提供给前端调用
+func (svc *AlbumExportService) ExportAlbumArticles(ctx context.Context, req types.ExportAlbumArticlesRequest) (res types.ExportAlbumArticlesResponse, err error) {
+	if len(req.Album.Articles) == 0 {
+		return res, errors.New("没有可以导出的专辑文章")
+	}
+	if svc.ExportDir == "" {
+		svc.ExportDir = resolveAlbumExportDir(req.ExportDir)
+	}
+
+	res.ExportDir = svc.ExportDir
+	res.FilePaths, err = svc.Export(req.Album, req.Formats)
+	return res, err
+}
+
+// resolveAlbumExportDir 确定导出目录：优先使用用户选择的目录，其次使用偏好设置中的保存路径，最后使用系统的下载目录
+func resolveAlbumExportDir(exportDir string) string {
+	if exportDir != "" {
+		return exportDir
+	}
+	if global.DB != nil {
+		pref, err := NewUserService().GetPreferenceInfo()
+		if err != nil {
+			zap.L().Error("获取偏好设置失败，使用默认下载目录", zap.Error(err))
+		} else if pref != nil && pref.SaveImgPath != "" {
+			return pref.SaveImgPath
+		}
+	}
+	return utils.GetDefaultDownloadsDir()
+}
+
+// Export 按照给定的格式导出专辑文章列表，返回导出的文件路径
+func (svc *AlbumExportService) Export(result types.AlbumFetchResult, formats []types.AlbumExportFormat) (filePaths []string, err error) {
+	if len(formats) == 0 {
+		formats = []types.AlbumExportFormat{types.AlbumExportFormatCSV}
+	}
+	if err = utils.MkdirIfNotExist(svc.ExportDir); err != nil {
+		return nil, errors.Wrap(err, "创建导出目录失败")
+	}
+
+	// 生成文件名：NickName_Title.ext
+	baseName := fmt.Sprintf("%s_%s", sanitizeFilename(result.NickName), sanitizeFilename(result.AlbumTitle))
+	for _, format := range formats {
+		filePath := filepath.Join(svc.ExportDir, baseName+"."+string(format))
+		switch format {
+		case types.AlbumExportFormatCSV:
+			err = svc.exportCSV(result, filePath)
+		case types.AlbumExportFormatJSON:
+			err = svc.exportJSON(result, filePath)
+		case types.AlbumExportFormatXLSX:
+			err = NewXlsxService(result.AlbumTitle).CreateWorkbook(filePath, albumExportHeader, svc.buildRows(result))
+		default:
+			err = errors.Errorf("不支持的导出格式: %s", format)
+		}
+		if err != nil {
+			return filePaths, errors.Wrapf(err, "导出 %s 文件失败", format)
+		}
+		zap.L().Info("成功导出专辑文章列表", zap.String("filePath", filePath))
+		filePaths = append(filePaths, filePath)
+	}
+
+	return filePaths, nil
+}
+
+// buildRows 将文章信息转换为表格数据行
+func (svc *AlbumExportService) buildRows(result types.AlbumFetchResult) [][]string {
+	rows := make([][]string, 0, len(result.Articles))
+	for _, article := range result.Articles {
+		createTime := ""
+		if article.CreateTime > 0 {
+			createTime = time.Unix(article.CreateTime, 0).Format("2006-01-02 15:04:05")
+		}
+		readCount := ""
+		if article.ReadCount >= 0 {
+			readCount = strconv.FormatInt(article.ReadCount, 10)
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(article.Index),
+			article.Title,
+			article.URL,
+			createTime,
+			article.MsgID,
+			article.CoverURL,
+			readCount,
+		})
+	}
+	return rows
+}
+
+// exportCSV 使用 encoding/csv 导出CSV文件，并在文件开头写入 UTF-8 BOM 方便 Excel 识别编码
+func (svc *AlbumExportService) exportCSV(result types.AlbumFetchResult, filePath string) error {
+	var buf bytes.Buffer
+	buf.WriteString(utf8BOM)
+
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(albumExportHeader); err != nil {
+		return errors.Wrap(err, "写入CSV表头失败")
+	}
+	if err := writer.WriteAll(svc.buildRows(result)); err != nil {
+		return errors.Wrap(err, "写入CSV内容失败")
+	}
+
+	return os.WriteFile(filePath, buf.Bytes(), 0644)
+}
+
+// exportJSON 导出JSON文件，保留专辑信息和每篇文章的完整字段
+func (svc *AlbumExportService) exportJSON(result types.AlbumFetchResult, filePath string) error {
+	content, err := json.MarshalIndent(struct {
+		AlbumTitle   string                   `json:"album_title"`
+		NickName     string                   `json:"nick_name"`
+		ArticleCount int                      `json:"article_count"`
+		ExportedAt   string                   `json:"exported_at"`
+		Articles     []types.AlbumArticleInfo `json:"articles"`
+	}{
+		AlbumTitle:   result.AlbumTitle,
+		NickName:     result.NickName,
+		ArticleCount: result.ArticleCount,
+		ExportedAt:   time.Now().Format(time.RFC3339),
+		Articles:     result.Articles,
+	}, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "序列化JSON失败")
+	}
+
+	return os.WriteFile(filePath, content, 0644)
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+)
+
+func TestAlbumExport(t *testing.T) {
+	album := types.AlbumFetchResult{
+		AlbumTitle:   "干货/分享集",
+		NickName:     `一安:未来?`,
+		ArticleCount: 2,
+		Articles: []types.AlbumArticleInfo{
+			{Index: 2, Title: `标题, 带 "引号"`, URL: "https://mp.weixin.qq.com/s/a", MsgID: "2247522415", CreateTime: 1756854000, CoverURL: "https://mmbiz.qpic.cn/a", ReadCount: 12},
+			{Index: 1, Title: "<b>&</b>", URL: "https://mp.weixin.qq.com/s/b", ReadCount: -1},
+		},
+	}
+	svc := NewAlbumExportService(t.TempDir())
+	filePaths, err := svc.Export(album, []types.AlbumExportFormat{types.AlbumExportFormatCSV, types.AlbumExportFormatJSON, types.AlbumExportFormatXLSX})
+	if err != nil || len(filePaths) != 3 {
+		t.Fatalf("导出结果为 %v, %v", filePaths, err)
+	}
+	// 公众号名称和专辑标题中的特殊字符都会被替换，文件直接保存在导出目录中
+	for _, filePath := range filePaths {
+		if filepath.Dir(filePath) != svc.ExportDir || !strings.HasPrefix(filepath.Base(filePath), "一安_未来__干货_分享集.") {
+			t.Errorf("导出文件路径为 %s", filePath)
+		}
+	}
+	wantRows := [][]string{
+		albumExportHeader,
+		{"2", `标题, 带 "引号"`, "https://mp.weixin.qq.com/s/a", time.Unix(1756854000, 0).Format("2006-01-02 15:04:05"), "2247522415", "https://mmbiz.qpic.cn/a", "12"},
+		{"1", "<b>&</b>", "https://mp.weixin.qq.com/s/b", "", "", "", ""},
+	}
+
+	// CSV：UTF-8 BOM 开头，按表头顺序输出每一列
+	content, err := os.ReadFile(filePaths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content), utf8BOM) {
+		t.Error("CSV 文件没有以 UTF-8 BOM 开头")
+	}
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(content), utf8BOM))).ReadAll()
+	if err != nil || !reflect.DeepEqual(rows, wantRows) {
+		t.Errorf("CSV 内容为 %q, %v", rows, err)
+	}
+
+	// JSON：保留专辑信息和文章的完整字段
+	content, err = os.ReadFile(filePaths[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exported struct {
+		AlbumTitle   string                   `json:"album_title"`
+		NickName     string                   `json:"nick_name"`
+		ArticleCount int                      `json:"article_count"`
+		ExportedAt   string                   `json:"exported_at"`
+		Articles     []types.AlbumArticleInfo `json:"articles"`
+	}
+	if err = json.Unmarshal(content, &exported); err != nil {
+		t.Fatal(err)
+	}
+	if exported.AlbumTitle != album.AlbumTitle || exported.NickName != album.NickName || exported.ArticleCount != 2 ||
+		exported.ExportedAt == "" || !reflect.DeepEqual(exported.Articles, album.Articles) {
+		t.Errorf("JSON 内容为 %+v", exported)
+	}
+
+	// XLSX：与 CSV 的内容一致
+	if got := readXlsxRows(t, filePaths[2]); !reflect.DeepEqual(got, wantRows) {
+		t.Errorf("XLSX 内容为 %q", got)
+	}
+
+	if _, err = svc.Export(album, []types.AlbumExportFormat{"pdf"}); err == nil {
+		t.Error("不支持的导出格式应返回错误")
+	}
+}
+
+// readXlsxRows 读取 XlsxService 生成的工作表中的内联字符串
+func readXlsxRows(t *testing.T, filePath string) [][]string {
+	t.Helper()
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		t.Fatalf("打开 xlsx 文件失败: %v", err)
+	}
+	defer reader.Close()
+	file, err := reader.Open("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatalf("xlsx 文件中没有工作表: %v", err)
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref  string `xml:"r,attr"`
+				Text string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err = xml.Unmarshal(content, &sheet); err != nil {
+		t.Fatalf("解析工作表失败: %v", err)
+	}
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		cells := make([]string, 0, len(row.Cells))
+		for _, cell := range row.Cells {
+			cells = append(cells, cell.Text)
+		}
+		rows = append(rows, cells)
+	}
+	return rows
+}
@@ -1,9 +1,18 @@
 package service
 
 import (
+	"context"
 	"fmt"
-	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"go.uber.org/zap"
 )
 
 var urls []string = []string{
@@ -52,3 +61,176 @@ func TestRunSpiderAlbum(t *testing.T) {
 		}
 	}
 }
+
+// albumFixtureTransport 用 testdata 中保存的专辑首页和分页接口响应代替网络请求
+// pages 的 key 为分页请求的 begin_msgid
+type albumFixtureTransport struct {
+	index string
+	pages map[string]string
+}
+
+func (tr *albumFixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, status := tr.index, http.StatusOK
+	if req.URL.Query().Get("f") == "json" {
+		page, ok := tr.pages[req.URL.Query().Get("begin_msgid")]
+		body, status = page, http.StatusOK
+		if !ok {
+			status = http.StatusNotFound
+		}
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func readAlbumFixture(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("读取样例失败: %v", err)
+	}
+	return string(b)
+}
+
+// newAlbumFixtureService 专辑首页有3篇文章，之后依次是3页各10篇文章，第3页之后的请求返回 tail
+func newAlbumFixtureService(t *testing.T, maxPages int, tail string, edit func(string) string) *AlbumService {
+	t.Helper()
+	if edit == nil {
+		edit = func(s string) string { return s }
+	}
+	svc := NewAlbumService(types.AlbumPagingOptions{IntervalMillis: 1, MaxPages: maxPages})
+	svc.HttpClient = &http.Client{Transport: &albumFixtureTransport{
+		index: readAlbumFixture(t, "album_index.html"),
+		pages: map[string]string{
+			"2247522829": edit(readAlbumFixture(t, "album_page_1.json")),
+			"2247522067": edit(readAlbumFixture(t, "album_page_2.json")),
+			"2247521903": edit(readAlbumFixture(t, "album_page_3.json")),
+			"2247521539": tail,
+		},
+	}}
+	return svc
+}
+
+func TestFetchAlbumArticlesTruncated(t *testing.T) {
+	cases := []struct {
+		name      string
+		maxPages  int
+		tail      string
+		wantErr   bool
+		reason    types.AlbumTruncatedReason
+		pageCount int
+		articles  int
+		cursor    string
+	}{
+		{name: "最大请求次数", maxPages: 2, reason: types.AlbumTruncatedMaxPages, pageCount: 2, articles: 23, cursor: "2247521903:23"},
+		{name: "不限制请求次数", maxPages: 0, tail: `{"base_resp":{"ret":0},"getalbum_resp":{"article_list":[],"continue_flag":"1"}}`,
+			reason: types.AlbumTruncatedEmptyPage, pageCount: 4, articles: 33, cursor: "2247521539:33"},
+		{name: "重复数据", maxPages: 0, tail: "page_3", reason: types.AlbumTruncatedRepeatedPage, pageCount: 4, articles: 33, cursor: "2247521539:33"},
+		{name: "请求失败", maxPages: 0, tail: `{"base_resp":{"ret":-1}}`, wantErr: true,
+			reason: types.AlbumTruncatedRequestFailed, pageCount: 4, articles: 33, cursor: "2247521539:33"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if c.tail == "page_3" {
+				c.tail = readAlbumFixture(t, "album_page_3.json")
+			}
+			svc := newAlbumFixtureService(t, c.maxPages, c.tail, nil)
+			result, err := svc.FetchAlbumArticles(urls[0], "")
+			if (err != nil) != c.wantErr {
+				t.Fatalf("错误为 %v", err)
+			}
+			if !result.Truncated || result.TruncatedReason != c.reason || result.PageCount != c.pageCount ||
+				len(result.Articles) != c.articles || result.NextBeginMsgID != c.cursor {
+				t.Errorf("获取结果为 truncated=%v reason=%s pages=%d articles=%d cursor=%s",
+					result.Truncated, result.TruncatedReason, result.PageCount, len(result.Articles), result.NextBeginMsgID)
+			}
+			// 专辑显示序号且倒序展示：首页文章没有编号时按展示位置计算，分页文章使用接口返回的编号
+			if result.Articles[0].Index != 490 || result.Articles[2].Index != 488 || result.Articles[3].Index != 477 {
+				t.Errorf("专辑序号为 %d %d %d", result.Articles[0].Index, result.Articles[2].Index, result.Articles[3].Index)
+			}
+		})
+	}
+
+	// 中途失败时仍然返回已获取的文章和游标
+	svc := newAlbumFixtureService(t, 0, `{"base_resp":{"ret":-1}}`, nil)
+	res, err := svc.GetAlbumArticles(context.Background(), types.GetAlbumArticlesRequest{AlbumURL: urls[0]})
+	if err != nil || res.ErrContent == "" || len(res.Articles) != 33 || res.NextBeginMsgID != "2247521539:33" {
+		t.Errorf("中途失败的结果为 %+v, %v", res.AlbumFetchResult, err)
+	}
+}
+
+func TestFetchAlbumArticlesResume(t *testing.T) {
+	// 分页接口没有返回编号时，从游标继续获取的文章按展示位置接着编号
+	noPosNum := regexp.MustCompile(`\s*"pos_num": "\d+",`)
+	lastPage := func(s string) string {
+		s = noPosNum.ReplaceAllString(s, "")
+		if strings.Contains(s, `"msgid": "2247521539"`) {
+			s = strings.Replace(s, `"continue_flag": "1"`, `"continue_flag": "0"`, 1)
+		}
+		return s
+	}
+	first, err := newAlbumFixtureService(t, 1, "", lastPage).FetchAlbumArticles(urls[0], "")
+	if err != nil || first.NextBeginMsgID != "2247522067:13" || first.Articles[12].Index != 478 {
+		t.Fatalf("第一次获取的结果为 %+v, %v", first, err)
+	}
+
+	result, err := newAlbumFixtureService(t, 0, "", lastPage).FetchAlbumArticles(urls[0], first.NextBeginMsgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Truncated || result.NextBeginMsgID != "" || result.PageCount != 2 || len(result.Articles) != 20 {
+		t.Fatalf("继续获取的结果为 truncated=%v cursor=%s pages=%d articles=%d",
+			result.Truncated, result.NextBeginMsgID, result.PageCount, len(result.Articles))
+	}
+	if result.Articles[0].Index != 477 || result.Articles[19].Index != 458 {
+		t.Errorf("继续获取的专辑序号为 %d 到 %d", result.Articles[0].Index, result.Articles[19].Index)
+	}
+}
+
+func TestParseAlbumCursor(t *testing.T) {
+	cases := map[string]struct {
+		msgID    string
+		position int
+	}{
+		"2247522067:13": {"2247522067", 13},
+		"2247522067":    {"2247522067", 0},
+		"2247522067:-1": {"2247522067", 0},
+	}
+	for cursor, want := range cases {
+		if msgID, position := parseAlbumCursor(cursor); msgID != want.msgID || position != want.position {
+			t.Errorf("%s 解析为 %s %d", cursor, msgID, position)
+		}
+	}
+	if formatAlbumCursor("", 3) != "" {
+		t.Error("没有 msgid 时游标应为空")
+	}
+}
+
+func TestAlbumArticleIndex(t *testing.T) {
+	cases := []struct {
+		name               string
+		numbered, reverse  int
+		total, posNum, pos int
+		want               int
+	}{
+		{"显示序号倒序-接口编号", 1, 1, 490, 477, 14, 477},
+		{"显示序号倒序-无编号", 1, 1, 490, 0, 1, 490},
+		{"显示序号倒序-无编号第3篇", 1, 1, 490, 0, 3, 488},
+		{"显示序号倒序-总数未知", 1, 1, 0, 0, 5, 5},
+		{"显示序号正序-无编号", 1, 0, 490, 0, 5, 5},
+		{"显示序号正序-接口编号", 1, 0, 490, 7, 3, 7},
+		{"不显示序号倒序-接口编号", 0, 1, 10, 10, 1, 1},
+		{"不显示序号倒序-无编号", 0, 1, 10, 0, 2, 2},
+		{"不显示序号正序-接口编号", 0, 0, 10, 3, 9, 3},
+		{"不显示序号正序-无编号", 0, 0, 10, 0, 4, 4},
+	}
+	for _, c := range cases {
+		indexResp := CgiData{IsNumbered: c.numbered, IsReverse: c.reverse, ArticleCount: c.total}
+		if got := albumArticleIndex(indexResp, c.posNum, c.pos); got != c.want {
+			t.Errorf("%s: 专辑序号为 %d，期望为 %d", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/global"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"github.com/pudongping/wx-graph-crawl/backend/utils"
+	"go.uber.org/zap"
+)
+
+// albumSubscriptionCrawlJob 待抓取的新文章
+type albumSubscriptionCrawlJob struct {
+	sub      types.AlbumSubscription
+	run      types.AlbumSubscriptionRun
+	articles []types.AlbumArticleInfo
+}
+
+// AlbumSubscriptionScheduler 专辑订阅调度器
+// 定时同步到期的订阅，发现新文章后放入抓取队列，由单独的协程按顺序抓取，避免同时发起过多请求
+type AlbumSubscriptionScheduler struct {
+	SubscriptionSvc *AlbumSubscriptionService
+	CheckInterval   time.Duration
+
+	// 获取专辑文章和抓取文章的方法，方便在测试中替换
+	FetchAlbum func(albumURL string) (types.AlbumFetchResult, error)
+	Crawl      func(ctx context.Context, req types.CrawlingRequest) (types.CrawlingResponse, error)
+
+	crawlQueue chan albumSubscriptionCrawlJob
+	mu         sync.Mutex
+	pending    map[int64]struct{} // 正在同步或等待抓取的订阅，避免重复同步
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+var (
+	albumSubscriptionScheduler     *AlbumSubscriptionScheduler
+	albumSubscriptionSchedulerOnce sync.Once
+)
+
+// DefaultAlbumSubscriptionScheduler 全局唯一的专辑订阅调度器
+func DefaultAlbumSubscriptionScheduler() *AlbumSubscriptionScheduler {
+	albumSubscriptionSchedulerOnce.Do(func() {
+		albumSubscriptionScheduler = NewAlbumSubscriptionScheduler(NewAlbumSubscriptionService())
+	})
+	return albumSubscriptionScheduler
+}
+
+func NewAlbumSubscriptionScheduler(subscriptionSvc *AlbumSubscriptionService) *AlbumSubscriptionScheduler {
+	return &AlbumSubscriptionScheduler{
+		SubscriptionSvc: subscriptionSvc,
+		CheckInterval:   constant.AlbumSubscriptionCheckInterval,
+		FetchAlbum: func(albumURL string) (types.AlbumFetchResult, error) {
+			return NewAlbumService(types.AlbumPagingOptions{}).FetchAlbumArticles(albumURL, "")
+		},
+		Crawl:      NewImageService().Crawling,
+		crawlQueue: make(chan albumSubscriptionCrawlJob, constant.AlbumSubscriptionCrawlQueueSize),
+		pending:    make(map[int64]struct{}),
+	}
+}
+
+// Start 启动调度器，重复调用时不会重复启动
+func (s *AlbumSubscriptionScheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(2)
+	go s.syncLoop(ctx)
+	go s.crawlLoop(ctx)
+	zap.L().Info("专辑订阅调度器已启动", zap.Duration("check_interval", s.CheckInterval))
+}
+
+// Stop 停止调度器，等待正在进行的同步结束
+func (s *AlbumSubscriptionScheduler) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	s.wg.Wait()
+	zap.L().Info("专辑订阅调度器已停止")
+}
+
+// SyncNow 立即同步指定的订阅，不等待同步结束，Stop 会等待同步结束后再返回
+func (s *AlbumSubscriptionScheduler) SyncNow(ctx context.Context, id int64) error {
+	sub, err := s.SubscriptionSvc.GetSubscription(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !s.acquire(sub.ID) {
+		return errors.New("该专辑正在同步中，请稍后再试")
+	}
+
+	// 在锁中判断是否已停止并计数，避免 Stop 等待结束后才开始同步
+	s.mu.Lock()
+	if s.cancel == nil {
+		s.mu.Unlock()
+		s.release(sub.ID)
+		return errors.New("专辑订阅调度器没有启动")
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		if _, err := s.syncSubscription(context.Background(), sub); err != nil {
+			zap.L().Error("手动同步专辑订阅失败", zap.Int64("subscription_id", sub.ID), zap.Error(err))
+		}
+	}()
+	return nil
+}
+
+func (s *AlbumSubscriptionScheduler) syncLoop(ctx context.Context) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.syncDueSubscriptions(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncDueSubscriptions 按顺序同步所有到期的订阅
+func (s *AlbumSubscriptionScheduler) syncDueSubscriptions(ctx context.Context) {
+	subs, err := s.SubscriptionSvc.DueSubscriptions(ctx, time.Now().Unix())
+	if err != nil {
+		zap.L().Error("查询到期的专辑订阅失败", zap.Error(err))
+		return
+	}
+
+	for _, sub := range subs {
+		if ctx.Err() != nil {
+			return
+		}
+		if !s.acquire(sub.ID) {
+			continue
+		}
+		if _, err = s.syncSubscription(ctx, sub); err != nil {
+			zap.L().Error("同步专辑订阅失败", zap.Int64("subscription_id", sub.ID), zap.String("album_url", sub.AlbumURL), zap.Error(err))
+		}
+	}
+}
+
+// syncSubscription 同步单个订阅，有新文章时放入抓取队列
+// 调用前需要先调用 acquire，没有放入抓取队列时会在这里释放
+func (s *AlbumSubscriptionScheduler) syncSubscription(ctx context.Context, sub types.AlbumSubscription) (run types.AlbumSubscriptionRun, err error) {
+	queued := false
+	defer func() {
+		if !queued {
+			s.release(sub.ID)
+		}
+	}()
+
+	run = types.AlbumSubscriptionRun{
+		SubscriptionID: sub.ID,
+		Status:         types.AlbumSubscriptionRunSyncing,
+		StartedAt:      time.Now().Unix(),
+	}
+	if err = s.SubscriptionSvc.CreateRun(ctx, &run); err != nil {
+		return run, err
+	}
+
+	// 无论同步是否成功，都按间隔计算下次同步时间，避免失败时频繁请求
+	defer func() {
+		if markErr := s.SubscriptionSvc.MarkSynced(ctx, sub, time.Now().Unix()); markErr != nil && err == nil {
+			err = markErr
+		}
+	}()
+
+	result, fetchErr := s.FetchAlbum(sub.AlbumURL)
+	if result.AlbumTitle != "" {
+		sub.Title = result.AlbumTitle
+		sub.NickName = result.NickName
+	}
+	run.ArticleCount = len(result.Articles)
+	if fetchErr != nil {
+		// 中途失败时，只处理已经获取到的文章
+		run.ErrContent = fetchErr.Error()
+		if len(result.Articles) == 0 {
+			return run, s.finishRun(ctx, run, types.AlbumSubscriptionRunFailed)
+		}
+	}
+
+	seenCount, err := s.SubscriptionSvc.SeenArticleCount(ctx, sub.ID)
+	if err != nil {
+		return run, err
+	}
+	newArticles, err := s.SubscriptionSvc.FilterNewArticles(ctx, sub.ID, result.Articles)
+	if err != nil {
+		return run, err
+	}
+	run.NewArticleCount = len(newArticles)
+
+	switch {
+	case len(newArticles) == 0:
+		return run, s.finishRun(ctx, run, types.AlbumSubscriptionRunNoChange)
+	case seenCount == 0 && !sub.CrawlExisting && (fetchErr != nil || result.Truncated):
+		// 只获取到部分文章时不能作为基准，否则下次同步会把没有记录的旧文章当作新文章全部抓取
+		if fetchErr == nil {
+			run.ErrContent = "首次同步没有获取到专辑的全部文章：" + string(result.TruncatedReason)
+		}
+		run.ErrContent += "，等待下次同步"
+		return run, s.finishRun(ctx, run, types.AlbumSubscriptionRunFailed)
+	case seenCount == 0 && !sub.CrawlExisting:
+		// 首次同步时只记录已有的文章，之后新发布的文章才会被抓取
+		if err = s.SubscriptionSvc.MarkArticlesSeen(ctx, sub.ID, newArticles); err != nil {
+			return run, err
+		}
+		return run, s.finishRun(ctx, run, types.AlbumSubscriptionRunBaseline)
+	}
+
+	run.Status = types.AlbumSubscriptionRunQueued
+	if err = s.SubscriptionSvc.UpdateRun(ctx, run); err != nil {
+		return run, err
+	}
+	select {
+	case s.crawlQueue <- albumSubscriptionCrawlJob{sub: sub, run: run, articles: newArticles}:
+		queued = true
+		zap.L().Info("专辑有新文章，已加入抓取队列", zap.Int64("subscription_id", sub.ID), zap.Int("new_article_count", len(newArticles)))
+	default:
+		run.ErrContent = "抓取队列已满，等待下次同步"
+		return run, s.finishRun(ctx, run, types.AlbumSubscriptionRunFailed)
+	}
+
+	return run, nil
+}
+
+func (s *AlbumSubscriptionScheduler) crawlLoop(ctx context.Context) {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-s.crawlQueue:
+			s.crawlJob(ctx, job)
+		}
+	}
+}
+
+// crawlJob 使用用户的偏好设置抓取新文章，只把抓取成功的文章记录为已同步，失败的文章下次同步时重新抓取
+func (s *AlbumSubscriptionScheduler) crawlJob(ctx context.Context, job albumSubscriptionCrawlJob) {
+	defer s.release(job.sub.ID)
+
+	run := job.run
+	req := s.buildCrawlingRequest(job.sub, job.articles)
+	run.SavePath = req.ImgSavePath
+	run.Status = types.AlbumSubscriptionRunCrawling
+	if err := s.SubscriptionSvc.UpdateRun(ctx, run); err != nil {
+		zap.L().Error("更新同步记录失败", zap.Int64("run_id", run.ID), zap.Error(err))
+	}
+
+	res, err := s.Crawl(ctx, req)
+	if err != nil {
+		run.ErrContent = err.Error()
+		_ = s.finishRun(ctx, run, types.AlbumSubscriptionRunFailed)
+		return
+	}
+
+	run.CrawlImgCount = res.CrawlImgCount
+	if res.ErrContent != "" {
+		run.ErrContent = res.ErrContent
+	}
+	succeeded := succeededAlbumArticles(job.articles, res.SucceededURLs)
+	if err = s.SubscriptionSvc.MarkArticlesSeen(ctx, job.sub.ID, succeeded); err != nil {
+		run.ErrContent = err.Error()
+		_ = s.finishRun(ctx, run, types.AlbumSubscriptionRunFailed)
+		return
+	}
+
+	status := types.AlbumSubscriptionRunSuccess
+	switch {
+	case len(succeeded) == 0:
+		status = types.AlbumSubscriptionRunFailed
+	case len(succeeded) < len(job.articles):
+		status = types.AlbumSubscriptionRunPartial
+	}
+	_ = s.finishRun(ctx, run, status)
+}
+
+// succeededAlbumArticles 按文章标识筛选出抓取成功的文章
+func succeededAlbumArticles(articles []types.AlbumArticleInfo, succeededURLs []string) []types.AlbumArticleInfo {
+	keys := make(map[string]struct{}, len(succeededURLs))
+	for _, u := range succeededURLs {
+		keys[utils.WXArticleKey(u)] = struct{}{}
+	}
+	succeeded := make([]types.AlbumArticleInfo, 0, len(articles))
+	for _, article := range articles {
+		if _, ok := keys[utils.WXArticleKey(article.URL)]; ok {
+			succeeded = append(succeeded, article)
+		}
+	}
+	return succeeded
+}
+
+// buildCrawlingRequest 根据偏好设置构建抓取参数，每次抓取保存在单独的目录中，避免覆盖之前的内容
+func (s *AlbumSubscriptionScheduler) buildCrawlingRequest(sub types.AlbumSubscription, articles []types.AlbumArticleInfo) types.CrawlingRequest {
+	saveDir := ""
+	timeoutSeconds := int64(constant.AlbumSubscriptionCrawlTimeoutSeconds)
+	if global.DB != nil {
+		pref, err := NewUserService().GetPreferenceInfo()
+		if err != nil {
+			zap.L().Error("获取偏好设置失败，使用默认设置", zap.Error(err))
+		}
+		if pref != nil {
+			saveDir = pref.SaveImgPath
+			if pref.DownloadTimeout > 0 {
+				timeoutSeconds = int64(pref.DownloadTimeout)
+			}
+		}
+	}
+	if saveDir == "" {
+		saveDir = utils.GetDefaultDownloadsDir()
+	}
+
+	albumDir := sanitizeFilename(sub.NickName + "_" + sub.Title)
+	if sub.Title == "" {
+		albumDir = sub.AlbumID
+	}
+
+	return types.CrawlingRequest{
+		ImgSavePath:    filepath.Join(saveDir, albumDir, time.Now().Format(constant.AlbumSubscriptionSaveDirTimeLayout)),
+		TimeoutSeconds: timeoutSeconds,
+		AlbumArticles:  articles,
+	}
+}
+
+func (s *AlbumSubscriptionScheduler) finishRun(ctx context.Context, run types.AlbumSubscriptionRun, status types.AlbumSubscriptionRunStatus) error {
+	run.Status = status
+	run.FinishedAt = time.Now().Unix()
+	if err := s.SubscriptionSvc.UpdateRun(ctx, run); err != nil {
+		zap.L().Error("更新同步记录失败", zap.Int64("run_id", run.ID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// acquire 标记订阅正在处理中，已经在处理中时返回 false
+func (s *AlbumSubscriptionScheduler) acquire(id int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.pending[id]; ok {
+		return false
+	}
+	s.pending[id] = struct{}{}
+	return true
+}
+
+func (s *AlbumSubscriptionScheduler) release(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, id)
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pudongping/wx-graph-crawl/backend/bootstrap"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+)
+
+// openTestDB 在当前测试的临时目录中创建一个空数据库，测试结束后删除，测试之间互不影响
+func openTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	db, err := sqlx.Connect("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("初始化测试数据库失败: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err = bootstrap.Migrate(db); err != nil {
+		t.Fatalf("迁移数据库失败: %v", err)
+	}
+	return db
+}
+
+func TestAlbumSubscriptionSync(t *testing.T) {
+	ctx := context.Background()
+	subscriptionSvc := &AlbumSubscriptionService{DB: openTestDB(t)}
+
+	sub, err := subscriptionSvc.CreateSubscription(ctx, types.CreateAlbumSubscriptionRequest{AlbumURL: urls[0], IntervalMinutes: 1})
+	if err != nil {
+		t.Fatalf("订阅专辑失败: %v", err)
+	}
+	if sub.Biz != "Mzg5MzgxMTIyOQ==" || sub.AlbumID != "2544487917101039623" || sub.IntervalMinutes != 10 {
+		t.Errorf("订阅信息不正确: %+v", sub)
+	}
+	if _, err = subscriptionSvc.CreateSubscription(ctx, types.CreateAlbumSubscriptionRequest{AlbumURL: urls[0]}); err == nil {
+		t.Error("重复订阅同一个专辑应该返回错误")
+	}
+
+	articles := []types.AlbumArticleInfo{
+		{Index: 1, Title: "a", URL: "https://mp.weixin.qq.com/s?a=1"},
+		{Index: 2, Title: "b", URL: "https://mp.weixin.qq.com/s?a=2"},
+	}
+	var crawled []types.CrawlingRequest
+	failedURLs := make(map[string]struct{}) // 模拟抓取失败的文章
+	truncated := true                       // 模拟只获取到专辑的部分文章
+	scheduler := NewAlbumSubscriptionScheduler(subscriptionSvc)
+	scheduler.FetchAlbum = func(albumURL string) (types.AlbumFetchResult, error) {
+		if truncated {
+			return types.AlbumFetchResult{AlbumTitle: "干货分享集", Articles: articles[:1], Truncated: true, TruncatedReason: types.AlbumTruncatedMaxPages}, nil
+		}
+		return types.AlbumFetchResult{AlbumTitle: "干货分享集", NickName: "一安未来", Articles: articles}, nil
+	}
+	scheduler.Crawl = func(ctx context.Context, req types.CrawlingRequest) (types.CrawlingResponse, error) {
+		crawled = append(crawled, req)
+		res := types.CrawlingResponse{CrawlImgCount: 3}
+		for _, article := range req.AlbumArticles {
+			if _, ok := failedURLs[article.URL]; ok {
+				res.ErrContent += "抓取失败 | \n"
+				continue
+			}
+			res.SucceededURLs = append(res.SucceededURLs, article.URL)
+		}
+		return res, nil
+	}
+
+	// 首次同步只获取到部分文章时不记录，等待下次同步
+	scheduler.acquire(sub.ID)
+	run, err := scheduler.syncSubscription(ctx, sub)
+	if err != nil {
+		t.Fatalf("同步专辑失败: %v", err)
+	}
+	if runs, _ := subscriptionSvc.ListRuns(ctx, types.ListAlbumSubscriptionRunsRequest{SubscriptionID: sub.ID}); len(runs) != 1 || runs[0].Status != types.AlbumSubscriptionRunFailed {
+		t.Fatalf("只获取到部分文章时首次同步应该失败: %+v", runs)
+	}
+	if count, _ := subscriptionSvc.SeenArticleCount(ctx, sub.ID); count != 0 || len(scheduler.crawlQueue) != 0 {
+		t.Errorf("只获取到部分文章时不应记录或抓取文章，已记录 %d 篇", count)
+	}
+	truncated = false
+
+	// 首次同步只记录已有的文章
+	if !scheduler.acquire(sub.ID) {
+		t.Fatal("订阅不应处于处理中")
+	}
+	run, err = scheduler.syncSubscription(ctx, sub)
+	if err != nil {
+		t.Fatalf("同步专辑失败: %v", err)
+	}
+	if run.NewArticleCount != 2 || len(scheduler.crawlQueue) != 0 {
+		t.Errorf("首次同步不应抓取文章: %+v", run)
+	}
+
+	// 专辑发布了新文章
+	articles = append(articles, types.AlbumArticleInfo{Index: 3, Title: "c", URL: "https://mp.weixin.qq.com/s?a=3"})
+	scheduler.acquire(sub.ID)
+	if run, err = scheduler.syncSubscription(ctx, sub); err != nil {
+		t.Fatalf("同步专辑失败: %v", err)
+	}
+	if run.NewArticleCount != 1 || len(scheduler.crawlQueue) != 1 {
+		t.Fatalf("应该有1篇新文章等待抓取: %+v", run)
+	}
+	if scheduler.acquire(sub.ID) {
+		t.Error("等待抓取时订阅应处于处理中")
+	}
+	scheduler.crawlJob(ctx, <-scheduler.crawlQueue)
+	if len(crawled) != 1 || len(crawled[0].AlbumArticles) != 1 || crawled[0].AlbumArticles[0].Index != 3 {
+		t.Fatalf("抓取参数不正确: %+v", crawled)
+	}
+
+	runs, err := subscriptionSvc.ListRuns(ctx, types.ListAlbumSubscriptionRunsRequest{SubscriptionID: sub.ID})
+	if err != nil {
+		t.Fatalf("查询同步记录失败: %v", err)
+	}
+	if len(runs) != 3 || runs[0].Status != types.AlbumSubscriptionRunSuccess || runs[0].CrawlImgCount != 3 || runs[1].Status != types.AlbumSubscriptionRunBaseline {
+		t.Errorf("同步记录不正确: %+v", runs)
+	}
+
+	// 部分文章抓取失败，失败的文章下次同步时重新抓取
+	articles = append(articles,
+		types.AlbumArticleInfo{Index: 4, Title: "d", URL: "https://mp.weixin.qq.com/s?a=4"},
+		types.AlbumArticleInfo{Index: 5, Title: "e", URL: "https://mp.weixin.qq.com/s?a=5"})
+	failedURLs[articles[4].URL] = struct{}{}
+	scheduler.acquire(sub.ID)
+	if _, err = scheduler.syncSubscription(ctx, sub); err != nil {
+		t.Fatalf("同步专辑失败: %v", err)
+	}
+	scheduler.crawlJob(ctx, <-scheduler.crawlQueue)
+	if runs, _ = subscriptionSvc.ListRuns(ctx, types.ListAlbumSubscriptionRunsRequest{SubscriptionID: sub.ID}); runs[0].Status != types.AlbumSubscriptionRunPartial {
+		t.Errorf("部分文章抓取失败时同步记录应为 partial: %+v", runs[0])
+	}
+	delete(failedURLs, articles[4].URL)
+	scheduler.acquire(sub.ID)
+	if run, err = scheduler.syncSubscription(ctx, sub); err != nil || run.NewArticleCount != 1 {
+		t.Fatalf("抓取失败的文章应重新抓取: %+v %v", run, err)
+	}
+	scheduler.crawlJob(ctx, <-scheduler.crawlQueue)
+	if last := crawled[len(crawled)-1]; len(last.AlbumArticles) != 1 || last.AlbumArticles[0].Index != 5 {
+		t.Errorf("重新抓取的参数不正确: %+v", last)
+	}
+
+	// 没有新文章
+	scheduler.acquire(sub.ID)
+	if run, err = scheduler.syncSubscription(ctx, sub); err != nil || run.NewArticleCount != 0 {
+		t.Errorf("不应有新文章: %+v %v", run, err)
+	}
+
+	saved, err := subscriptionSvc.GetSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("查询订阅失败: %v", err)
+	}
+	if saved.Title != "干货分享集" || saved.NextSyncAt != saved.LastSyncedAt+600 {
+		t.Errorf("同步后订阅信息不正确: %+v", saved)
+	}
+	due, err := subscriptionSvc.DueSubscriptions(ctx, saved.LastSyncedAt)
+	if err != nil || len(due) != 0 {
+		t.Errorf("刚同步过的订阅不应到期: %+v %v", due, err)
+	}
+
+	// 手动同步由调度器等待结束
+	if err = scheduler.SyncNow(ctx, sub.ID); err == nil {
+		t.Error("调度器没有启动时手动同步应该返回错误")
+	}
+	scheduler.CheckInterval = time.Hour
+	scheduler.Start(ctx)
+	if err = scheduler.SyncNow(ctx, sub.ID); err != nil {
+		t.Fatalf("手动同步失败: %v", err)
+	}
+	scheduler.Stop()
+	if runs, _ = subscriptionSvc.ListRuns(ctx, types.ListAlbumSubscriptionRunsRequest{SubscriptionID: sub.ID}); runs[0].Status == types.AlbumSubscriptionRunSyncing {
+		t.Errorf("停止调度器后手动同步应该已经结束: %+v", runs[0])
+	}
+
+	if err = subscriptionSvc.DeleteSubscription(ctx, sub.ID); err != nil {
+		t.Fatalf("取消订阅失败: %v", err)
+	}
+	if count, _ := subscriptionSvc.SeenArticleCount(ctx, sub.ID); count != 0 {
+		t.Errorf("取消订阅后应删除已同步的文章，实际还有 %d 篇", count)
+	}
+}
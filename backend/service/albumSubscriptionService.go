@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/global"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"github.com/pudongping/wx-graph-crawl/backend/utils"
+)
+
+// AlbumSubscriptionService 专辑订阅的增删改查，以及已同步文章和同步记录的读写
+type AlbumSubscriptionService struct {
+	DB *sqlx.DB
+}
+
+func NewAlbumSubscriptionService() *AlbumSubscriptionService {
+	return &AlbumSubscriptionService{
+		DB: global.DB,
+	}
+}
+
+// CreateSubscription 订阅专辑，同一个专辑只能订阅一次
+func (svc *AlbumSubscriptionService) CreateSubscription(ctx context.Context, req types.CreateAlbumSubscriptionRequest) (sub types.AlbumSubscription, err error) {
+	albumURL := unescapeAmp(strings.TrimSpace(req.AlbumURL))
+	params, err := parseAlbumHomeURL(albumURL)
+	if err != nil {
+		return sub, errors.Wrap(err, "专辑地址不正确")
+	}
+
+	now := time.Now().Unix()
+	sub = types.AlbumSubscription{
+		AlbumURL:        albumURL,
+		Biz:             params["__biz"],
+		AlbumID:         params["album_id"],
+		IntervalMinutes: normalizeSubscriptionInterval(req.IntervalMinutes),
+		Enabled:         true,
+		CrawlExisting:   req.CrawlExisting,
+		NextSyncAt:      now, // 订阅后尽快进行首次同步
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	result, err := svc.DB.NamedExecContext(ctx, `
+		INSERT INTO album_subscriptions (album_url, biz, album_id, title, nick_name, interval_minutes, enabled, crawl_existing, last_synced_at, next_sync_at, created_at, updated_at)
+		VALUES (:album_url, :biz, :album_id, :title, :nick_name, :interval_minutes, :enabled, :crawl_existing, :last_synced_at, :next_sync_at, :created_at, :updated_at)
+	`, sub)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return sub, errors.New("该专辑已经订阅过了")
+		}
+		return sub, errors.Wrap(err, "保存专辑订阅失败")
+	}
+	sub.ID, err = result.LastInsertId()
+	if err != nil {
+		return sub, errors.Wrap(err, "获取专辑订阅ID失败")
+	}
+
+	return sub, nil
+}
+
+// ListSubscriptions 获取全部专辑订阅
+func (svc *AlbumSubscriptionService) ListSubscriptions(ctx context.Context) ([]types.AlbumSubscription, error) {
+	subs := make([]types.AlbumSubscription, 0)
+	if err := svc.DB.SelectContext(ctx, &subs, "SELECT * FROM album_subscriptions ORDER BY id"); err != nil {
+		return nil, errors.Wrap(err, "查询专辑订阅失败")
+	}
+	return subs, nil
+}
+
+// GetSubscription 根据ID获取专辑订阅
+func (svc *AlbumSubscriptionService) GetSubscription(ctx context.Context, id int64) (sub types.AlbumSubscription, err error) {
+	err = svc.DB.GetContext(ctx, &sub, "SELECT * FROM album_subscriptions WHERE id = ?", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sub, errors.Errorf("专辑订阅 %d 不存在", id)
+		}
+		return sub, errors.Wrap(err, "查询专辑订阅失败")
+	}
+	return sub, nil
+}
+
+// UpdateSubscription 修改同步间隔或启用状态
+func (svc *AlbumSubscriptionService) UpdateSubscription(ctx context.Context, req types.UpdateAlbumSubscriptionRequest) (sub types.AlbumSubscription, err error) {
+	sub, err = svc.GetSubscription(ctx, req.ID)
+	if err != nil {
+		return sub, err
+	}
+
+	now := time.Now().Unix()
+	if req.IntervalMinutes > 0 {
+		sub.IntervalMinutes = normalizeSubscriptionInterval(req.IntervalMinutes)
+	}
+	// 重新启用时，如果已经超过了同步时间，尽快同步一次
+	if req.Enabled && !sub.Enabled && sub.NextSyncAt < now {
+		sub.NextSyncAt = now
+	}
+	sub.Enabled = req.Enabled
+	sub.UpdatedAt = now
+
+	_, err = svc.DB.ExecContext(ctx,
+		"UPDATE album_subscriptions SET interval_minutes = ?, enabled = ?, next_sync_at = ?, updated_at = ? WHERE id = ?",
+		sub.IntervalMinutes, sub.Enabled, sub.NextSyncAt, sub.UpdatedAt, sub.ID)
+	if err != nil {
+		return sub, errors.Wrap(err, "修改专辑订阅失败")
+	}
+	return sub, nil
+}
+
+// DeleteSubscription 取消订阅，同时删除已同步的文章和同步记录
+func (svc *AlbumSubscriptionService) DeleteSubscription(ctx context.Context, id int64) error {
+	tx, err := svc.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "开启事务失败")
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM album_subscription_articles WHERE subscription_id = ?",
+		"DELETE FROM album_subscription_runs WHERE subscription_id = ?",
+		"DELETE FROM album_subscriptions WHERE id = ?",
+	} {
+		if _, err = tx.ExecContext(ctx, query, id); err != nil {
+			return errors.Wrap(err, "删除专辑订阅失败")
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DueSubscriptions 获取已经到了同步时间的订阅
+func (svc *AlbumSubscriptionService) DueSubscriptions(ctx context.Context, now int64) ([]types.AlbumSubscription, error) {
+	subs := make([]types.AlbumSubscription, 0)
+	err := svc.DB.SelectContext(ctx, &subs,
+		"SELECT * FROM album_subscriptions WHERE enabled = 1 AND next_sync_at <= ? ORDER BY next_sync_at, id", now)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询到期的专辑订阅失败")
+	}
+	return subs, nil
+}
+
+// MarkSynced 记录同步时间和专辑信息，并计算下次同步时间
+func (svc *AlbumSubscriptionService) MarkSynced(ctx context.Context, sub types.AlbumSubscription, syncedAt int64) error {
+	nextSyncAt := syncedAt + int64(normalizeSubscriptionInterval(sub.IntervalMinutes))*60
+	_, err := svc.DB.ExecContext(ctx,
+		"UPDATE album_subscriptions SET title = ?, nick_name = ?, last_synced_at = ?, next_sync_at = ?, updated_at = ? WHERE id = ?",
+		sub.Title, sub.NickName, syncedAt, nextSyncAt, syncedAt, sub.ID)
+	if err != nil {
+		return errors.Wrap(err, "更新专辑订阅同步时间失败")
+	}
+	return nil
+}
+
+// SeenArticleCount 已同步过的文章数量，为0时表示还没有同步过
+func (svc *AlbumSubscriptionService) SeenArticleCount(ctx context.Context, subscriptionID int64) (count int, err error) {
+	err = svc.DB.GetContext(ctx, &count, "SELECT COUNT(*) FROM album_subscription_articles WHERE subscription_id = ?", subscriptionID)
+	if err != nil {
+		return 0, errors.Wrap(err, "查询已同步文章数量失败")
+	}
+	return count, nil
+}
+
+// FilterNewArticles 过滤出还没有同步过的文章，按文章标识判断，同一篇文章的地址参数变化时不会被当作新文章
+func (svc *AlbumSubscriptionService) FilterNewArticles(ctx context.Context, subscriptionID int64, articles []types.AlbumArticleInfo) ([]types.AlbumArticleInfo, error) {
+	seenKeys := make([]string, 0)
+	err := svc.DB.SelectContext(ctx, &seenKeys, "SELECT article_key FROM album_subscription_articles WHERE subscription_id = ?", subscriptionID)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询已同步文章失败")
+	}
+	seen := make(map[string]struct{}, len(seenKeys))
+	for _, key := range seenKeys {
+		seen[key] = struct{}{}
+	}
+
+	newArticles := make([]types.AlbumArticleInfo, 0)
+	for _, article := range articles {
+		key := utils.WXArticleKey(article.URL)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		newArticles = append(newArticles, article)
+	}
+	return newArticles, nil
+}
+
+// MarkArticlesSeen 记录已同步过的文章，下次同步时不会再次抓取
+func (svc *AlbumSubscriptionService) MarkArticlesSeen(ctx context.Context, subscriptionID int64, articles []types.AlbumArticleInfo) error {
+	tx, err := svc.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "开启事务失败")
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	for _, article := range articles {
+		_, err = tx.ExecContext(ctx,
+			"INSERT OR IGNORE INTO album_subscription_articles (subscription_id, article_key, url, msgid, title, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+			subscriptionID, utils.WXArticleKey(article.URL), article.URL, article.MsgID, article.Title, now)
+		if err != nil {
+			return errors.Wrap(err, "保存已同步文章失败")
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CreateRun 新增一条同步记录
+func (svc *AlbumSubscriptionService) CreateRun(ctx context.Context, run *types.AlbumSubscriptionRun) error {
+	result, err := svc.DB.NamedExecContext(ctx, `
+		INSERT INTO album_subscription_runs (subscription_id, status, article_count, new_article_count, crawl_img_count, save_path, err_content, started_at, finished_at)
+		VALUES (:subscription_id, :status, :article_count, :new_article_count, :crawl_img_count, :save_path, :err_content, :started_at, :finished_at)
+	`, run)
+	if err != nil {
+		return errors.Wrap(err, "保存同步记录失败")
+	}
+	run.ID, err = result.LastInsertId()
+	if err != nil {
+		return errors.Wrap(err, "获取同步记录ID失败")
+	}
+	return nil
+}
+
+// UpdateRun 更新同步记录
+func (svc *AlbumSubscriptionService) UpdateRun(ctx context.Context, run types.AlbumSubscriptionRun) error {
+	_, err := svc.DB.NamedExecContext(ctx, `
+		UPDATE album_subscription_runs SET status = :status, article_count = :article_count, new_article_count = :new_article_count,
+		crawl_img_count = :crawl_img_count, save_path = :save_path, err_content = :err_content, finished_at = :finished_at
+		WHERE id = :id
+	`, run)
+	if err != nil {
+		return errors.Wrap(err, "更新同步记录失败")
+	}
+	return nil
+}
+
+// ListRuns 查询同步记录，最新的记录在最前面
+func (svc *AlbumSubscriptionService) ListRuns(ctx context.Context, req types.ListAlbumSubscriptionRunsRequest) ([]types.AlbumSubscriptionRun, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	runs := make([]types.AlbumSubscriptionRun, 0)
+	var err error
+	if req.SubscriptionID > 0 {
+		err = svc.DB.SelectContext(ctx, &runs,
+			"SELECT * FROM album_subscription_runs WHERE subscription_id = ? ORDER BY id DESC LIMIT ?", req.SubscriptionID, limit)
+	} else {
+		err = svc.DB.SelectContext(ctx, &runs, "SELECT * FROM album_subscription_runs ORDER BY id DESC LIMIT ?", limit)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "查询同步记录失败")
+	}
+	return runs, nil
+}
+
+// normalizeSubscriptionInterval 同步间隔未设置时使用默认值，过小时使用最小值
+func normalizeSubscriptionInterval(minutes int) int {
+	if minutes <= 0 {
+		return constant.AlbumSubscriptionDefaultIntervalMinutes
+	}
+	if minutes < constant.AlbumSubscriptionMinIntervalMinutes {
+		return constant.AlbumSubscriptionMinIntervalMinutes
+	}
+	return minutes
+}
@@ -0,0 +1,393 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"github.com/pudongping/wx-graph-crawl/backend/utils"
+	"go.uber.org/zap"
+)
+
+// reHashtag 文案中的话题，如 #旅行
+var reHashtag = regexp.MustCompile(`#([\p{L}\p{N}_]+)#?`)
+
+// BundleService 按抓取记录把文章的图片和文案整理为可以直接发布的小绿书发布包
+type BundleService struct {
+}
+
+func NewBundleService() *BundleService {
+	return &BundleService{}
+}
+
+// ListArticles 列出抓取记录中可以制作发布包的文章
+func (svc *BundleService) ListArticles(saveDir string) ([]types.BundleArticle, error) {
+	manifest, err := ReadCrawlManifest(saveDir)
+	if err != nil {
+		return nil, err
+	}
+	articles := make([]types.BundleArticle, 0, len(manifest.Articles))
+	for _, article := range manifest.Articles {
+		images, _ := existingManifestImages(saveDir, article)
+		articles = append(articles, types.BundleArticle{
+			ArticleKey: article.ArticleKey,
+			URL:        article.URL,
+			Seq:        article.Seq,
+			Title:      article.Title,
+			ImageCount: len(images),
+			CrawledAt:  article.CrawledAt,
+		})
+	}
+	return articles, nil
+}
+
+// BuildBundles 为抓取记录中的文章制作发布包，图片按文章中的顺序排列，超过最多数量时拆分为多个发布包
+// 单个发布包出错时记录到 ErrContent 中，继续制作其他发布包
+func (svc *BundleService) BuildBundles(ctx context.Context, req types.BuildBundlesRequest) (res types.BuildBundlesResponse, err error) {
+	start := time.Now()
+	if err = ValidateBuildBundlesRequest(&req); err != nil {
+		return res, err
+	}
+	manifest, err := ReadCrawlManifest(req.ImgSavePath)
+	if err != nil {
+		return res, err
+	}
+	if len(manifest.Articles) == 0 {
+		return res, errors.Errorf("%s 中没有抓取记录，请先抓取文章", req.ImgSavePath)
+	}
+
+	articles := manifest.Articles
+	if len(req.ArticleKeys) > 0 {
+		byKey := make(map[string]types.CrawlManifestArticle, len(manifest.Articles))
+		for _, article := range manifest.Articles {
+			byKey[article.ArticleKey] = article
+		}
+		articles = make([]types.CrawlManifestArticle, 0, len(req.ArticleKeys))
+		for _, key := range req.ArticleKeys {
+			article, ok := byKey[key]
+			if !ok {
+				res.ErrContent += fmt.Sprintf("抓取记录中没有文章 %s", key) + " | \n"
+				continue
+			}
+			articles = append(articles, article)
+		}
+	}
+
+	res.OutputDir = req.OutputDir
+	if err = os.MkdirAll(req.OutputDir, 0755); err != nil {
+		return res, errors.Wrap(err, "创建发布包目录失败")
+	}
+	hashtags := normalizeHashtags(req.Hashtags)
+	res.Bundles = make([]types.PublishBundle, 0, len(articles))
+	for _, article := range articles {
+		if ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+		images, missing := existingManifestImages(req.ImgSavePath, article)
+		res.SkippedImageCount += missing
+		if len(images) == 0 {
+			res.ErrContent += fmt.Sprintf("文章 %s 没有可用的图片", article.Title) + " | \n"
+			continue
+		}
+
+		chunks := chunkBundleImages(images, req.MaxImages)
+		for i, chunk := range chunks {
+			bundle, err := svc.buildBundle(req, article, chunk, i+1, len(chunks), hashtags)
+			if err != nil {
+				zap.L().Error("制作发布包失败", zap.String("title", article.Title), zap.Error(err))
+				res.ErrContent += err.Error() + " | \n"
+				continue
+			}
+			res.Bundles = append(res.Bundles, bundle)
+		}
+	}
+	res.CastTimeStr = time.Since(start).String()
+	return res, nil
+}
+
+// ValidateBuildBundlesRequest 检查请求并填充默认值
+func ValidateBuildBundlesRequest(req *types.BuildBundlesRequest) error {
+	if req.ImgSavePath == "" {
+		return errors.New("图片保存目录不能为空")
+	}
+	if req.OutputDir == "" {
+		req.OutputDir = filepath.Join(req.ImgSavePath, constant.BundleDirName)
+	}
+	if req.MaxImages == 0 {
+		req.MaxImages = constant.BundleMaxImages
+	}
+	if req.MaxImages < 0 || req.MaxImages > constant.BundleMaxImages {
+		return errors.Errorf("每个发布包的图片数量必须在 1 到 %d 之间", constant.BundleMaxImages)
+	}
+	if req.CoverRule == "" {
+		req.CoverRule = constant.BundleDefaultCoverRule
+	}
+	switch req.CoverRule {
+	case types.BundleCoverFirst, types.BundleCoverLargest, types.BundleCoverPortrait:
+	default:
+		return errors.Errorf("不支持的封面规则 %s", req.CoverRule)
+	}
+	return nil
+}
+
+// buildBundle 制作一个发布包：按顺序复制图片为 01.jpg、02.jpg……，复制封面为 cover.jpg，写入文案
+func (svc *BundleService) buildBundle(req types.BuildBundlesRequest, article types.CrawlManifestArticle, images []string, part, parts int, hashtags []string) (bundle types.PublishBundle, err error) {
+	title := article.Title
+	if title == "" {
+		title = article.ArticleKey
+	}
+	bundle = types.PublishBundle{
+		Name:       bundleName(article.Seq, title, part, parts),
+		ArticleURL: article.URL,
+		Title:      article.Title,
+		ImageCount: len(images),
+		CoverPath:  chooseBundleCover(images, req.CoverRule),
+	}
+	dir := filepath.Join(req.OutputDir, bundle.Name)
+	zipPath := dir + ".zip"
+	for _, path := range []string{dir, zipPath} {
+		if _, err = os.Stat(path); err == nil {
+			return bundle, errors.Errorf("发布包 %s 已存在", path)
+		}
+	}
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return bundle, errors.Wrapf(err, "创建发布包 %s 失败", bundle.Name)
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	width := max(len(fmt.Sprint(len(images))), 2)
+	for i, src := range images {
+		dst := filepath.Join(dir, fmt.Sprintf("%0*d%s", width, i+1, strings.ToLower(filepath.Ext(src))))
+		if err = utils.CopyFile(src, dst); err != nil {
+			return bundle, errors.Wrapf(err, "复制图片 %s 失败", src)
+		}
+	}
+	if err = utils.CopyFile(bundle.CoverPath, filepath.Join(dir, "cover"+strings.ToLower(filepath.Ext(bundle.CoverPath)))); err != nil {
+		return bundle, errors.Wrapf(err, "复制封面 %s 失败", bundle.CoverPath)
+	}
+
+	partLabel := ""
+	if parts > 1 {
+		partLabel = fmt.Sprintf("（%d/%d）", part, parts)
+	}
+	copyText := article.Content
+	if strings.TrimSpace(copyText) == "" {
+		copyText = article.Description
+	}
+	caption := BuildBundleCaption(article.Title, partLabel, copyText, hashtags, constant.BundleCaptionMaxRunes)
+	if err = os.WriteFile(filepath.Join(dir, constant.BundleCaptionFileName), []byte(caption), 0644); err != nil {
+		return bundle, errors.Wrapf(err, "写入 %s 失败", constant.BundleCaptionFileName)
+	}
+
+	if !req.Zip {
+		bundle.Dir = dir
+		return bundle, nil
+	}
+	if err = zipDir(dir, zipPath); err != nil {
+		os.Remove(zipPath)
+		return bundle, errors.Wrapf(err, "打包 %s 失败", bundle.Name)
+	}
+	bundle.ZipPath = zipPath
+	return bundle, os.RemoveAll(dir)
+}
+
+// BuildBundleCaption 生成发布文案：第一行为标题加上 partLabel（如“（1/3）”），然后是去掉重复行和标题行的正文，最后一行为话题
+// 正文中的话题会被提取出来放到最后一行，文案总字数超过 maxRunes 时截断正文
+func BuildBundleCaption(title, partLabel, text string, hashtags []string, maxRunes int) string {
+	tags := make([]string, 0, len(hashtags))
+	for _, match := range reHashtag.FindAllStringSubmatch(text, -1) {
+		tags = append(tags, match[1])
+	}
+	tags = normalizeHashtags(append(tags, hashtags...))
+	text = reHashtag.ReplaceAllString(text, "")
+
+	seen := map[string]struct{}{strings.TrimSpace(title): {}}
+	lines := make([]string, 0)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if _, ok := seen[line]; ok {
+			continue
+		}
+		seen[line] = struct{}{}
+		lines = append(lines, line)
+	}
+	body := strings.Join(lines, "\n")
+	title += partLabel
+
+	tagLine := ""
+	if len(tags) > 0 {
+		tagLine = "#" + strings.Join(tags, " #")
+	}
+	// 标题、正文和话题之间各有一个空行
+	budget := maxRunes - utf8.RuneCountInString(title) - utf8.RuneCountInString(tagLine) - 4
+	if utf8.RuneCountInString(body) > budget {
+		truncated := ""
+		if budget > 1 {
+			truncated = truncateRunes(body, budget-1) + "…"
+		}
+		body = truncated
+	}
+
+	parts := make([]string, 0, 3)
+	for _, part := range []string{title, body, tagLine} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+// normalizeHashtags 去掉话题前后的 # 和空白，去掉重复的话题
+func normalizeHashtags(hashtags []string) []string {
+	result := make([]string, 0, len(hashtags))
+	seen := make(map[string]struct{}, len(hashtags))
+	for _, tag := range hashtags {
+		tag = strings.Trim(strings.TrimSpace(tag), "#")
+		tag = strings.Join(strings.Fields(tag), "")
+		if _, ok := seen[tag]; ok || tag == "" {
+			continue
+		}
+		seen[tag] = struct{}{}
+		result = append(result, tag)
+	}
+	return result
+}
+
+func truncateRunes(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// bundleName 发布包名称为“序号_标题”，拆分为多个发布包时在后面加上“_第几个”
+func bundleName(seq int, title string, part, parts int) string {
+	name := sanitizeFilename(title)
+	if seq > 0 {
+		name = fmt.Sprintf("%d_%s", seq, name)
+	}
+	if parts > 1 {
+		name = fmt.Sprintf("%s_%d", name, part)
+	}
+	return name
+}
+
+// chunkBundleImages 按顺序把图片分为每组最多 size 张
+func chunkBundleImages(images []string, size int) [][]string {
+	chunks := make([][]string, 0, (len(images)+size-1)/size)
+	for start := 0; start < len(images); start += size {
+		chunks = append(chunks, images[start:min(start+size, len(images))])
+	}
+	return chunks
+}
+
+// existingManifestImages 返回抓取记录中仍然存在的图片的绝对路径，以及已经不存在的图片数量
+func existingManifestImages(saveDir string, article types.CrawlManifestArticle) (images []string, missing int) {
+	images = make([]string, 0, len(article.Images))
+	for _, rel := range article.Images {
+		path := filepath.FromSlash(rel)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(saveDir, path)
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			missing++
+			continue
+		}
+		images = append(images, path)
+	}
+	return images, missing
+}
+
+// chooseBundleCover 按规则选择封面，读取不到图片尺寸时使用第一张图片
+func chooseBundleCover(images []string, rule types.BundleCoverRule) string {
+	cover := images[0]
+	switch rule {
+	case types.BundleCoverLargest:
+		largest := 0
+		for _, path := range images {
+			if width, height := imageSize(path); width*height > largest {
+				cover, largest = path, width*height
+			}
+		}
+	case types.BundleCoverPortrait:
+		for _, path := range images {
+			if width, height := imageSize(path); height > width {
+				return path
+			}
+		}
+	}
+	return cover
+}
+
+func imageSize(path string) (width, height int) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0
+	}
+	defer file.Close()
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0
+	}
+	return config.Width, config.Height
+}
+
+// zipDir 把目录中的文件打包为 zip 文件，zip 中的文件位于与目录同名的文件夹中
+func zipDir(dir, zipPath string) error {
+	file, err := os.Create(zipPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	zipWriter := zip.NewWriter(file)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		w, err := zipWriter.Create(filepath.Base(dir) + "/" + entry.Name())
+		if err != nil {
+			return err
+		}
+		src, err := os.Open(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, src)
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if err = zipWriter.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
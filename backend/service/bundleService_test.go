@@ -0,0 +1,135 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+)
+
+func TestBuildBundles(t *testing.T) {
+	root := t.TempDir()
+	imgDir := filepath.Join(root, "3_标题")
+	if err := os.MkdirAll(imgDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	paths := make([]string, 0, 5)
+	for i := 0; i < 5; i++ {
+		width, height := 40, 30
+		if i == 3 {
+			width, height = 30, 40 // 第一张竖图
+		}
+		path := filepath.Join(imgDir, fmt.Sprintf("%d.png", i))
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = png.Encode(file, image.NewNRGBA(image.Rect(0, 0, width, height))); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+		paths = append(paths, path)
+	}
+	results := []types.CrawlResult{{
+		URL:           "https://mp.weixin.qq.com/s/abc",
+		Number:        1,
+		AlbumIndex:    3,
+		Title:         "标题",
+		ImgDir:        imgDir,
+		ImgLocalPaths: paths,
+		ArticleText:   "标题\n第一段 #旅行\n第一段\n第二段",
+	}}
+	// 处理图片时 0.png 被转换为 0.jpg
+	renamed := filepath.Join(imgDir, "0.jpg")
+	if err := os.Rename(paths[0], renamed); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateCrawlManifest(root, results, map[string]string{paths[0]: renamed}); err != nil {
+		t.Fatalf("更新抓取记录失败: %v", err)
+	}
+	// 打乱后图片路径改变，抓取记录中的顺序不变
+	moved := filepath.Join(imgDir, "moved.png")
+	if err := os.Rename(paths[1], moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemapCrawlManifest(imgDir, map[string]string{paths[1]: moved}); err != nil {
+		t.Fatalf("更新图片路径失败: %v", err)
+	}
+	manifest, err := ReadCrawlManifest(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"3_标题/0.jpg", "3_标题/moved.png", "3_标题/2.png", "3_标题/3.png", "3_标题/4.png"}
+	if got := manifest.Articles[0].Images; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("抓取记录中的图片为 %v，应该为 %v", got, want)
+	}
+
+	res, err := NewBundleService().BuildBundles(context.Background(), types.BuildBundlesRequest{
+		ImgSavePath: root,
+		MaxImages:   2,
+		Hashtags:    []string{"#小绿书", "旅行"},
+		CoverRule:   types.BundleCoverPortrait,
+	})
+	if err != nil {
+		t.Fatalf("制作发布包失败: %v", err)
+	}
+	if len(res.Bundles) != 3 || res.ErrContent != "" {
+		t.Fatalf("发布包数量为 %d，应该为 3，错误: %s", len(res.Bundles), res.ErrContent)
+	}
+	second := res.Bundles[1]
+	if second.Name != "3_标题_2" || second.ImageCount != 2 || second.CoverPath != paths[3] {
+		t.Errorf("第二个发布包为 %+v", second)
+	}
+	for _, name := range []string{"01.png", "02.png", "cover.png"} {
+		if _, err = os.Stat(filepath.Join(second.Dir, name)); err != nil {
+			t.Errorf("发布包中没有 %s", name)
+		}
+	}
+	caption, _ := os.ReadFile(filepath.Join(res.Bundles[0].Dir, "caption.txt"))
+	if want := "标题（1/3）\n\n第一段\n第二段\n\n#旅行 #小绿书\n"; string(caption) != want {
+		t.Errorf("文案为 %q，应该为 %q", caption, want)
+	}
+
+	// 已存在的发布包不会被覆盖
+	res, err = NewBundleService().BuildBundles(context.Background(), types.BuildBundlesRequest{ImgSavePath: root, MaxImages: 2})
+	if err != nil || len(res.Bundles) != 0 || res.ErrContent == "" {
+		t.Errorf("重复制作发布包应该报错，结果为 %+v, %v", res, err)
+	}
+
+	res, err = NewBundleService().BuildBundles(context.Background(), types.BuildBundlesRequest{
+		ImgSavePath: root,
+		OutputDir:   filepath.Join(root, "zip"),
+		Zip:         true,
+	})
+	if err != nil || len(res.Bundles) != 1 {
+		t.Fatalf("打包失败: %+v, %v", res, err)
+	}
+	reader, err := zip.OpenReader(res.Bundles[0].ZipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if len(reader.File) != 7 { // 5 张图片、封面和文案
+		t.Errorf("zip 中有 %d 个文件，应该为 7", len(reader.File))
+	}
+	if _, err = os.Stat(strings.TrimSuffix(res.Bundles[0].ZipPath, ".zip")); !os.IsNotExist(err) {
+		t.Error("打包后应该删除发布包目录")
+	}
+}
+
+func TestBuildBundleCaption(t *testing.T) {
+	got := BuildBundleCaption("标题", "", strings.Repeat("字", 50), []string{"话题"}, 20)
+	if want := "标题\n\n" + strings.Repeat("字", 10) + "…\n\n#话题\n"; got != want {
+		t.Errorf("文案为 %q，应该为 %q", got, want)
+	}
+	if got = BuildBundleCaption("标题", "", "", nil, 20); got != "标题\n" {
+		t.Errorf("没有正文时文案为 %q", got)
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"github.com/pudongping/wx-graph-crawl/backend/utils"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"go.uber.org/zap"
+)
+
+// ClipboardWatcher 定时读取剪贴板，发现公众号文章链接时推送 constant.IntakeEventClipboard 事件，由前端询问是否加入待抓取列表
+type ClipboardWatcher struct {
+	Interval time.Duration
+	ReadText func(ctx context.Context) (string, error)          // 读取剪贴板，默认使用 runtime.ClipboardGetText
+	Emit     func(ctx context.Context, event types.IntakeEvent) // 推送事件，默认使用 runtime.EventsEmit
+	Intake   *IntakeService
+
+	mu       sync.Mutex
+	enabled  bool
+	cancel   context.CancelFunc
+	done     chan struct{}
+	lastText string
+	offered  map[string]struct{} // 已经提示过的文章，避免重复询问
+}
+
+var (
+	clipboardWatcherOnce sync.Once
+	clipboardWatcher     *ClipboardWatcher
+)
+
+// DefaultClipboardWatcher 程序中唯一的剪贴板监听
+func DefaultClipboardWatcher() *ClipboardWatcher {
+	clipboardWatcherOnce.Do(func() {
+		clipboardWatcher = NewClipboardWatcher()
+	})
+	return clipboardWatcher
+}
+
+func NewClipboardWatcher() *ClipboardWatcher {
+	return &ClipboardWatcher{
+		Interval: constant.ClipboardWatchInterval,
+		ReadText: runtime.ClipboardGetText,
+		Emit: func(ctx context.Context, event types.IntakeEvent) {
+			runtime.EventsEmit(ctx, constant.IntakeEventClipboard, event)
+		},
+		offered: make(map[string]struct{}),
+	}
+}
+
+// Start 开始监听剪贴板，默认不开启，需要用户在界面上手动打开
+func (w *ClipboardWatcher) Start(ctx context.Context) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	w.cancel = cancel
+	w.done = make(chan struct{})
+	// 开启前剪贴板中已有的内容不做提示
+	if text, err := w.ReadText(ctx); err == nil {
+		w.lastText = text
+	}
+	go w.loop(ctx, w.done)
+	w.enabled = true
+	zap.L().Info("开始监听剪贴板")
+}
+
+// Stop 停止监听剪贴板
+func (w *ClipboardWatcher) Stop() {
+	w.mu.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel, w.done = nil, nil
+	w.enabled = false
+	w.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+	zap.L().Info("停止监听剪贴板")
+}
+
+// Enabled 是否正在监听剪贴板
+func (w *ClipboardWatcher) Enabled() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enabled
+}
+
+func (w *ClipboardWatcher) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.poll(ctx)
+		}
+	}
+}
+
+// poll 读取一次剪贴板，内容变化且包含新的文章链接时推送事件
+func (w *ClipboardWatcher) poll(ctx context.Context) {
+	text, err := w.ReadText(ctx)
+	if err != nil {
+		zap.L().Debug("读取剪贴板失败", zap.Error(err))
+		return
+	}
+
+	w.mu.Lock()
+	changed := text != w.lastText
+	w.lastText = text
+	w.mu.Unlock()
+	if !changed || !strings.Contains(text, "mp.weixin.qq.com") {
+		return
+	}
+
+	intake := w.Intake
+	if intake == nil {
+		intake = NewIntakeService()
+	}
+	event := intake.ImportText(text)
+
+	// 已经提示过的文章不再提示
+	w.mu.Lock()
+	fresh := make([]types.ImportedURL, 0, len(event.Accepted))
+	for _, item := range event.Accepted {
+		key := utils.WXArticleKey(item.URL)
+		if _, ok := w.offered[key]; ok {
+			continue
+		}
+		w.offered[key] = struct{}{}
+		fresh = append(fresh, item)
+	}
+	w.mu.Unlock()
+	if len(fresh) == 0 {
+		return
+	}
+
+	event.Accepted = fresh
+	zap.L().Info("剪贴板中发现文章链接", zap.Int("count", len(fresh)))
+	w.Emit(ctx, event)
+}
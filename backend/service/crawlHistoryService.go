@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/global"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"go.uber.org/zap"
+)
+
+// CrawlHistoryService 抓取记录的保存、查询和删除
+type CrawlHistoryService struct {
+	DB *sqlx.DB
+}
+
+func NewCrawlHistoryService() *CrawlHistoryService {
+	return &CrawlHistoryService{
+		DB: global.DB,
+	}
+}
+
+// BuildCrawlRun 根据抓取请求和结果生成抓取记录，crawlErr 为抓取过程中出现的错误
+// crawlURLs 为合并专辑文章并去重后要抓取的全部地址，包括抓取时和其他地址指向同一篇文章而跳过的短链接
+func BuildCrawlRun(req types.CrawlingRequest, crawlURLs []string, res types.CrawlingResponse, results []types.CrawlResult, start time.Time, crawlErr error) (run types.CrawlRun, articles []types.CrawlRunArticle) {
+	finish := time.Now()
+	requestedURLs, _ := json.Marshal(append([]string{}, crawlURLs...))
+	options := req
+	options.ImgUrls = nil
+	optionsJSON, _ := json.Marshal(options)
+
+	run = types.CrawlRun{
+		SavePath:          req.ImgSavePath,
+		RequestedURLs:     string(requestedURLs),
+		Options:           string(optionsJSON),
+		URLCount:          len(crawlURLs),
+		ImgCount:          res.CrawlImgCount,
+		ProcessedImgCount: res.ProcessedImgCount,
+		ErrContent:        res.ErrContent,
+		DurationMs:        finish.Sub(start).Milliseconds(),
+		StartedAt:         start.Unix(),
+		FinishedAt:        finish.Unix(),
+	}
+
+	textDir := filepath.Join(req.ImgSavePath, constant.TextContentFileDir)
+	articles = make([]types.CrawlRunArticle, 0, len(results))
+	for _, item := range results {
+		article := types.CrawlRunArticle{
+			ArticleKey: crawlResultKey(item),
+			URL:        item.URL,
+			Title:      item.Title,
+			Status:     types.CrawlArticleSuccess,
+			ImgCount:   len(item.ImgSavePathSuccess),
+			ImgDir:     item.ImgDir,
+			Files:      "[]",
+			CreatedAt:  finish.Unix(),
+		}
+		if item.Err != nil {
+			article.Status = types.CrawlArticleFailed
+			article.ErrContent = item.Err.Error()
+			run.FailedCount++
+		} else {
+			files, _ := json.Marshal(crawlArticleFiles(textDir, item))
+			article.Files = string(files)
+			run.SuccessCount++
+		}
+		articles = append(articles, article)
+	}
+
+	switch {
+	case crawlErr != nil:
+		run.Status = types.CrawlRunFailed
+		if run.ErrContent == "" {
+			run.ErrContent = crawlErr.Error()
+		}
+	case run.FailedCount == 0:
+		run.Status = types.CrawlRunSuccess
+	case run.SuccessCount == 0:
+		run.Status = types.CrawlRunFailed
+	default:
+		run.Status = types.CrawlRunPartial
+	}
+	return run, articles
+}
+
+// crawlArticleFiles 一篇文章抓取时生成的文件和目录：图片目录、网页、文案和 Word 文档
+func crawlArticleFiles(textDir string, item types.CrawlResult) []string {
+	files := make([]string, 0, 4)
+	if item.ImgDir != "" {
+		files = append(files, item.ImgDir, item.ImgDir+".html")
+	}
+	if item.WriteContent != "" {
+		files = append(files, filepath.Join(textDir, fmt.Sprintf("%d_%s.txt", crawlResultSeq(item), item.Title)))
+		title := item.Title
+		if title == "" {
+			title = fmt.Sprintf("文章_%d", item.Number)
+		}
+		files = append(files, filepath.Join(textDir, articleBaseName(sanitizeWordFilename(title), item.AlbumIndex)+".docx"))
+	}
+	return files
+}
+
+// RecordRun 保存一次抓取的记录和每篇文章的结果
+func (svc *CrawlHistoryService) RecordRun(ctx context.Context, run *types.CrawlRun, articles []types.CrawlRunArticle) error {
+	tx, err := svc.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "开启事务失败")
+	}
+	defer tx.Rollback()
+
+	result, err := tx.NamedExecContext(ctx, `
+		INSERT INTO crawl_runs (status, save_path, requested_urls, options, url_count, success_count, failed_count, img_count, processed_img_count, err_content, duration_ms, started_at, finished_at)
+		VALUES (:status, :save_path, :requested_urls, :options, :url_count, :success_count, :failed_count, :img_count, :processed_img_count, :err_content, :duration_ms, :started_at, :finished_at)
+	`, run)
+	if err != nil {
+		return errors.Wrap(err, "保存抓取记录失败")
+	}
+	if run.ID, err = result.LastInsertId(); err != nil {
+		return errors.Wrap(err, "获取抓取记录ID失败")
+	}
+	for i := range articles {
+		articles[i].RunID = run.ID
+		if _, err = tx.NamedExecContext(ctx, `
+			INSERT INTO crawl_run_articles (run_id, article_key, url, title, status, img_count, img_dir, files, err_content, created_at)
+			VALUES (:run_id, :article_key, :url, :title, :status, :img_count, :img_dir, :files, :err_content, :created_at)
+		`, articles[i]); err != nil {
+			return errors.Wrap(err, "保存文章抓取结果失败")
+		}
+	}
+	return tx.Commit()
+}
+
+// ListRuns 按条件分页查询抓取记录，最新的记录在最前面
+func (svc *CrawlHistoryService) ListRuns(ctx context.Context, filter types.CrawlRunFilter, page types.PageRequest) (res types.ListCrawlRunsResponse, err error) {
+	res.Page = max(page.Page, 1)
+	res.PageSize = page.PageSize
+	if res.PageSize <= 0 {
+		res.PageSize = constant.CrawlRunDefaultPageSize
+	}
+	res.PageSize = min(res.PageSize, constant.CrawlRunMaxPageSize)
+
+	conditions := []string{"1 = 1"}
+	args := make([]any, 0)
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.StartedFrom > 0 {
+		conditions = append(conditions, "started_at >= ?")
+		args = append(args, filter.StartedFrom)
+	}
+	if filter.StartedTo > 0 {
+		conditions = append(conditions, "started_at < ?")
+		args = append(args, filter.StartedTo)
+	}
+	if keyword := strings.TrimSpace(filter.Keyword); keyword != "" {
+		like := "%" + keyword + "%"
+		conditions = append(conditions, "(requested_urls LIKE ? OR id IN (SELECT run_id FROM crawl_run_articles WHERE url LIKE ? OR title LIKE ?))")
+		args = append(args, like, like, like)
+	}
+	where := strings.Join(conditions, " AND ")
+
+	if err = svc.DB.GetContext(ctx, &res.Total, "SELECT COUNT(*) FROM crawl_runs WHERE "+where, args...); err != nil {
+		return res, errors.Wrap(err, "查询抓取记录数量失败")
+	}
+	res.Runs = make([]types.CrawlRun, 0, res.PageSize)
+	err = svc.DB.SelectContext(ctx, &res.Runs, "SELECT * FROM crawl_runs WHERE "+where+" ORDER BY id DESC LIMIT ? OFFSET ?",
+		append(args, res.PageSize, (res.Page-1)*res.PageSize)...)
+	if err != nil {
+		return res, errors.Wrap(err, "查询抓取记录失败")
+	}
+	return res, nil
+}
+
+// GetRun 查询抓取记录的详情
+func (svc *CrawlHistoryService) GetRun(ctx context.Context, id int64) (detail types.CrawlRunDetail, err error) {
+	if err = svc.DB.GetContext(ctx, &detail.CrawlRun, "SELECT * FROM crawl_runs WHERE id = ?", id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return detail, errors.Errorf("抓取记录 %d 不存在", id)
+		}
+		return detail, errors.Wrap(err, "查询抓取记录失败")
+	}
+	if err = json.Unmarshal([]byte(detail.Options), &detail.Request); err != nil {
+		return detail, errors.Wrap(err, "解析抓取参数失败")
+	}
+	if err = json.Unmarshal([]byte(detail.CrawlRun.RequestedURLs), &detail.RequestedURLs); err != nil {
+		return detail, errors.Wrap(err, "解析抓取的链接地址失败")
+	}
+	detail.Request.ImgUrls = detail.RequestedURLs
+
+	detail.Articles = make([]types.CrawlRunArticle, 0)
+	if err = svc.DB.SelectContext(ctx, &detail.Articles, "SELECT * FROM crawl_run_articles WHERE run_id = ? ORDER BY id", id); err != nil {
+		return detail, errors.Wrap(err, "查询文章抓取结果失败")
+	}
+	return detail, nil
+}
+
+// DeleteRun 删除抓取记录，deleteFiles 为 true 时同时删除这次抓取生成的文件
+// 只删除图片保存路径中的文件，其他抓取记录也生成过的文件（如重新抓取了同一篇文章）不会删除
+func (svc *CrawlHistoryService) DeleteRun(ctx context.Context, id int64, deleteFiles bool) (res types.DeleteCrawlRunResponse, err error) {
+	res.ID = id
+	res.DeletedFiles = make([]string, 0)
+	res.KeptFiles = make([]string, 0)
+	detail, err := svc.GetRun(ctx, id)
+	if err != nil {
+		return res, err
+	}
+
+	tx, err := svc.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return res, errors.Wrap(err, "开启事务失败")
+	}
+	defer tx.Rollback()
+	if _, err = tx.ExecContext(ctx, "DELETE FROM crawl_run_articles WHERE run_id = ?", id); err != nil {
+		return res, errors.Wrap(err, "删除文章抓取结果失败")
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM crawl_runs WHERE id = ?", id); err != nil {
+		return res, errors.Wrap(err, "删除抓取记录失败")
+	}
+	if err = tx.Commit(); err != nil {
+		return res, errors.Wrap(err, "删除抓取记录失败")
+	}
+	if !deleteFiles {
+		return res, nil
+	}
+
+	used, err := svc.filesUsedByOtherRuns(ctx, detail.Articles)
+	if err != nil {
+		return res, err
+	}
+	for _, article := range detail.Articles {
+		var files []string
+		_ = json.Unmarshal([]byte(article.Files), &files)
+		for _, file := range files {
+			rest, inSaveDir := trimPathPrefix(file, detail.SavePath)
+			if _, ok := used[file]; ok || !inSaveDir || rest == "" {
+				res.KeptFiles = append(res.KeptFiles, file)
+				continue
+			}
+			if !pathExists(file) {
+				continue
+			}
+			if err := os.RemoveAll(file); err != nil {
+				zap.L().Error("删除抓取的文件失败", zap.String("file", file), zap.Error(err))
+				res.ErrContent += err.Error() + " | \n"
+				continue
+			}
+			res.DeletedFiles = append(res.DeletedFiles, file)
+		}
+	}
+	return res, nil
+}
+
+// filesUsedByOtherRuns 其他抓取记录中同一篇文章或同一个图片目录生成的文件
+func (svc *CrawlHistoryService) filesUsedByOtherRuns(ctx context.Context, articles []types.CrawlRunArticle) (map[string]struct{}, error) {
+	used := make(map[string]struct{})
+	keys := make([]string, 0, len(articles))
+	dirs := make([]string, 0, len(articles))
+	for _, article := range articles {
+		keys = append(keys, article.ArticleKey)
+		dirs = append(dirs, article.ImgDir)
+	}
+	if len(keys) == 0 {
+		return used, nil
+	}
+	query, args, err := sqlx.In("SELECT files FROM crawl_run_articles WHERE article_key IN (?) OR img_dir IN (?)", keys, dirs)
+	if err != nil {
+		return nil, errors.Wrap(err, "构建文件查询失败")
+	}
+	var fileLists []string
+	if err = svc.DB.SelectContext(ctx, &fileLists, svc.DB.Rebind(query), args...); err != nil {
+		return nil, errors.Wrap(err, "查询其他抓取记录的文件失败")
+	}
+	for _, list := range fileLists {
+		var files []string
+		_ = json.Unmarshal([]byte(list), &files)
+		for _, file := range files {
+			used[file] = struct{}{}
+		}
+	}
+	return used, nil
+}
+
+// recordCrawlRun 保存抓取记录，失败时只记录日志，不影响抓取结果
+func recordCrawlRun(ctx context.Context, req types.CrawlingRequest, crawlURLs []string, res types.CrawlingResponse, results []types.CrawlResult, start time.Time, crawlErr error) {
+	if global.DB == nil {
+		return
+	}
+	run, articles := BuildCrawlRun(req, crawlURLs, res, results, start, crawlErr)
+	if err := NewCrawlHistoryService().RecordRun(ctx, &run, articles); err != nil {
+		zap.L().Error("保存抓取记录失败", zap.Error(err))
+	}
+}
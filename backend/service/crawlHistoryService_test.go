@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"github.com/pudongping/wx-graph-crawl/backend/utils"
+)
+
+func TestCrawlHistory(t *testing.T) {
+	ctx := context.Background()
+	svc := &CrawlHistoryService{DB: openTestDB(t)}
+	saveDir := t.TempDir()
+	imgDir := filepath.Join(saveDir, "标题")
+	if err := os.MkdirAll(imgDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(imgDir, "1.jpg"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(imgDir+".html", []byte("<html></html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	req := types.CrawlingRequest{ImgSavePath: saveDir, ImgUrls: []string{"https://mp.weixin.qq.com/s/a", "https://mp.weixin.qq.com/s/b"}, TimeoutSeconds: 10}
+	results := []types.CrawlResult{
+		{URL: req.ImgUrls[0], ArticleKey: "wx:MzHistory==_1001_1", Number: 1, Title: "标题", ImgDir: imgDir, ImgSavePathSuccess: []string{"https://img/1"}},
+		{URL: req.ImgUrls[1], Number: 2, Err: errors.New("超时")},
+	}
+	run, articles := BuildCrawlRun(req, req.ImgUrls, types.CrawlingResponse{CrawlImgCount: 1}, results, time.Now(), nil)
+	if run.Status != types.CrawlRunPartial || run.SuccessCount != 1 || run.FailedCount != 1 {
+		t.Fatalf("抓取记录为 %+v", run)
+	}
+	// 从专辑抓取时记录合并后的全部地址，包括跳过的重复短链接
+	albumReq := types.CrawlingRequest{ImgSavePath: saveDir, AlbumArticles: []types.AlbumArticleInfo{{Index: 1, URL: req.ImgUrls[0]}}}
+	crawlURLs := []string{req.ImgUrls[0], "https://mp.weixin.qq.com/s/c"}
+	if albumRun, _ := BuildCrawlRun(albumReq, crawlURLs, types.CrawlingResponse{}, results[:1], time.Now(), nil); albumRun.URLCount != 2 ||
+		albumRun.RequestedURLs != `["https://mp.weixin.qq.com/s/a","https://mp.weixin.qq.com/s/c"]` {
+		t.Errorf("专辑抓取记录的地址为 %d %s", albumRun.URLCount, albumRun.RequestedURLs)
+	}
+	if articles[0].ArticleKey != "wx:MzHistory==_1001_1" || articles[1].ArticleKey != utils.WXArticleKey(req.ImgUrls[1]) {
+		t.Errorf("文章标识为 %q %q", articles[0].ArticleKey, articles[1].ArticleKey)
+	}
+	if err := svc.RecordRun(ctx, &run, articles); err != nil {
+		t.Fatal(err)
+	}
+	// 再次抓取失败的文章，全部失败
+	failedRun, failedArticles := BuildCrawlRun(types.CrawlingRequest{ImgSavePath: saveDir, ImgUrls: req.ImgUrls[1:]}, req.ImgUrls[1:], types.CrawlingResponse{}, results[1:], time.Now(), nil)
+	if err := svc.RecordRun(ctx, &failedRun, failedArticles); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := svc.ListRuns(ctx, types.CrawlRunFilter{}, types.PageRequest{PageSize: 1})
+	if err != nil || list.Total != 2 || len(list.Runs) != 1 || list.Runs[0].ID != failedRun.ID {
+		t.Fatalf("分页查询结果为 %+v, %v", list, err)
+	}
+	list, err = svc.ListRuns(ctx, types.CrawlRunFilter{Keyword: "标题"}, types.PageRequest{})
+	if err != nil || list.Total != 1 || list.Runs[0].ID != run.ID || list.PageSize != constant.CrawlRunDefaultPageSize {
+		t.Errorf("按关键字查询结果为 %+v, %v", list, err)
+	}
+	list, err = svc.ListRuns(ctx, types.CrawlRunFilter{Status: types.CrawlRunFailed}, types.PageRequest{})
+	if err != nil || list.Total != 1 || list.Runs[0].ID != failedRun.ID {
+		t.Errorf("按状态查询结果为 %+v, %v", list, err)
+	}
+
+	detail, err := svc.GetRun(ctx, run.ID)
+	if err != nil || len(detail.Articles) != 2 || len(detail.Request.ImgUrls) != 2 || detail.Request.TimeoutSeconds != 10 {
+		t.Fatalf("抓取记录详情为 %+v, %v", detail, err)
+	}
+	if _, err = svc.GetRun(ctx, 999); err == nil {
+		t.Error("不存在的抓取记录应返回错误")
+	}
+
+	// 抓取成功的文章不会被再次导入，短链接按解析出的文章标识和地址本身都能查到
+	intake := &IntakeService{DB: svc.DB}
+	keys := intake.crawledKeys(req.ImgUrls)
+	if _, ok := keys[utils.WXArticleKey(req.ImgUrls[0])]; !ok {
+		t.Errorf("已抓取的文章为 %v", keys)
+	}
+	if _, ok := keys[utils.WXArticleKey(req.ImgUrls[1])]; ok {
+		t.Errorf("抓取失败的文章不应算已抓取: %v", keys)
+	}
+	longURL := "https://mp.weixin.qq.com/s?__biz=MzHistory==&mid=1001&idx=1"
+	if keys = intake.crawledKeys([]string{longURL}); len(keys) == 0 {
+		t.Error("短链接抓取成功后，指向同一篇文章的长链接应算已抓取")
+	}
+
+	delRes, err := svc.DeleteRun(ctx, run.ID, true)
+	if err != nil || len(delRes.DeletedFiles) != 2 {
+		t.Fatalf("删除结果为 %+v, %v", delRes, err)
+	}
+	if pathExists(imgDir) || pathExists(imgDir+".html") {
+		t.Error("抓取的文件没有被删除")
+	}
+	if _, err = svc.GetRun(ctx, run.ID); err == nil {
+		t.Error("抓取记录没有被删除")
+	}
+	if _, err = svc.DeleteRun(ctx, failedRun.ID, false); err != nil {
+		t.Error(err)
+	}
+}
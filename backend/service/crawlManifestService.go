@@ -0,0 +1,196 @@
+package service
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"go.uber.org/zap"
+)
+
+// crawlManifestMu 抓取、打乱和处理图片都可能修改抓取记录，同一时间只允许一个地方读写
+var crawlManifestMu sync.Mutex
+
+// ReadCrawlManifest 读取图片保存目录中的抓取记录，文件不存在时返回空的抓取记录
+func ReadCrawlManifest(saveDir string) (manifest types.CrawlManifest, err error) {
+	crawlManifestMu.Lock()
+	defer crawlManifestMu.Unlock()
+	return readCrawlManifest(saveDir)
+}
+
+func readCrawlManifest(saveDir string) (manifest types.CrawlManifest, err error) {
+	content, err := os.ReadFile(filepath.Join(saveDir, constant.CrawlManifestFile))
+	if os.IsNotExist(err) {
+		return types.CrawlManifest{Version: constant.CrawlManifestVer, Articles: make([]types.CrawlManifestArticle, 0)}, nil
+	}
+	if err != nil {
+		return manifest, errors.Wrap(err, "读取抓取记录失败")
+	}
+	if err = json.Unmarshal(content, &manifest); err != nil {
+		return manifest, errors.Wrap(err, "解析抓取记录失败")
+	}
+	if manifest.Version > constant.CrawlManifestVer {
+		return manifest, errors.Errorf("抓取记录的版本 %d 高于当前支持的版本 %d，请升级软件", manifest.Version, constant.CrawlManifestVer)
+	}
+	return manifest, nil
+}
+
+// writeCrawlManifest 先写入临时文件再替换，避免写入失败时损坏原来的抓取记录
+func writeCrawlManifest(saveDir string, manifest types.CrawlManifest) error {
+	manifest.Version = constant.CrawlManifestVer
+	manifest.UpdatedAt = time.Now().Unix()
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "序列化抓取记录失败")
+	}
+	path := filepath.Join(saveDir, constant.CrawlManifestFile)
+	if err = os.WriteFile(path+".tmp", content, 0644); err != nil {
+		return errors.Wrap(err, "写入抓取记录失败")
+	}
+	if err = os.Rename(path+".tmp", path); err != nil {
+		os.Remove(path + ".tmp")
+		return errors.Wrap(err, "写入抓取记录失败")
+	}
+	return nil
+}
+
+// UpdateCrawlManifest 把抓取成功的文章合并到抓取记录中，同一篇文章或同一个图片目录只保留最新的一次
+// renames 为抓取后处理图片时改了名字的图片（如转换为 JPEG），key 为原来的路径
+func UpdateCrawlManifest(saveDir string, results []types.CrawlResult, renames map[string]string) error {
+	crawlManifestMu.Lock()
+	defer crawlManifestMu.Unlock()
+
+	manifest, err := readCrawlManifest(saveDir)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	fresh := make([]types.CrawlManifestArticle, 0, len(results))
+	for _, item := range results {
+		if item.Err != nil || len(item.ImgLocalPaths) == 0 {
+			continue
+		}
+		article := types.CrawlManifestArticle{
+			ArticleKey:  crawlResultKey(item),
+			URL:         item.URL,
+			Seq:         crawlResultSeq(item),
+			Title:       item.Title,
+			Description: item.Description,
+			Content:     item.ArticleText,
+			ImgDir:      relToSaveDir(saveDir, item.ImgDir),
+			Images:      make([]string, 0, len(item.ImgLocalPaths)),
+			CrawledAt:   now,
+		}
+		for _, path := range item.ImgLocalPaths {
+			if renamed, ok := renames[path]; ok {
+				path = renamed
+			}
+			article.Images = append(article.Images, relToSaveDir(saveDir, path))
+		}
+		fresh = append(fresh, article)
+	}
+	if len(fresh) == 0 {
+		return nil
+	}
+
+	replaced := make(map[string]struct{}, len(fresh)*2)
+	for _, article := range fresh {
+		replaced["key:"+article.ArticleKey] = struct{}{}
+		replaced["dir:"+article.ImgDir] = struct{}{}
+	}
+	articles := make([]types.CrawlManifestArticle, 0, len(manifest.Articles)+len(fresh))
+	for _, article := range manifest.Articles {
+		_, sameKey := replaced["key:"+article.ArticleKey]
+		_, sameDir := replaced["dir:"+article.ImgDir]
+		if !sameKey && !sameDir {
+			articles = append(articles, article)
+		}
+	}
+	articles = append(articles, fresh...)
+	sort.SliceStable(articles, func(i, j int) bool {
+		if articles[i].CrawledAt != articles[j].CrawledAt {
+			return articles[i].CrawledAt < articles[j].CrawledAt
+		}
+		return articles[i].Seq < articles[j].Seq
+	})
+	manifest.Articles = articles
+	return writeCrawlManifest(saveDir, manifest)
+}
+
+// RemapCrawlManifest 图片改名或移动后更新抓取记录中的图片路径，会向上查找 dir 所在的图片保存目录中的抓取记录
+// renames 的 key 为原来的路径，value 为新的路径，都是绝对路径
+func RemapCrawlManifest(dir string, renames map[string]string) error {
+	if len(renames) == 0 {
+		return nil
+	}
+	saveDir, ok := findCrawlManifestDir(dir)
+	if !ok {
+		return nil
+	}
+
+	crawlManifestMu.Lock()
+	defer crawlManifestMu.Unlock()
+	manifest, err := readCrawlManifest(saveDir)
+	if err != nil {
+		return err
+	}
+	changed := false
+	for i := range manifest.Articles {
+		for j, rel := range manifest.Articles[i].Images {
+			if to, ok := renames[filepath.Join(saveDir, filepath.FromSlash(rel))]; ok {
+				manifest.Articles[i].Images[j] = relToSaveDir(saveDir, to)
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return writeCrawlManifest(saveDir, manifest)
+}
+
+// findCrawlManifestDir 从 dir 开始向上查找包含抓取记录的目录
+func findCrawlManifestDir(dir string) (string, bool) {
+	dir = filepath.Clean(dir)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, constant.CrawlManifestFile)); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// remapCrawlManifestMoves 打乱或撤销打乱后更新抓取记录，失败时只记录日志，不影响图片本身
+func remapCrawlManifestMoves(dir string, moves []types.ShuffleMove, reverse bool) {
+	renames := make(map[string]string, len(moves))
+	for _, m := range moves {
+		if reverse {
+			renames[m.To] = m.From
+		} else {
+			renames[m.From] = m.To
+		}
+	}
+	if err := RemapCrawlManifest(dir, renames); err != nil {
+		zap.L().Warn("更新抓取记录中的图片路径失败", zap.String("dir", dir), zap.Error(err))
+	}
+}
+
+// relToSaveDir 转换为相对于图片保存目录的路径，统一使用 / 分隔，不在图片保存目录中时保留原路径
+func relToSaveDir(saveDir, path string) string {
+	rel, err := filepath.Rel(saveDir, path)
+	if err != nil || rel == ".." || filepath.IsAbs(rel) || len(rel) > 2 && rel[:3] == ".."+string(filepath.Separator) {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
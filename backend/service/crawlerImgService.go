@@ -3,6 +3,7 @@ package service
 import (
 	"crypto/md5"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"github.com/labstack/gommon/log"
 	"io"
@@ -51,9 +52,6 @@ var (
 
 	// 或者匹配特定 class 的 div 容器
 	//reContentData = regexp.MustCompile(`<div[^>]*class="[^"]*rich_media_content[^"]*"[^>]*>([\s\S]*?)</div>`)
-
-	reTitle = regexp.MustCompile(`<meta\s+property="og:title"\s+content="(.*?)"`) // 抓取标题
-	reDesc  = regexp.MustCompile(`<meta\s+name="description"\s+content="(.*?)"`)  // 抓取正文内容
 )
 
 // 定义全局的资源文件映射，用于跟踪已下载的资源
@@ -65,14 +63,30 @@ var (
 )
 
 type CrawlerImgService struct {
-	WXTuWenIMGUrls      []string      // 需要被抓取的微信图文链接地址
-	HttpClientTimeout   time.Duration // 网络请求超时时间
-	ImgSavePath         string        // 图片保存路径
-	TextContentFilePath string        // 文案保存文件地址（所有文案保存到一个文件中）
-	TextContentFileDir  string        // 文案保存文件目录（每个文章文案保存到一个文件中）
-	CommonJSDir         string        // JS公共目录
-	CommonCSSDir        string        // CSS公共目录
-	FailedDownloadDir   string        // 下载失败地址保存目录
+	WXTuWenIMGUrls      []string          // 需要被抓取的微信图文链接地址
+	HttpClientTimeout   time.Duration     // 网络请求超时时间
+	ImgSavePath         string            // 图片保存路径
+	TextContentFilePath string            // 文案保存文件地址（所有文案保存到一个文件中）
+	TextContentFileDir  string            // 文案保存文件目录（每个文章文案保存到一个文件中）
+	CommonJSDir         string            // JS公共目录
+	CommonCSSDir        string            // CSS公共目录
+	FailedDownloadDir   string            // 下载失败地址保存目录
+	AlbumIndexes        map[string]int    // 文章标识对应的专辑序号（从专辑抓取时使用），key 为 utils.WXArticleKey
+	DuplicateURLs       map[string]string // 和其他地址指向同一篇文章而跳过抓取的地址，value 为保留的地址
+
+	claimMu sync.Mutex
+	claimed map[string]*articleClaim // 文章标识对应的占用信息，同一篇文章只由一个地址抓取
+}
+
+// articleClaim 占用一篇文章的地址，done 在该地址抓取结束后关闭，ok 为是否抓取成功
+type articleClaim struct {
+	owner int
+	ok    bool
+	done  chan struct{}
+}
+
+func newArticleClaim(owner int) *articleClaim {
+	return &articleClaim{owner: owner, done: make(chan struct{})}
 }
 
 func NewCrawlerImgService(
@@ -108,6 +122,12 @@ func (svc *CrawlerImgService) RunSpiderImg() (spiderResults []types.CrawlResult,
 	wg := sync.WaitGroup{}
 
 	crawlResultChan := make(chan types.CrawlResult, len(svc.WXTuWenIMGUrls)) // 收集信息
+	// 长链接在抓取前就能得到文章标识，先占用，短链接解析后指向同一篇文章时跳过
+	svc.DuplicateURLs = make(map[string]string)
+	svc.claimed = make(map[string]*articleClaim, len(svc.WXTuWenIMGUrls))
+	for i, wxTuWenIMGUrl := range svc.WXTuWenIMGUrls {
+		svc.claimed[utils.WXArticleKey(wxTuWenIMGUrl)] = newArticleClaim(i)
+	}
 	wg.Add(len(svc.WXTuWenIMGUrls))
 
 	for i, wxTuWenIMGUrl := range svc.WXTuWenIMGUrls {
@@ -148,11 +168,19 @@ func (svc *CrawlerImgService) work(i int, wxTuWenIMGUrl string, wg *sync.WaitGro
 	var err error
 
 	crawlRes := types.CrawlResult{
-		URL:    wxTuWenIMGUrl,
-		Number: num,
-		Err:    nil,
-		Title:  "未命名标题",
-	}
+		URL:        wxTuWenIMGUrl,
+		Number:     num,
+		Err:        nil,
+		Title:      "未命名标题",
+		ArticleKey: utils.WXArticleKey(wxTuWenIMGUrl),
+	}
+	crawlRes.AlbumIndex = svc.AlbumIndexes[crawlRes.ArticleKey]
+	// 抓取结束后释放占用的文章，指向同一篇文章的其他地址在抓取成功时跳过，失败时接着抓取
+	urlKey := crawlRes.ArticleKey
+	defer func() {
+		svc.releaseArticle(i, urlKey, crawlRes.Err == nil)
+		svc.releaseArticle(i, crawlRes.ArticleKey, crawlRes.Err == nil)
+	}()
 	// 先一个一个的抓取每一个链接地址对应的 html 内容
 	html, err := svc.FetchWXHTMLContent(wxTuWenIMGUrl)
 	if err != nil {
@@ -165,6 +193,16 @@ func (svc *CrawlerImgService) work(i int, wxTuWenIMGUrl string, wg *sync.WaitGro
 	log.Info("抓取微信图片链接地址成功", zap.String("链接地址", wxTuWenIMGUrl), zap.Int("序号", num))
 	log.Info("微信HTML内容长度：", zap.Int("HTML内容长度", len(html)))
 	crawlRes.Html = html
+	// 短链接在抓取前无法得到文章标识，从页面中解析出长链接参数后再去重和查找专辑序号
+	articleKey := articleKeyFromHTML(wxTuWenIMGUrl, html)
+	if !svc.claimArticle(i, articleKey) {
+		zap.L().Info("跳过重复的文章地址", zap.String("url", wxTuWenIMGUrl), zap.String("key", articleKey))
+		return
+	}
+	crawlRes.ArticleKey = articleKey
+	if crawlRes.AlbumIndex == 0 && len(svc.AlbumIndexes) > 0 {
+		crawlRes.AlbumIndex = svc.AlbumIndexes[articleKey]
+	}
 	// 从抓取后的 html 内容中解析出所有的图片链接地址
 	imgUrls, err := svc.ParseImgUrls(html)
 	if err != nil {
@@ -186,11 +224,59 @@ func (svc *CrawlerImgService) work(i int, wxTuWenIMGUrl string, wg *sync.WaitGro
 	//zap.L().Info("无需重复下载的图片数量：", zap.Int("count", len(imgUrls)))
 	zap.L().Info(fmt.Sprintf("无需重复下载的图片数量：%d", len(imgUrls)))
 	// 提取想要记录的内容
-	crawlRes.Title, crawlRes.WriteContent = svc.GetWriteContent(html, num)
+	crawlRes.Title, crawlRes.WriteContent = svc.GetWriteContent(html, &crawlRes)
 
 	crawlResultChan <- crawlRes
 }
 
+// claimArticle 由第 i 个地址抓取 articleKey 对应的文章
+// 文章已由其他地址占用时等待该地址抓取结束：抓取成功则返回 false 并记录到 DuplicateURLs，抓取失败则改由第 i 个地址抓取
+func (svc *CrawlerImgService) claimArticle(i int, articleKey string) bool {
+	for {
+		svc.claimMu.Lock()
+		claim, ok := svc.claimed[articleKey]
+		if !ok {
+			svc.claimed[articleKey] = newArticleClaim(i)
+			svc.claimMu.Unlock()
+			return true
+		}
+		if claim.owner == i {
+			svc.claimMu.Unlock()
+			return true
+		}
+		select {
+		case <-claim.done:
+			if claim.ok {
+				svc.DuplicateURLs[svc.WXTuWenIMGUrls[i]] = svc.WXTuWenIMGUrls[claim.owner]
+				svc.claimMu.Unlock()
+				return false
+			}
+			svc.claimed[articleKey] = newArticleClaim(i)
+			svc.claimMu.Unlock()
+			return true
+		default:
+		}
+		svc.claimMu.Unlock()
+		<-claim.done
+	}
+}
+
+// releaseArticle 第 i 个地址抓取结束，释放它占用的 articleKey 对应的文章，ok 为是否抓取成功
+func (svc *CrawlerImgService) releaseArticle(i int, articleKey string, ok bool) {
+	svc.claimMu.Lock()
+	defer svc.claimMu.Unlock()
+	claim, exists := svc.claimed[articleKey]
+	if !exists || claim.owner != i {
+		return
+	}
+	select {
+	case <-claim.done:
+	default:
+		claim.ok = ok
+		close(claim.done)
+	}
+}
+
 // 抓取每一个链接地址对应的 html 内容
 func (svc *CrawlerImgService) FetchWXHTMLContent(wxTuWenUrl string) (string, error) {
 	if "" == wxTuWenUrl {
@@ -222,41 +308,42 @@ func (svc *CrawlerImgService) FetchWXHTMLContent(wxTuWenUrl string) (string, err
 	return html, err
 }
 
+// picturePageInfo window.picture_page_info_list 中的单张图片信息，只关心图片本身的地址，水印信息下的 cdn_url 不在这一层
+type picturePageInfo struct {
+	CdnURL string `json:"cdn_url"`
+}
+
 func (svc *CrawlerImgService) ParseImgUrls(html string) ([]string, error) {
-	// 提取 window.picture_page_info_list 内容块
-	reList := regexp.MustCompile(`window\.picture_page_info_list\s*=\s*(\[[\s\S]*?\]);`)
-	listMatch := reList.FindStringSubmatch(html)
-	if len(listMatch) < 2 {
+	// 解析 window.picture_page_info_list 数组字面量
+	jsonStr, err := utils.ExtractJSAssignmentJSON(html, "window.picture_page_info_list")
+	if err != nil {
+		zap.L().Debug("未找到可解析的 picture_page_info_list 内容", zap.Error(err))
 		return []string{}, nil // 返回空列表
-		//return nil, errors.New("未找到 picture_page_info_list 内容")
 	}
-	listContent := listMatch[1]
 
-	// 提取 cdn_url，排除 watermark_info 下的
-	reImgNeed := regexp.MustCompile(`cdn_url:\s*'([^']+)'`)
-	reWatermark := regexp.MustCompile(`watermark_info\s*:\s*\{[\s\S]*?cdn_url:\s*'([^']+)'[\s\S]*?\}`)
-
-	// 先找所有 watermark_info 下的 cdn_url
-	watermarkUrls := make(map[string]struct{})
-	for _, wm := range reWatermark.FindAllStringSubmatch(listContent, -1) {
-		if len(wm) > 1 {
-			watermarkUrls[wm[1]] = struct{}{}
-		}
+	var pictures []picturePageInfo
+	if err = json.Unmarshal([]byte(jsonStr), &pictures); err != nil {
+		return nil, errors.Wrap(err, "解析 picture_page_info_list 内容失败")
 	}
 
-	// 再找所有 cdn_url，排除水印
-	results := reImgNeed.FindAllStringSubmatch(listContent, -1)
-	urls := make([]string, 0, len(results))
-	for _, v := range results {
-		if len(v) > 1 {
-			if _, isWatermark := watermarkUrls[v[1]]; !isWatermark {
-				urls = append(urls, v[1])
-			}
+	urls := make([]string, 0, len(pictures))
+	for _, picture := range pictures {
+		if picture.CdnURL == "" {
+			continue
 		}
+		urls = append(urls, unescapeAmp(picture.CdnURL))
 	}
 	return urls, nil
 }
 
+// unescapeAmp 还原页面中被多次转义的 &amp;
+func unescapeAmp(s string) string {
+	for strings.Contains(s, "&amp;") {
+		s = strings.ReplaceAll(s, "&amp;", "&")
+	}
+	return s
+}
+
 func (svc *CrawlerImgService) FastDownloadImgFiles(imgUrls []string, num int) (imgFilePaths []string, err error) {
 	savePath := fmt.Sprintf("%s/%d", svc.ImgSavePath, num) // 以批次分组成不同的文件夹
 	// 先检查目录是否存在
@@ -468,30 +555,27 @@ func (svc *CrawlerImgService) getCommonResourcePath(url, fileType string) (strin
 	return fullPath, relativePath
 }
 
-func (svc *CrawlerImgService) GetWriteContent(html string, num int) (title string, content string) {
-	// 提取 title 和 desc 的值
-	// 因为提取的 jsonStr 内容中是一定会含有 title 和 desc 字段的，因此以下代码可不用做边界值的判断
-	// 这里不能直接通过解析 json 字符串的方式来提取内容，因为这里的内容不是一个合法的 json 字符串，它仅仅是一个 js 代码（尤其注意）
-	titleMatch := reTitle.FindStringSubmatch(html)
-	descMatch := reDesc.FindStringSubmatch(html)
-
-	if len(titleMatch) < 2 || len(descMatch) < 2 {
-		zap.L().Error("未找到标题或描述信息")
+// GetWriteContent 保存文章的 html 和图片，并提取需要记录的文案内容，描述、正文和图片的保存路径记录到 crawlRes 中
+// crawlRes.AlbumIndex 为文章的专辑序号（不是从专辑抓取时为0），大于0时文件以“序号_标题”命名，与专辑页面顺序一致
+func (svc *CrawlerImgService) GetWriteContent(html string, crawlRes *types.CrawlResult) (title string, content string) {
+	// 从页面脚本中的 msg_title 和 msg_desc 变量提取标题和描述
+	title = jsAssignmentString(html, "var msg_title")
+	desc := jsAssignmentString(html, "var msg_desc")
+	if title == "" {
+		zap.L().Error("未找到标题信息")
 		//return title, "未找到标题或描述信息"
 		return title, html
 	}
-	for _, titleStr := range titleMatch {
-		zap.L().Info("匹配到的标题内容：" + titleStr)
-	}
-
-	title = titleMatch[1]
-	desc := descMatch[1]
+	zap.L().Info("匹配到的标题内容：" + title)
 
 	// 清理标题，使其适合作为文件名
 	title = sanitizeFilename(title)
+	crawlRes.Description = desc
+	baseName := articleBaseName(title, crawlRes.AlbumIndex)
+	crawlRes.ImgDir = filepath.Join(svc.ImgSavePath, baseName)
 
 	// 使用清理后的标题创建文件路径，保存 html 文件
-	filePath := fmt.Sprintf("%s/%s.html", svc.ImgSavePath, title)
+	filePath := fmt.Sprintf("%s/%s.html", svc.ImgSavePath, baseName)
 	zap.L().Info("保存 html 文件供后续分析，文件路径为：" + filePath)
 
 	// 创建goquery文档对象用于解析HTML
@@ -515,7 +599,7 @@ func (svc *CrawlerImgService) GetWriteContent(html string, num int) (title strin
 
 		// 找到所有的img标签并下载图片，然后更新其data-src和src属性
 		// 创建资源目录
-		resourceDir := fmt.Sprintf("%s/%s", svc.ImgSavePath, title)
+		resourceDir := fmt.Sprintf("%s/%s", svc.ImgSavePath, baseName)
 		if err := os.MkdirAll(resourceDir, 0755); err != nil {
 			zap.L().Error("创建资源目录失败", zap.Error(err))
 		} else {
@@ -533,13 +617,14 @@ func (svc *CrawlerImgService) GetWriteContent(html string, num int) (title strin
 				dataSrc, exists := selection.Attr("data-src")
 				if exists && strings.Contains(dataSrc, "http") {
 					// 构建本地图片路径：文件名/图片序号
-					localImgPath := fmt.Sprintf("%s/%d.jpeg", title, i)
+					localImgPath := fmt.Sprintf("%s/%d.jpeg", baseName, i)
 					fullImgPath := fmt.Sprintf("%s/%s", svc.ImgSavePath, localImgPath)
 
 					// 下载图片
 					if _, err := svc.DownloadImgFile(dataSrc, fullImgPath); err != nil {
 						zap.L().Error("下载图片失败", zap.String("imgUrl", dataSrc), zap.Error(err))
 					} else {
+						crawlRes.ImgLocalPaths = append(crawlRes.ImgLocalPaths, filepath.FromSlash(fullImgPath))
 						// 同时更新data-src和src属性为本地路径
 						selection.SetAttr("data-src", localImgPath)
 						selection.SetAttr("src", localImgPath)
@@ -651,7 +736,8 @@ func (svc *CrawlerImgService) GetWriteContent(html string, num int) (title strin
 	extractedContent := svc.ExtractArticleContent(contentStr)
 	zap.L().Info("提取到的文本内容长度：" + fmt.Sprintf("%d", len(extractedContent)))
 
-	content = fmt.Sprintf("第 %d 篇文章====> \r\n", num)
+	crawlRes.ArticleText = extractedContent
+	content = fmt.Sprintf("第 %d 篇文章====> \r\n", crawlResultSeq(*crawlRes))
 	content += "标题： " + title + "\r\n"
 	content += "描述： " + desc + "\r\n"
 	content += "正文内容 --------------- \r\n " + extractedContent + "\r\n ------------- \r\n"
@@ -701,9 +787,9 @@ func (svc *CrawlerImgService) WriteWenAnContent(contents []types.CrawlResult) er
 		return errors.Wrap(err, "写入文案时，创建文本文件出现异常")
 	}
 
-	// 先按照 number 字段进行从小到大排序
+	// 先按照序号进行从小到大排序（从专辑抓取时按专辑序号排序）
 	sort.Slice(contents, func(i, j int) bool {
-		return contents[i].Number < contents[j].Number
+		return crawlResultSeq(contents[i]) < crawlResultSeq(contents[j])
 	})
 
 	result := ""
@@ -720,7 +806,7 @@ func (svc *CrawlerImgService) WriteWenAnContent(contents []types.CrawlResult) er
 		return errors.Wrap(err, "创建文本文件保存目录出现异常")
 	}
 	for _, content := range contents {
-		filePath := filepath.Join(svc.TextContentFileDir, fmt.Sprintf("%d_%s.txt", content.Number, content.Title))
+		filePath := filepath.Join(svc.TextContentFileDir, fmt.Sprintf("%d_%s.txt", crawlResultSeq(content), content.Title))
 		if err := utils.CreateFileIfNotExist(filePath); err != nil {
 			return errors.Wrapf(err, "写入 %s 文件时，发生错误：%+v", filePath, err)
 		}
@@ -733,6 +819,43 @@ func (svc *CrawlerImgService) WriteWenAnContent(contents []types.CrawlResult) er
 	return nil
 }
 
+// jsAssignmentString 使用 JS 字面量解析器读取页面脚本中字符串变量的值，没有找到或不是字符串时返回空
+func jsAssignmentString(html, name string) string {
+	jsonStr, err := utils.ExtractJSAssignmentJSON(html, name)
+	if err != nil {
+		return ""
+	}
+	var value string
+	if err = json.Unmarshal([]byte(jsonStr), &value); err != nil {
+		return ""
+	}
+	return value
+}
+
+// crawlResultKey 返回抓取结果的文章标识，没有记录时按地址计算
+func crawlResultKey(result types.CrawlResult) string {
+	if result.ArticleKey != "" {
+		return result.ArticleKey
+	}
+	return utils.WXArticleKey(result.URL)
+}
+
+// crawlResultSeq 返回文章的排序序号：从专辑抓取时使用专辑序号，否则使用输入顺序
+func crawlResultSeq(result types.CrawlResult) int {
+	if result.AlbumIndex > 0 {
+		return result.AlbumIndex
+	}
+	return result.Number
+}
+
+// articleBaseName 返回文章相关文件的基础名称，从专辑抓取时以“序号_标题”命名
+func articleBaseName(title string, albumIndex int) string {
+	if albumIndex > 0 {
+		return fmt.Sprintf("%d_%s", albumIndex, title)
+	}
+	return title
+}
+
 // 辅助函数来清理文件名
 func sanitizeFilename(filename string) string {
 	// 移除非字母数字和常见符号的字符
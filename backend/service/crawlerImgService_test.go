@@ -0,0 +1,103 @@
+package service
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseImgUrls(t *testing.T) {
+	html, err := os.ReadFile("../../downloads/wx_article_01.html")
+	if err != nil {
+		t.Skipf("读取文章样例失败: %v", err)
+	}
+
+	svc := &CrawlerImgService{}
+	imgUrls, err := svc.ParseImgUrls(string(html))
+	if err != nil {
+		t.Fatalf("解析图片地址失败: %v", err)
+	}
+	if len(imgUrls) == 0 {
+		t.Fatal("没有解析到图片地址")
+	}
+	first := "https://mmecoa.qpic.cn/mmecoa_gif/Bjfa4Cibv5M5U9ARBibiaBw9ibokd8zxuvUs5tlkl906u0AFOfEcEHx35q5EjofBtibg5UtSUUicLibN6ib8PKSiasyKYZA/640?wx_fmt=gif&from=appmsg"
+	if imgUrls[0] != first {
+		t.Errorf("第一张图片地址不正确: %s", imgUrls[0])
+	}
+	for _, imgUrl := range imgUrls {
+		if !strings.HasPrefix(imgUrl, "https://") || !strings.Contains(imgUrl, ".qpic.cn/") {
+			t.Errorf("图片地址不正确: %s", imgUrl)
+		}
+		if strings.Contains(imgUrl, "&amp;") || strings.Contains(imgUrl, `\x26`) {
+			t.Errorf("图片地址没有还原转义: %s", imgUrl)
+		}
+	}
+
+	imgUrls, err = svc.ParseImgUrls("<html><body>没有图片</body></html>")
+	if err != nil || len(imgUrls) != 0 {
+		t.Errorf("没有图片列表时应返回空列表，实际为 %v, %v", imgUrls, err)
+	}
+}
+
+func TestJSAssignmentString(t *testing.T) {
+	html := `<script>
+var msg_title = '标题 &amp; 副标题'.html(false);
+var msg_desc = htmlDecode("描述&quot;");
+var msg_count = '3' * 1;
+</script>`
+	cases := map[string]string{
+		"var msg_title": "标题 & 副标题",
+		"var msg_desc":  `描述"`,
+		"var msg_count": "", // 不是字符串
+		"var msg_none":  "", // 没有找到
+	}
+	for name, want := range cases {
+		if got := jsAssignmentString(html, name); got != want {
+			t.Errorf("%s 的值为 %q，期望为 %q", name, got, want)
+		}
+	}
+}
+
+func TestClaimArticle(t *testing.T) {
+	longURL := "https://mp.weixin.qq.com/s?__biz=MzIwMzQwODg3MQ==&mid=2247507554&idx=2&sn=f21300108eeb95a8dca484cd3bc0a4c3"
+	shortURL := "https://mp.weixin.qq.com/s/w-lFW8AjuHRKSY74UIGwxQ"
+	firstShortURL := "https://mp.weixin.qq.com/s/AbCdEfGhIjKlMnOpQrStUv"
+	secondShortURL := "https://mp.weixin.qq.com/s/ZyXwVuTsRqPoNmLkJiHgFe"
+	key := "wx:MzIwMzQwODg3MQ==_2247507554_2"
+	otherKey := "wx:MzIwMzQwODg3MQ==_2247507555_1"
+
+	svc := &CrawlerImgService{
+		WXTuWenIMGUrls: []string{shortURL, longURL, firstShortURL, secondShortURL},
+		DuplicateURLs:  make(map[string]string),
+		claimed:        map[string]*articleClaim{key: newArticleClaim(1)}, // 长链接抓取前就占用了文章
+	}
+	if !svc.claimArticle(1, key) {
+		t.Error("长链接应该可以抓取自己占用的文章")
+	}
+	// 短链接解析出的文章已由长链接占用，等待长链接抓取结束，长链接抓取成功时跳过短链接
+	claimed := make(chan bool)
+	go func() { claimed <- svc.claimArticle(0, key) }()
+	select {
+	case <-claimed:
+		t.Fatal("长链接抓取结束前短链接应该等待")
+	case <-time.After(50 * time.Millisecond):
+	}
+	svc.releaseArticle(1, key, true)
+	if <-claimed {
+		t.Error("短链接和长链接指向同一篇文章时应该跳过短链接")
+	}
+	// 两个短链接指向同一篇文章，先占用的抓取失败时由另一个接着抓取
+	if !svc.claimArticle(3, otherKey) {
+		t.Error("第一个指向该文章的地址应该保留")
+	}
+	svc.releaseArticle(3, otherKey, false)
+	if !svc.claimArticle(2, otherKey) {
+		t.Error("占用的地址抓取失败时应该由第二个地址接着抓取")
+	}
+	want := map[string]string{shortURL: longURL}
+	if !reflect.DeepEqual(svc.DuplicateURLs, want) {
+		t.Errorf("跳过的地址为 %v，期望为 %v", svc.DuplicateURLs, want)
+	}
+}
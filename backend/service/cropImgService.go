@@ -2,16 +2,22 @@ package service
 
 import (
 	"bytes" // 用于处理字节缓冲区
+	"encoding/base64"
+	"image"
 	"io"
+	"math"
 	"net/http"      // 用于处理HTTP请求，这里主要用于MIME类型检测
 	"os"            // 用于文件系统操作
 	"path/filepath" // 用于文件路径操作
+	"strconv"
 	"strings"
 	"sync" // 用于并发控制
 
 	"github.com/disintegration/imaging" // 第三方图像处理库，提供更高级的图像处理功能
 	"github.com/pkg/errors"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
 	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"github.com/pudongping/wx-graph-crawl/backend/utils"
 	"go.uber.org/zap"
 )
 
@@ -23,21 +29,91 @@ var bufferPool = sync.Pool{
 }
 
 type CropImgService struct {
-	RootDir        string // 设置要处理的目录路径
-	ConcurrencyMax int    // 设置并发处理的goroutines数量
-	BottomPixel    int    // 裁剪底部的65像素
+	RootDir        string                // 设置要处理的目录路径
+	ConcurrencyMax int                   // 设置并发处理的goroutines数量
+	Geometry       types.CropGeometry    // 裁剪方式，默认只裁掉底部的65像素
+	Mode           types.CropMode        // 保存方式，为空时直接覆盖原图
+	OutputDir      string                // 输出目录，保存方式为 output_dir 时使用
+	JournalDir     string                // 裁剪日志和原图备份所在的目录，保存方式为 backup 时必填，为空时不记录日志
+	Filter         types.ImageFileFilter // 要裁剪的文件范围
+
+	journal *cropJournal
 }
 
+// NewCropImgService 只裁掉图片底部 bottomPixel 像素
 func NewCropImgService(rootDir string, concurrencyMax int, bottomPixel int) *CropImgService {
+	return NewCropImgServiceWithGeometry(rootDir, concurrencyMax, BottomCropGeometry(bottomPixel))
+}
+
+// NewCropImgServiceWithGeometry 按指定的裁剪方式裁剪图片
+func NewCropImgServiceWithGeometry(rootDir string, concurrencyMax int, geometry types.CropGeometry) *CropImgService {
 	return &CropImgService{
 		RootDir:        rootDir,
 		ConcurrencyMax: concurrencyMax,
-		BottomPixel:    bottomPixel,
+		Geometry:       geometry,
+	}
+}
+
+// BottomCropGeometry 只裁掉底部指定像素的默认裁剪方式
+func BottomCropGeometry(bottomPixel int) types.CropGeometry {
+	return types.CropGeometry{
+		Bottom: float64(bottomPixel),
+		Unit:   types.CropUnitPixel,
+	}
+}
+
+// CropGeometryFromRequest 获取请求中的裁剪方式，没有设置时使用只裁剪底部的默认方式
+func CropGeometryFromRequest(req types.CroppingRequest) types.CropGeometry {
+	geometry := req.CropGeometry
+	if geometry == (types.CropGeometry{}) {
+		return BottomCropGeometry(req.BottomPixel)
+	}
+	// 自动识别水印条时，识别不出来就使用设置的底部像素
+	if geometry.AutoBottom && geometry.Bottom == 0 && geometry.Unit != types.CropUnitPercent {
+		geometry.Bottom = float64(req.BottomPixel)
+	}
+	return geometry
+}
+
+// Validate 检查裁剪方式、保存方式和文件范围
+func (svc *CropImgService) Validate() error {
+	if err := ValidateCropGeometry(svc.Geometry); err != nil {
+		return err
+	}
+	if err := ValidateImageFileFilter(svc.RootDir, svc.Filter); err != nil {
+		return err
+	}
+	switch svc.Mode {
+	case "", types.CropModeOverwrite, types.CropModeBackup:
+	case types.CropModeOutputDir:
+		if svc.OutputDir == "" {
+			return errors.New("没有设置输出目录")
+		}
+		if filepath.Clean(svc.OutputDir) == filepath.Clean(svc.RootDir) {
+			return errors.New("输出目录不能和图片目录相同")
+		}
+	default:
+		return errors.Errorf("不支持的保存方式: %s", svc.Mode)
 	}
+	return nil
 }
 
 func (svc *CropImgService) RunCropImg() ([]types.CropResult, error) {
-	return svc.processImages(svc.RootDir, svc.BottomPixel, svc.ConcurrencyMax)
+	if err := svc.Validate(); err != nil {
+		return nil, err
+	}
+	if svc.Mode == types.CropModeBackup && svc.JournalDir == "" {
+		return nil, errors.New("没有设置原图备份目录")
+	}
+	if svc.JournalDir != "" && svc.Mode != types.CropModeOverwrite {
+		journal, err := openCropJournal(svc.JournalDir)
+		if err != nil {
+			return nil, err
+		}
+		defer journal.Close()
+		svc.journal = journal
+	}
+	return svc.processImages(svc.RootDir, svc.ConcurrencyMax)
 }
 
 // isImage 函数检查给定文件是否为图片，通过读取文件的前512字节并检测其MIME类型实现
@@ -60,7 +136,7 @@ func (svc *CropImgService) isImage(file *os.File) bool {
 }
 
 // processFile 函数处理单个图片文件，包括打开文件、检测是否为图片、读取图片、裁剪图片和保存图片
-func (svc *CropImgService) processFile(path string, bottomPixel int, wg *sync.WaitGroup, semaphore chan struct{}, cropResultChan chan types.CropResult) {
+func (svc *CropImgService) processFile(path string, wg *sync.WaitGroup, semaphore chan struct{}, cropResultChan chan types.CropResult) {
 	defer wg.Done()                // 在函数结束时通知WaitGroup，表示一个goroutine完成了工作
 	defer func() { <-semaphore }() // 释放信号量，允许其他goroutine开始执行
 
@@ -93,60 +169,350 @@ func (svc *CropImgService) processFile(path string, bottomPixel int, wg *sync.Wa
 		return
 	}
 
-	// 使用imaging库裁剪图片，裁掉底部的80像素
-	// 在这里要做一个判断，不能让高度减少成为0
-	if img.Bounds().Dy() <= bottomPixel {
-		cropResult.Err = errors.New("裁剪高度不能大于图片高度")
+	// 按裁剪方式裁剪图片，裁剪后的宽高不能为0
+	geometry := ResolveCropGeometry(img, svc.Geometry)
+	cropResult.BottomPixel = cropEdgePixels(geometry.Bottom, img.Bounds().Dy(), geometry.Unit)
+	cropResult.AutoDetected = geometry.AutoBottom
+	croppedImg, err := CropImage(img, geometry)
+	if err != nil {
+		cropResult.Err = errors.Wrapf(err, "裁剪图片 %s 失败", filepath.Base(path))
 		cropResultChan <- cropResult
 		return
 	}
-	croppedImg := imaging.CropAnchor(img, img.Bounds().Dx(), img.Bounds().Dy()-bottomPixel, imaging.Top)
 
-	// 将裁剪后的图片保存回原文件
-	if err = imaging.Save(croppedImg, path); err != nil {
+	// 按保存方式备份原图或确定输出路径，然后保存裁剪后的图片
+	savePath, err := svc.prepareSave(path)
+	if err != nil {
+		cropResult.Err = errors.Wrapf(err, "保存图片 %s 失败", filepath.Base(path))
+		cropResultChan <- cropResult
+		return
+	}
+	saveFormat, err := imaging.FormatFromFilename(savePath)
+	if err != nil {
 		cropResult.Err = errors.Wrap(err, "保存图片失败")
 		cropResultChan <- cropResult
 		return
 	}
+	// 先写入临时文件再替换，直接覆盖原图时保存失败也不会损坏原图
+	if err = saveImageFile(croppedImg, savePath, saveFormat); err != nil {
+		cropResult.Err = err
+		cropResultChan <- cropResult
+		return
+	}
+	cropResult.OutputPath = savePath
 
 	cropResultChan <- cropResult // 将裁剪结果发送到通道中
 
-	zap.L().Info("裁剪并保存成功", zap.String("path", path))
+	zap.L().Info("裁剪并保存成功", zap.String("path", path), zap.Int("bottomPixel", cropResult.BottomPixel), zap.Bool("autoDetected", cropResult.AutoDetected))
 }
 
 // processImages 函数遍历指定目录及其子目录，寻找图片文件并并发处理它们
-func (svc *CropImgService) processImages(rootDir string, bottomPixel int, concurrency int) (cropResults []types.CropResult, err error) {
+func (svc *CropImgService) processImages(rootDir string, concurrency int) (cropResults []types.CropResult, err error) {
 	// 创建一个信号量通道，用于限制并发数量
 	semaphore := make(chan struct{}, concurrency)
 
 	var wg sync.WaitGroup                              // WaitGroup用于等待所有goroutine完成
 	cropResultChan := make(chan types.CropResult, 100) // 创建一个通道，用于接收裁剪结果
-
-	// 遍历目录中的所有文件和子目录
-	err = filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		// 如果是文件，则启动一个goroutine来处理它
-		if !info.IsDir() {
-			wg.Add(1)                                                             // 增加WaitGroup的计数
-			semaphore <- struct{}{}                                               // 获取信号量，如果信号量用尽则阻塞，直到其他goroutine释放信号量
-			go svc.processFile(path, bottomPixel, &wg, semaphore, cropResultChan) // 启动goroutine处理文件
+	// 在单独的goroutine中收集裁剪结果，避免图片数量超过通道容量时阻塞
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for cropResult := range cropResultChan {
+			cropResults = append(cropResults, cropResult)
 		}
+	}()
+
+	// 按过滤条件遍历目录中的图片文件
+	err = WalkImageFiles(rootDir, svc.Filter, func(path string, info os.FileInfo) bool {
+		return svc.skipDir(rootDir, path, info)
+	}, func(path string, info os.FileInfo) error {
+		// 启动一个goroutine来处理文件
+		wg.Add(1)                                                // 增加WaitGroup的计数
+		semaphore <- struct{}{}                                  // 获取信号量，如果信号量用尽则阻塞，直到其他goroutine释放信号量
+		go svc.processFile(path, &wg, semaphore, cropResultChan) // 启动goroutine处理文件
 		return nil
 	})
 
+	// 遍历出错时也要等待已经启动的goroutine完成
+	wg.Wait()             // 等待所有goroutine完成
+	close(cropResultChan) // 关闭通道，表示没有更多的裁剪结果
+	<-done
 	if err != nil {
 		return nil, errors.Wrap(err, "遍历目录时出错")
 	}
 
-	wg.Wait()             // 等待所有goroutine完成
-	close(cropResultChan) // 关闭通道，表示没有更多的裁剪结果
+	return
+}
 
-	// 处理裁剪结果
-	for cropResult := range cropResultChan {
-		cropResults = append(cropResults, cropResult)
+// skipDir 跳过原图备份目录和位于图片目录中的输出目录
+func (svc *CropImgService) skipDir(rootDir, path string, info os.FileInfo) bool {
+	if !info.IsDir() || path == rootDir {
+		return false
 	}
+	return info.Name() == constant.CropBackupDirName || (svc.OutputDir != "" && filepath.Clean(path) == filepath.Clean(svc.OutputDir))
+}
 
-	return
+// Preview 预览裁剪效果，path 为图片时只预览这一张，为目录时预览目录中的前 limit 张图片
+// 与实际裁剪使用相同的裁剪方式和水印识别，返回裁剪前后的缩略图
+func (svc *CropImgService) Preview(path string, limit, thumbnailSize int) ([]types.CropPreviewItem, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "读取预览路径失败")
+	}
+
+	paths := []string{path}
+	if info.IsDir() {
+		paths = paths[:0]
+		err = WalkImageFiles(path, svc.Filter, func(p string, fi os.FileInfo) bool {
+			return svc.skipDir(path, p, fi)
+		}, func(p string, fi os.FileInfo) error {
+			if len(paths) >= limit {
+				return filepath.SkipAll
+			}
+			if svc.isImagePath(p) {
+				paths = append(paths, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "遍历目录时出错")
+		}
+	}
+
+	items := make([]types.CropPreviewItem, 0, len(paths))
+	for _, p := range paths {
+		items = append(items, svc.previewFile(p, thumbnailSize))
+	}
+	return items, nil
+}
+
+// previewFile 裁剪一张图片但不保存，生成裁剪前后的缩略图
+func (svc *CropImgService) previewFile(path string, thumbnailSize int) (item types.CropPreviewItem) {
+	item.ImgPath = path
+	img, err := imaging.Open(path)
+	if err != nil {
+		item.Err = errors.Wrap(err, "解码图片失败").Error()
+		return item
+	}
+	if item.Before, err = cropPreviewImage(img, thumbnailSize); err != nil {
+		item.Err = err.Error()
+		return item
+	}
+
+	geometry := ResolveCropGeometry(img, svc.Geometry)
+	item.BottomPixel = cropEdgePixels(geometry.Bottom, img.Bounds().Dy(), geometry.Unit)
+	item.AutoDetected = geometry.AutoBottom
+	croppedImg, err := CropImage(img, geometry)
+	if err != nil {
+		item.Err = err.Error()
+		return item
+	}
+	if item.After, err = cropPreviewImage(croppedImg, thumbnailSize); err != nil {
+		item.Err = err.Error()
+	}
+	return item
+}
+
+// cropPreviewImage 生成 base64 编码的 JPEG 缩略图，返回的宽高为缩略前的尺寸
+func cropPreviewImage(img image.Image, thumbnailSize int) (preview types.CropPreviewImage, err error) {
+	preview.Width, preview.Height = img.Bounds().Dx(), img.Bounds().Dy()
+
+	buffer := bufferPool.Get().(*bytes.Buffer)
+	defer bufferPool.Put(buffer)
+	buffer.Reset()
+	thumbnail := imaging.Fit(img, thumbnailSize, thumbnailSize, imaging.Box)
+	if err = imaging.Encode(buffer, thumbnail, imaging.JPEG, imaging.JPEGQuality(constant.CropPreviewJPEGQuality)); err != nil {
+		return preview, errors.Wrap(err, "生成缩略图失败")
+	}
+	preview.DataURI = "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buffer.Bytes())
+	return preview, nil
+}
+
+// isImagePath 根据文件内容判断是否为图片
+func (svc *CropImgService) isImagePath(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	return svc.isImage(file)
+}
+
+// prepareSave 返回裁剪后图片的保存路径
+// backup 方式会先备份原图并写入裁剪日志，再覆盖原图；output_dir 方式会在输出目录中保持原来的目录结构
+func (svc *CropImgService) prepareSave(path string) (string, error) {
+	if svc.Mode != types.CropModeBackup && svc.Mode != types.CropModeOutputDir {
+		return path, nil
+	}
+
+	rel, err := filepath.Rel(svc.RootDir, path)
+	if err != nil {
+		return "", errors.Wrap(err, "获取图片相对路径失败")
+	}
+	entry := types.CropJournalEntry{ImgPath: path}
+	savePath := path
+	if svc.Mode == types.CropModeBackup {
+		entry.BackupPath = filepath.Join(svc.JournalDir, rel)
+		if err = utils.CopyFile(path, entry.BackupPath); err != nil {
+			return "", errors.Wrap(err, "备份原图失败")
+		}
+	} else {
+		savePath = filepath.Join(svc.OutputDir, rel)
+		entry.OutputPath = savePath
+		if err = utils.MkdirIfNotExist(filepath.Dir(savePath)); err != nil {
+			return "", err
+		}
+	}
+
+	// 先写日志再写图片，程序中途退出时也可以撤销已经处理过的图片
+	if svc.journal != nil {
+		if err = svc.journal.Append(entry); err != nil {
+			return "", err
+		}
+	}
+	return savePath, nil
+}
+
+var cropAnchors = map[types.CropAnchor]imaging.Anchor{
+	types.CropAnchorCenter:      imaging.Center,
+	types.CropAnchorTop:         imaging.Top,
+	types.CropAnchorBottom:      imaging.Bottom,
+	types.CropAnchorLeft:        imaging.Left,
+	types.CropAnchorRight:       imaging.Right,
+	types.CropAnchorTopLeft:     imaging.TopLeft,
+	types.CropAnchorTopRight:    imaging.TopRight,
+	types.CropAnchorBottomLeft:  imaging.BottomLeft,
+	types.CropAnchorBottomRight: imaging.BottomRight,
+}
+
+// ValidateCropGeometry 检查裁剪方式的参数，与图片尺寸有关的检查在裁剪时进行
+func ValidateCropGeometry(g types.CropGeometry) error {
+	if g.Top < 0 || g.Bottom < 0 || g.Left < 0 || g.Right < 0 {
+		return errors.New("裁剪的大小不能为负数")
+	}
+	switch g.Unit {
+	case "", types.CropUnitPixel:
+	case types.CropUnitPercent:
+		if g.Top+g.Bottom >= 100 || g.Left+g.Right >= 100 {
+			return errors.New("按百分比裁剪时，上下或左右的裁剪比例之和必须小于100")
+		}
+	default:
+		return errors.Errorf("不支持的裁剪单位: %s", g.Unit)
+	}
+	if g.AspectRatio != "" {
+		if _, _, err := parseAspectRatio(g.AspectRatio); err != nil {
+			return err
+		}
+	}
+	if _, ok := cropAnchors[g.Anchor]; g.Anchor != "" && !ok {
+		return errors.Errorf("不支持的裁剪位置: %s", g.Anchor)
+	}
+	if g.MaxWidth < 0 || g.MaxHeight < 0 {
+		return errors.New("最大宽高不能为负数")
+	}
+	return nil
+}
+
+// CropImage 按裁剪方式裁剪图片：先裁掉四边，再按宽高比裁剪，最后等比缩小到最大宽高以内
+func CropImage(img image.Image, g types.CropGeometry) (image.Image, error) {
+	if err := ValidateCropGeometry(g); err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	top, bottom := cropEdgePixels(g.Top, height, g.Unit), cropEdgePixels(g.Bottom, height, g.Unit)
+	left, right := cropEdgePixels(g.Left, width, g.Unit), cropEdgePixels(g.Right, width, g.Unit)
+	// 在这里要做一个判断，不能让宽高减少成为0
+	if top+bottom >= height {
+		return nil, errors.New("裁剪高度不能大于图片高度")
+	}
+	if left+right >= width {
+		return nil, errors.New("裁剪宽度不能大于图片宽度")
+	}
+
+	result := img
+	if top > 0 || bottom > 0 || left > 0 || right > 0 {
+		result = imaging.Crop(img, image.Rect(bounds.Min.X+left, bounds.Min.Y+top, bounds.Max.X-right, bounds.Max.Y-bottom))
+		width, height = width-left-right, height-top-bottom
+	}
+
+	if g.AspectRatio != "" {
+		ratioW, ratioH, _ := parseAspectRatio(g.AspectRatio)
+		cropW, cropH := width, height
+		// 宽度偏大时按高度计算宽度，否则按宽度计算高度
+		if float64(width)*ratioH > float64(height)*ratioW {
+			cropW = int(math.Round(float64(height) * ratioW / ratioH))
+		} else {
+			cropH = int(math.Round(float64(width) * ratioH / ratioW))
+		}
+		cropW, cropH = max(cropW, 1), max(cropH, 1)
+		if cropW != width || cropH != height {
+			anchor, ok := cropAnchors[g.Anchor]
+			if !ok {
+				anchor = imaging.Center
+			}
+			result = imaging.CropAnchor(result, cropW, cropH, anchor)
+			width, height = cropW, cropH
+		}
+	}
+
+	if (g.MaxWidth > 0 && width > g.MaxWidth) || (g.MaxHeight > 0 && height > g.MaxHeight) {
+		maxW, maxH := g.MaxWidth, g.MaxHeight
+		if maxW <= 0 {
+			maxW = width
+		}
+		if maxH <= 0 {
+			maxH = height
+		}
+		result = imaging.Fit(result, maxW, maxH, imaging.Lanczos)
+	}
+
+	// 没有任何裁剪时保持原图的像素格式
+	if result == img {
+		return imaging.Clone(img), nil
+	}
+	return result, nil
+}
+
+// ResolveCropGeometry 开启自动识别时，识别图片底部的水印条作为这张图片的底部裁剪高度
+// 返回的裁剪方式中 AutoBottom 表示是否识别成功，识别失败时使用设置的 Bottom
+func ResolveCropGeometry(img image.Image, g types.CropGeometry) types.CropGeometry {
+	if !g.AutoBottom {
+		return g
+	}
+	strip, ok := utils.DetectWatermarkStrip(img)
+	if !ok {
+		g.AutoBottom = false
+		return g
+	}
+	if g.Unit == types.CropUnitPercent {
+		g.Bottom = float64(strip.Height) * 100 / float64(img.Bounds().Dy())
+	} else {
+		g.Bottom = float64(strip.Height)
+	}
+	return g
+}
+
+// cropEdgePixels 将边距换算为像素
+func cropEdgePixels(value float64, size int, unit types.CropUnit) int {
+	if unit == types.CropUnitPercent {
+		return int(math.Round(value / 100 * float64(size)))
+	}
+	return int(math.Round(value))
+}
+
+// parseAspectRatio 解析 3:4、1:1、9:16 这样的宽高比
+func parseAspectRatio(ratio string) (w, h float64, err error) {
+	parts := strings.Split(strings.TrimSpace(ratio), ":")
+	if len(parts) == 2 {
+		w, err = strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		if err == nil {
+			h, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		}
+		if err == nil && w > 0 && h > 0 {
+			return w, h, nil
+		}
+	}
+	return 0, 0, errors.Errorf("宽高比格式不正确: %s，应为 宽:高，如 3:4", ratio)
 }
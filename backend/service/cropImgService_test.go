@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+)
+
+func TestCropImage(t *testing.T) {
+	// 300x400 的图片，左上角 10x10 为红色，方便检查保留的位置
+	img := image.NewNRGBA(image.Rect(0, 0, 300, 400))
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			img.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+
+	cases := []struct {
+		name          string
+		geometry      types.CropGeometry
+		width, height int
+		topLeftRed    bool
+		wantErr       bool
+	}{
+		{name: "默认只裁剪底部", geometry: BottomCropGeometry(65), width: 300, height: 335, topLeftRed: true},
+		{name: "四边像素", geometry: types.CropGeometry{Top: 5, Bottom: 15, Left: 20, Right: 30}, width: 250, height: 380},
+		{name: "四边百分比", geometry: types.CropGeometry{Top: 10, Bottom: 10, Left: 50, Unit: types.CropUnitPercent}, width: 150, height: 320},
+		{name: "1:1 居中", geometry: types.CropGeometry{AspectRatio: "1:1"}, width: 300, height: 300},
+		{name: "1:1 靠上", geometry: types.CropGeometry{AspectRatio: "1:1", Anchor: types.CropAnchorTop}, width: 300, height: 300, topLeftRed: true},
+		{name: "9:16", geometry: types.CropGeometry{AspectRatio: "9:16", Anchor: types.CropAnchorTopLeft}, width: 225, height: 400, topLeftRed: true},
+		{name: "比例一致时不裁剪", geometry: types.CropGeometry{AspectRatio: "3:4"}, width: 300, height: 400, topLeftRed: true},
+		{name: "裁剪后缩小", geometry: types.CropGeometry{Bottom: 100, AspectRatio: "1:1", MaxWidth: 150}, width: 150, height: 150, topLeftRed: true},
+		{name: "只限制高度", geometry: types.CropGeometry{MaxHeight: 200}, width: 150, height: 200, topLeftRed: true},
+		{name: "小图不放大", geometry: types.CropGeometry{MaxWidth: 1000, MaxHeight: 1000}, width: 300, height: 400, topLeftRed: true},
+		{name: "裁剪高度超过图片", geometry: BottomCropGeometry(400), wantErr: true},
+		{name: "百分比之和过大", geometry: types.CropGeometry{Left: 60, Right: 40, Unit: types.CropUnitPercent}, wantErr: true},
+		{name: "宽高比格式不正确", geometry: types.CropGeometry{AspectRatio: "3x4"}, wantErr: true},
+		{name: "不支持的位置", geometry: types.CropGeometry{AspectRatio: "1:1", Anchor: "middle"}, wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res, err := CropImage(img, c.geometry)
+			if c.wantErr {
+				if err == nil {
+					t.Fatal("应该返回错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("裁剪失败: %v", err)
+			}
+			if res.Bounds().Dx() != c.width || res.Bounds().Dy() != c.height {
+				t.Fatalf("裁剪后的尺寸为 %dx%d，应为 %dx%d", res.Bounds().Dx(), res.Bounds().Dy(), c.width, c.height)
+			}
+			r, _, _, _ := res.At(res.Bounds().Min.X, res.Bounds().Min.Y).RGBA()
+			if (r > 0) != c.topLeftRed {
+				t.Errorf("左上角是否为原图左上角: %v，应为 %v", r > 0, c.topLeftRed)
+			}
+		})
+	}
+}
+
+func TestResolveCropGeometry(t *testing.T) {
+	geometry := CropGeometryFromRequest(types.CroppingRequest{BottomPixel: 65, CropGeometry: types.CropGeometry{AutoBottom: true}})
+	if geometry.Bottom != 65 || !geometry.AutoBottom {
+		t.Fatalf("自动识别时应该使用设置的底部像素作为默认值: %+v", geometry)
+	}
+
+	// 底部没有水印条的图片，使用设置的底部像素
+	img := image.NewNRGBA(image.Rect(0, 0, 300, 400))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 31 % 256)
+	}
+	resolved := ResolveCropGeometry(img, geometry)
+	if resolved.AutoBottom || resolved.Bottom != 65 {
+		t.Errorf("识别失败时应该使用设置的底部像素: %+v", resolved)
+	}
+
+	// 底部有 40 像素白色水印条的图片
+	for y := 360; y < 400; y++ {
+		for x := 0; x < 300; x++ {
+			c := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+			if y >= 372 && y < 388 && x >= 200 && x%4 == 0 {
+				c = color.NRGBA{R: 80, G: 80, B: 80, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	resolved = ResolveCropGeometry(img, geometry)
+	if !resolved.AutoBottom || resolved.Bottom != 40 {
+		t.Errorf("应该识别出 40 像素的水印条: %+v", resolved)
+	}
+}
+
+func TestPreviewCrop(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"1.png", "2.png", "3.png"} {
+		if err := imaging.Save(image.NewNRGBA(image.Rect(0, 0, 400, 800)), filepath.Join(root, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "0.txt"), []byte("不是图片"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	req := types.PreviewCropRequest{CroppingRequest: types.CroppingRequest{BottomPixel: 100}, Limit: 2, ThumbnailSize: 200}
+	res, err := NewImageService().PreviewCrop(context.Background(), root, req)
+	if err != nil {
+		t.Fatalf("预览裁剪失败: %v", err)
+	}
+	if len(res.Items) != 2 || filepath.Base(res.Items[0].ImgPath) != "1.png" {
+		t.Fatalf("应该预览目录中的前 2 张图片: %+v", res.Items)
+	}
+	item := res.Items[0]
+	if item.Err != "" || item.Before.Width != 400 || item.Before.Height != 800 || item.After.Height != 700 || item.BottomPixel != 100 {
+		t.Errorf("预览结果不正确: %+v", item)
+	}
+	if !strings.HasPrefix(item.After.DataURI, "data:image/jpeg;base64,") {
+		t.Errorf("缩略图格式不正确: %.40s", item.After.DataURI)
+	}
+	thumbnail, err := imaging.Decode(base64.NewDecoder(base64.StdEncoding, strings.NewReader(strings.TrimPrefix(item.After.DataURI, "data:image/jpeg;base64,"))))
+	if err != nil || thumbnail.Bounds().Dy() != 200 {
+		t.Errorf("缩略图尺寸不正确: %v", err)
+	}
+
+	// 预览单张图片，裁剪参数不正确时返回错误
+	res, err = NewImageService().PreviewCrop(context.Background(), filepath.Join(root, "3.png"), req)
+	if err != nil || len(res.Items) != 1 {
+		t.Fatalf("预览单张图片失败: %v, %+v", err, res)
+	}
+	req.AspectRatio = "1-1"
+	if _, err = NewImageService().PreviewCrop(context.Background(), root, req); err == nil {
+		t.Error("宽高比格式不正确时应该返回错误")
+	}
+}
+
+func TestRunCropImgManyFiles(t *testing.T) {
+	// 图片数量超过结果通道的容量时也不会阻塞
+	root := t.TempDir()
+	const count = 150
+	for i := 0; i < count; i++ {
+		if err := imaging.Save(image.NewNRGBA(image.Rect(0, 0, 10, 20)), filepath.Join(root, strconv.Itoa(i)+".png")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cropSvc := NewCropImgService(root, 4, 5)
+	cropSvc.Mode = types.CropModeOverwrite
+	results, err := cropSvc.RunCropImg()
+	if err != nil || len(results) != count {
+		t.Fatalf("应该裁剪 %d 张图片，实际为 %d: %v", count, len(results), err)
+	}
+	for _, res := range results {
+		if res.Err != nil {
+			t.Fatalf("裁剪 %s 失败: %v", res.ImgPath, res.Err)
+		}
+	}
+	if matches, _ := filepath.Glob(filepath.Join(root, "*.tmp")); len(matches) > 0 {
+		t.Errorf("覆盖原图后不应该留下临时文件: %v", matches)
+	}
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/global"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"github.com/pudongping/wx-graph-crawl/backend/utils"
+	"go.uber.org/zap"
+)
+
+// CropJobService 裁剪任务的记录和撤销
+type CropJobService struct {
+	DB *sqlx.DB
+}
+
+func NewCropJobService() *CropJobService {
+	return &CropJobService{
+		DB: global.DB,
+	}
+}
+
+// CreateJob 新建裁剪任务，生成任务ID和裁剪日志目录
+// backup 方式的日志和原图备份放在图片目录的 .wxgc_backup 中，output_dir 方式放在输出目录的 .wxgc_backup 中，不改动原图目录
+func (svc *CropJobService) CreateJob(ctx context.Context, rootDir string, mode types.CropMode, outputDir string) (job types.CropJob, err error) {
+	now := time.Now()
+	job = types.CropJob{
+		JobID:     fmt.Sprintf("%s%03d", now.Format(constant.CropJobIDTimeLayout), now.Nanosecond()/int(time.Millisecond)),
+		RootDir:   rootDir,
+		Mode:      mode,
+		OutputDir: outputDir,
+		CreatedAt: now.Unix(),
+	}
+	journalBase := rootDir
+	if mode == types.CropModeOutputDir {
+		journalBase = outputDir
+	}
+	job.JournalDir = filepath.Join(journalBase, constant.CropBackupDirName, job.JobID)
+
+	result, err := svc.DB.NamedExecContext(ctx, `
+		INSERT INTO crop_jobs (job_id, root_dir, mode, output_dir, journal_dir, image_count, created_at, undone_at)
+		VALUES (:job_id, :root_dir, :mode, :output_dir, :journal_dir, :image_count, :created_at, :undone_at)
+	`, job)
+	if err != nil {
+		return job, errors.Wrap(err, "保存裁剪任务失败")
+	}
+	job.ID, err = result.LastInsertId()
+	if err != nil {
+		return job, errors.Wrap(err, "获取裁剪任务ID失败")
+	}
+	return job, nil
+}
+
+// FinishJob 记录裁剪成功的图片数量
+func (svc *CropJobService) FinishJob(ctx context.Context, jobID string, imageCount int64) error {
+	_, err := svc.DB.ExecContext(ctx, "UPDATE crop_jobs SET image_count = ? WHERE job_id = ?", imageCount, jobID)
+	if err != nil {
+		return errors.Wrap(err, "更新裁剪任务失败")
+	}
+	return nil
+}
+
+// GetJob 根据任务ID获取裁剪任务
+func (svc *CropJobService) GetJob(ctx context.Context, jobID string) (job types.CropJob, err error) {
+	err = svc.DB.GetContext(ctx, &job, "SELECT * FROM crop_jobs WHERE job_id = ?", jobID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return job, errors.Errorf("裁剪任务 %s 不存在", jobID)
+		}
+		return job, errors.Wrap(err, "查询裁剪任务失败")
+	}
+	return job, nil
+}
+
+// UndoCrop 撤销裁剪或添加水印：backup 方式用备份的原图覆盖处理后的图片，output_dir 方式删除输出的图片
+// 全部恢复成功后才会删除备份，部分失败时可以再次撤销
+func (svc *CropJobService) UndoCrop(ctx context.Context, jobID string) (res types.UndoCropResponse, err error) {
+	res.JobID = jobID
+	job, err := svc.GetJob(ctx, jobID)
+	if err != nil {
+		return res, err
+	}
+	if job.UndoneAt > 0 {
+		return res, errors.Errorf("裁剪任务 %s 已经撤销过了", jobID)
+	}
+	if job.Mode != types.CropModeBackup && job.Mode != types.CropModeOutputDir {
+		return res, errors.Errorf("裁剪任务 %s 直接覆盖了原图，无法撤销", jobID)
+	}
+
+	entries, err := readCropJournal(job.JournalDir)
+	if err != nil {
+		return res, err
+	}
+
+	var errs []string
+	renames := make(map[string]string) // 添加水印时转换了格式的图片，恢复后更新抓取记录中的路径
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		switch {
+		case job.Mode == types.CropModeBackup && entry.BackupPath != "":
+			err = utils.CopyFile(entry.BackupPath, entry.ImgPath)
+			if err == nil && entry.OutputPath != "" && entry.OutputPath != entry.ImgPath {
+				if err = os.Remove(entry.OutputPath); os.IsNotExist(err) {
+					err = nil
+				}
+				renames[entry.OutputPath] = entry.ImgPath
+			}
+		case job.Mode == types.CropModeOutputDir && entry.OutputPath != "":
+			if err = os.Remove(entry.OutputPath); os.IsNotExist(err) {
+				err = nil
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			zap.L().Error("撤销裁剪失败", zap.String("path", entry.ImgPath), zap.Error(err))
+			errs = append(errs, err.Error())
+			continue
+		}
+		res.RestoredCount++
+	}
+	if err = RemapCrawlManifest(job.RootDir, renames); err != nil {
+		zap.L().Warn("更新抓取记录中的图片路径失败", zap.Error(err))
+	}
+	if len(errs) > 0 {
+		res.ErrContent = strings.Join(errs, " | \n")
+		return res, nil
+	}
+
+	if err = os.RemoveAll(job.JournalDir); err != nil {
+		zap.L().Warn("删除裁剪日志目录失败", zap.String("dir", job.JournalDir), zap.Error(err))
+	}
+	_ = os.Remove(filepath.Dir(job.JournalDir)) // .wxgc_backup 为空时一并删除
+
+	_, err = svc.DB.ExecContext(ctx, "UPDATE crop_jobs SET undone_at = ? WHERE job_id = ?", time.Now().Unix(), jobID)
+	if err != nil {
+		return res, errors.Wrap(err, "更新裁剪任务失败")
+	}
+	return res, nil
+}
+
+// cropJournal 裁剪日志，每处理一张图片追加一行
+type cropJournal struct {
+	mu   sync.Mutex
+	dir  string
+	file *os.File
+}
+
+func openCropJournal(dir string) (*cropJournal, error) {
+	if err := utils.MkdirIfNotExist(dir); err != nil {
+		return nil, errors.Wrap(err, "创建裁剪日志目录失败")
+	}
+	file, err := os.OpenFile(filepath.Join(dir, constant.CropJournalFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "打开裁剪日志失败")
+	}
+	return &cropJournal{dir: dir, file: file}, nil
+}
+
+// Backup 备份 rootDir 中的原图并追加一行日志，outputPath 与原图路径不同时撤销会删除 outputPath
+func (j *cropJournal) Backup(rootDir, path, outputPath string) error {
+	rel, err := filepath.Rel(rootDir, path)
+	if err != nil {
+		return errors.Wrap(err, "获取图片相对路径失败")
+	}
+	entry := types.CropJournalEntry{ImgPath: path, BackupPath: filepath.Join(j.dir, rel)}
+	if outputPath != path {
+		entry.OutputPath = outputPath
+	}
+	if err = utils.CopyFile(path, entry.BackupPath); err != nil {
+		return errors.Wrap(err, "备份原图失败")
+	}
+	return j.Append(entry)
+}
+
+// Append 追加一行日志并立即写入磁盘
+func (j *cropJournal) Append(entry types.CropJournalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "序列化裁剪日志失败")
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err = j.file.Write(append(line, '\n')); err != nil {
+		return errors.Wrap(err, "写入裁剪日志失败")
+	}
+	return j.file.Sync()
+}
+
+func (j *cropJournal) Close() error {
+	return j.file.Close()
+}
+
+// readCropJournal 读取裁剪日志，忽略程序中途退出时没有写完整的最后一行
+func readCropJournal(dir string) ([]types.CropJournalEntry, error) {
+	file, err := os.Open(filepath.Join(dir, constant.CropJournalFileName))
+	if err != nil {
+		return nil, errors.Wrap(err, "打开裁剪日志失败")
+	}
+	defer file.Close()
+
+	entries := make([]types.CropJournalEntry, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry types.CropJournalEntry
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			zap.L().Warn("跳过无法解析的裁剪日志", zap.String("line", scanner.Text()), zap.Error(err))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "读取裁剪日志失败")
+	}
+	return entries, nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+)
+
+func imageHeight(t *testing.T, path string) int {
+	t.Helper()
+	img, err := imaging.Open(path)
+	if err != nil {
+		t.Fatalf("打开图片 %s 失败: %v", path, err)
+	}
+	return img.Bounds().Dy()
+}
+
+func TestCropJobUndo(t *testing.T) {
+	ctx := context.Background()
+	jobSvc := &CropJobService{DB: openTestDB(t)}
+
+	root := t.TempDir()
+	imgPaths := []string{filepath.Join(root, "1.png"), filepath.Join(root, "sub", "2.png")}
+	for _, path := range imgPaths {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := imaging.Save(image.NewNRGBA(image.Rect(0, 0, 50, 100)), path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runCrop := func(mode types.CropMode, outputDir string) types.CropJob {
+		job, err := jobSvc.CreateJob(ctx, root, mode, outputDir)
+		if err != nil {
+			t.Fatalf("创建裁剪任务失败: %v", err)
+		}
+		cropSvc := NewCropImgService(root, 2, 10)
+		cropSvc.Mode, cropSvc.OutputDir, cropSvc.JournalDir = mode, outputDir, job.JournalDir
+		results, err := cropSvc.RunCropImg()
+		if err != nil || len(results) != len(imgPaths) {
+			t.Fatalf("裁剪失败: %v, %+v", err, results)
+		}
+		for _, res := range results {
+			if res.Err != nil {
+				t.Fatalf("裁剪 %s 失败: %v", res.ImgPath, res.Err)
+			}
+		}
+		return job
+	}
+
+	// 原地裁剪并备份原图
+	job := runCrop(types.CropModeBackup, "")
+	for _, path := range imgPaths {
+		if h := imageHeight(t, path); h != 90 {
+			t.Errorf("%s 裁剪后的高度为 %d，应为 90", path, h)
+		}
+	}
+	// 再次裁剪时不会处理备份目录中的原图
+	job2 := runCrop(types.CropModeBackup, "")
+
+	for _, j := range []types.CropJob{job2, job} {
+		res, err := jobSvc.UndoCrop(ctx, j.JobID)
+		if err != nil || res.RestoredCount != 2 || res.ErrContent != "" {
+			t.Fatalf("撤销裁剪失败: %v, %+v", err, res)
+		}
+	}
+	for _, path := range imgPaths {
+		if h := imageHeight(t, path); h != 100 {
+			t.Errorf("%s 撤销后的高度为 %d，应为 100", path, h)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, constant.CropBackupDirName)); !os.IsNotExist(err) {
+		t.Errorf("撤销后应该删除备份目录: %v", err)
+	}
+	if _, err := jobSvc.UndoCrop(ctx, job.JobID); err == nil {
+		t.Error("重复撤销应该返回错误")
+	}
+
+	// 输出到图片目录中的子目录，原图不变
+	outputDir := filepath.Join(root, "output")
+	job = runCrop(types.CropModeOutputDir, outputDir)
+	for _, path := range imgPaths {
+		rel, _ := filepath.Rel(root, path)
+		if h := imageHeight(t, path); h != 100 {
+			t.Errorf("原图 %s 的高度为 %d，应为 100", path, h)
+		}
+		if h := imageHeight(t, filepath.Join(outputDir, rel)); h != 90 {
+			t.Errorf("输出图片 %s 的高度为 %d，应为 90", rel, h)
+		}
+	}
+	res, err := jobSvc.UndoCrop(ctx, job.JobID)
+	if err != nil || res.RestoredCount != 2 {
+		t.Fatalf("撤销裁剪失败: %v, %+v", err, res)
+	}
+	if _, err = os.Stat(filepath.Join(outputDir, "sub", "2.png")); !os.IsNotExist(err) {
+		t.Errorf("撤销后应该删除输出的图片: %v", err)
+	}
+
+	// 直接覆盖原图的任务无法撤销
+	job, err = jobSvc.CreateJob(ctx, root, types.CropModeOverwrite, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = jobSvc.UndoCrop(ctx, job.JobID); err == nil {
+		t.Error("直接覆盖原图的任务应该无法撤销")
+	}
+}
@@ -0,0 +1,603 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/pudongping/wx-graph-crawl/backend/bootstrap"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/global"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"go.uber.org/zap"
+)
+
+// DataTransferService 导出和导入偏好设置、专辑订阅、同步记录、抓取记录和抓取失败记录，用于迁移到另一台电脑
+type DataTransferService struct {
+	DB *sqlx.DB
+}
+
+func NewDataTransferService() *DataTransferService {
+	return &DataTransferService{
+		DB: global.DB,
+	}
+}
+
+// Export 导出数据到 req.Path
+func (svc *DataTransferService) Export(ctx context.Context, req types.ExportDataRequest) (res types.ExportDataResponse, err error) {
+	start := time.Now()
+	if req.Path == "" {
+		return res, errors.New("导出文件的保存路径不能为空")
+	}
+	if req.Format == "" {
+		req.Format = types.DataExportJSON
+		if strings.EqualFold(filepath.Ext(req.Path), ".zip") {
+			req.Format = types.DataExportZip
+		}
+	}
+	if req.Format != types.DataExportJSON && req.Format != types.DataExportZip {
+		return res, errors.Errorf("不支持的导出格式 %s", req.Format)
+	}
+
+	bundle, err := svc.BuildExportBundle(ctx, req.SaveImgPath)
+	if err != nil {
+		return res, err
+	}
+	content, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return res, errors.Wrap(err, "序列化导出数据失败")
+	}
+	if req.Format == types.DataExportZip {
+		if content, err = zipBytes(constant.DataExportZipEntry, content); err != nil {
+			return res, errors.Wrap(err, "压缩导出数据失败")
+		}
+	}
+	if err = os.MkdirAll(filepath.Dir(req.Path), 0755); err != nil {
+		return res, errors.Wrap(err, "创建导出目录失败")
+	}
+	if err = os.WriteFile(req.Path, content, 0644); err != nil {
+		return res, errors.Wrap(err, "写入导出文件失败")
+	}
+
+	res.Path = req.Path
+	res.SubscriptionCount = len(bundle.Subscriptions)
+	res.CrawlHistoryCount = len(bundle.CrawlHistory)
+	res.CrawlRunCount = len(bundle.CrawlRuns)
+	res.FailureCount = len(bundle.FailureRecords)
+	res.CastTimeStr = time.Since(start).String()
+	return res, nil
+}
+
+// BuildExportBundle 读取需要导出的全部数据，saveImgPath 为空时从偏好设置中的图片保存目录读取失败记录
+func (svc *DataTransferService) BuildExportBundle(ctx context.Context, saveImgPath string) (bundle types.DataExportBundle, err error) {
+	bundle.FormatVersion = constant.DataExportFormatVersion
+	bundle.ExportedAt = time.Now().Unix()
+	if bundle.SchemaVersion, err = bootstrap.SchemaVersion(svc.DB); err != nil {
+		return bundle, err
+	}
+
+	var prefContent string
+	err = svc.DB.GetContext(ctx, &prefContent, "SELECT content FROM system_configs WHERE key = ?", constant.SystemConfigKeyPreferenceInfo)
+	switch {
+	case err == nil && prefContent != "":
+		bundle.Preferences = json.RawMessage(prefContent)
+		if saveImgPath == "" {
+			var pref types.GetPreferenceInfoResponse
+			if json.Unmarshal([]byte(prefContent), &pref) == nil {
+				saveImgPath = pref.SaveImgPath
+			}
+		}
+	case err != nil && !isNoRows(err):
+		return bundle, errors.Wrap(err, "读取偏好设置失败")
+	}
+	bundle.SaveImgPath = saveImgPath
+
+	subs := make([]types.AlbumSubscription, 0)
+	if err = svc.DB.SelectContext(ctx, &subs, "SELECT * FROM album_subscriptions ORDER BY id"); err != nil {
+		return bundle, errors.Wrap(err, "读取专辑订阅失败")
+	}
+	bundle.Subscriptions = make([]types.ExportedSubscription, 0, len(subs))
+	for _, sub := range subs {
+		articles := make([]types.SubscriptionArticle, 0)
+		if err = svc.DB.SelectContext(ctx, &articles, "SELECT * FROM album_subscription_articles WHERE subscription_id = ? ORDER BY id", sub.ID); err != nil {
+			return bundle, errors.Wrap(err, "读取已同步文章失败")
+		}
+		bundle.Subscriptions = append(bundle.Subscriptions, types.ExportedSubscription{AlbumSubscription: sub, Articles: articles})
+	}
+
+	bundle.CrawlHistory = make([]types.AlbumSubscriptionRun, 0)
+	if err = svc.DB.SelectContext(ctx, &bundle.CrawlHistory, "SELECT * FROM album_subscription_runs ORDER BY id"); err != nil {
+		return bundle, errors.Wrap(err, "读取同步记录失败")
+	}
+
+	if bundle.CrawlRuns, err = svc.exportCrawlRuns(ctx); err != nil {
+		return bundle, err
+	}
+
+	bundle.FailureRecords = make([]types.FailureRecord, 0)
+	if saveImgPath != "" {
+		if bundle.FailureRecords, err = readFailureRecords(filepath.Join(saveImgPath, constant.FailedDownloadDir)); err != nil {
+			return bundle, err
+		}
+	}
+	return bundle, nil
+}
+
+// ReadExportBundle 读取导出文件，支持 JSON 和 zip 格式
+func ReadExportBundle(path string) (bundle types.DataExportBundle, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return bundle, errors.Wrap(err, "读取导出文件失败")
+	}
+	if bytes.HasPrefix(content, []byte("PK")) {
+		if content, err = unzipEntry(content, constant.DataExportZipEntry); err != nil {
+			return bundle, errors.Wrap(err, "解压导出文件失败")
+		}
+	}
+	if err = json.Unmarshal(content, &bundle); err != nil {
+		return bundle, errors.Wrap(err, "导出文件格式不正确")
+	}
+	if bundle.FormatVersion < 1 {
+		return bundle, errors.New("不是有效的导出文件")
+	}
+	if bundle.FormatVersion > constant.DataExportFormatVersion {
+		return bundle, errors.Errorf("导出文件的格式版本 %d 高于当前支持的版本 %d，请升级到最新版本后再导入", bundle.FormatVersion, constant.DataExportFormatVersion)
+	}
+	return bundle, nil
+}
+
+// Import 导入导出文件中的数据，专辑订阅和同步记录在一个事务中导入，本机不存在的目录会被替换
+func (svc *DataTransferService) Import(ctx context.Context, req types.ImportDataRequest) (res types.ImportDataResponse, err error) {
+	start := time.Now()
+	if req.Mode == "" {
+		req.Mode = types.DataImportMerge
+	}
+	if req.Mode != types.DataImportMerge && req.Mode != types.DataImportReplace {
+		return res, errors.Errorf("不支持的导入方式 %s", req.Mode)
+	}
+	res.Mode = req.Mode
+	res.RemappedPaths = make([]types.PathRemap, 0)
+	res.MissingPaths = make([]string, 0)
+	res.Warnings = make([]string, 0)
+
+	bundle, err := ReadExportBundle(req.Path)
+	if err != nil {
+		return res, err
+	}
+	// 数据库版本不同时只导入双方都有的字段，新版本中增加的数据会被忽略
+	if latest := bootstrap.LatestSchemaVersion(); bundle.SchemaVersion > latest {
+		res.Warnings = append(res.Warnings, fmt.Sprintf("导出文件来自更新的版本（数据库版本 %d，本机 %d），本机不支持的数据不会导入", bundle.SchemaVersion, latest))
+	}
+
+	userSvc := &UserService{DB: svc.DB}
+	localPref, err := userSvc.GetPreferenceInfo()
+	if err != nil {
+		return res, errors.Wrap(err, "读取本机偏好设置失败")
+	}
+	target := req.SaveImgPath
+	if target == "" && localPref != nil {
+		target = localPref.SaveImgPath
+	}
+	remapper := newPathRemapper(req.PathRemap, bundle.SaveImgPath, target, &res)
+
+	// 专辑订阅和抓取记录在同一个事务中导入，任何一部分失败时都不修改本机数据
+	tx, err := svc.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return res, errors.Wrap(err, "开启事务失败")
+	}
+	defer tx.Rollback()
+	if err = svc.importSubscriptions(ctx, tx, bundle, req.Mode, remapper, &res); err != nil {
+		return res, err
+	}
+	if err = svc.importCrawlRuns(ctx, tx, bundle, req.Mode, remapper, &res); err != nil {
+		return res, err
+	}
+	if err = tx.Commit(); err != nil {
+		return res, errors.Wrap(err, "提交导入数据失败")
+	}
+
+	// 数据库中的数据导入成功后，再保存偏好设置和抓取失败记录
+
+	if len(bundle.Preferences) > 0 && string(bundle.Preferences) != "null" && (req.Mode == types.DataImportReplace || localPref == nil) {
+		var pref types.SetPreferenceInfoRequest
+		if err = json.Unmarshal(bundle.Preferences, &pref); err != nil {
+			res.Warnings = append(res.Warnings, "偏好设置格式不正确，没有导入")
+		} else {
+			pref.SaveImgPath = remapper.remap(pref.SaveImgPath)
+			if _, err = userSvc.SetPreferenceInfo(ctx, pref); err != nil {
+				res.Warnings = append(res.Warnings, "偏好设置没有导入："+err.Error())
+			} else {
+				res.PreferencesSaved = true
+			}
+		}
+	}
+
+	if len(bundle.FailureRecords) > 0 {
+		dir := remapper.remap(bundle.SaveImgPath)
+		if dir == "" || !pathExists(dir) {
+			res.Warnings = append(res.Warnings, "没有可用的图片保存目录，抓取失败记录没有导入")
+		} else if res.FailureCount, err = writeFailureRecords(filepath.Join(dir, constant.FailedDownloadDir), bundle.FailureRecords, req.Mode); err != nil {
+			res.Warnings = append(res.Warnings, "抓取失败记录没有导入："+err.Error())
+		}
+	}
+
+	res.CastTimeStr = time.Since(start).String()
+	zap.L().Info("导入数据完成", zap.String("path", req.Path), zap.String("mode", string(req.Mode)),
+		zap.Int("subscriptions", res.SubscriptionCount), zap.Int("history", res.CrawlHistoryCount), zap.Int("crawlRuns", res.CrawlRunCount))
+	return res, nil
+}
+
+// importSubscriptions 导入专辑订阅、已同步文章和同步记录
+// 合并时已订阅的专辑保留本机的设置，只补充本机没有的已同步文章和同步记录
+func (svc *DataTransferService) importSubscriptions(ctx context.Context, tx *sqlx.Tx, bundle types.DataExportBundle, mode types.DataImportMode, remapper *pathRemapper, res *types.ImportDataResponse) (err error) {
+	if mode == types.DataImportReplace {
+		for _, table := range []string{"album_subscription_runs", "album_subscription_articles", "album_subscriptions"} {
+			if _, err = tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+				return errors.Wrap(err, "清空本机专辑订阅失败")
+			}
+		}
+	}
+
+	// 导出文件中的订阅ID对应的本机订阅ID
+	subIDs := make(map[int64]int64, len(bundle.Subscriptions))
+	for _, sub := range bundle.Subscriptions {
+		var localID int64
+		err = tx.GetContext(ctx, &localID, "SELECT id FROM album_subscriptions WHERE biz = ? AND album_id = ?", sub.Biz, sub.AlbumID)
+		if isNoRows(err) {
+			var result sql.Result
+			result, err = tx.NamedExecContext(ctx, `
+				INSERT INTO album_subscriptions (album_url, biz, album_id, title, nick_name, interval_minutes, enabled, crawl_existing, last_synced_at, next_sync_at, created_at, updated_at)
+				VALUES (:album_url, :biz, :album_id, :title, :nick_name, :interval_minutes, :enabled, :crawl_existing, :last_synced_at, :next_sync_at, :created_at, :updated_at)
+			`, sub.AlbumSubscription)
+			if err == nil {
+				localID, err = result.LastInsertId()
+			}
+			res.SubscriptionCount++
+		}
+		if err != nil {
+			return errors.Wrapf(err, "导入专辑订阅 %s 失败", sub.AlbumURL)
+		}
+		subIDs[sub.ID] = localID
+
+		for _, article := range sub.Articles {
+			result, err := tx.ExecContext(ctx,
+				"INSERT OR IGNORE INTO album_subscription_articles (subscription_id, article_key, url, msgid, title, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+				localID, article.ArticleKey, article.URL, article.MsgID, article.Title, article.CreatedAt)
+			if err != nil {
+				return errors.Wrap(err, "导入已同步文章失败")
+			}
+			if affected, _ := result.RowsAffected(); affected > 0 {
+				res.ArticleCount++
+			}
+		}
+	}
+
+	for _, run := range bundle.CrawlHistory {
+		localID, ok := subIDs[run.SubscriptionID]
+		if !ok {
+			continue
+		}
+		var exists int
+		if err = tx.GetContext(ctx, &exists, "SELECT COUNT(*) FROM album_subscription_runs WHERE subscription_id = ? AND started_at = ?", localID, run.StartedAt); err != nil {
+			return errors.Wrap(err, "查询同步记录失败")
+		}
+		if exists > 0 {
+			continue
+		}
+		run.SubscriptionID = localID
+		run.SavePath = remapper.remap(run.SavePath)
+		if _, err = tx.NamedExecContext(ctx, `
+			INSERT INTO album_subscription_runs (subscription_id, status, article_count, new_article_count, crawl_img_count, save_path, err_content, started_at, finished_at)
+			VALUES (:subscription_id, :status, :article_count, :new_article_count, :crawl_img_count, :save_path, :err_content, :started_at, :finished_at)
+		`, run); err != nil {
+			return errors.Wrap(err, "导入同步记录失败")
+		}
+		res.CrawlHistoryCount++
+	}
+	return nil
+}
+
+// exportCrawlRuns 读取全部抓取记录及每篇文章的结果
+func (svc *DataTransferService) exportCrawlRuns(ctx context.Context) ([]types.ExportedCrawlRun, error) {
+	runs := make([]types.CrawlRun, 0)
+	if err := svc.DB.SelectContext(ctx, &runs, "SELECT * FROM crawl_runs ORDER BY id"); err != nil {
+		return nil, errors.Wrap(err, "读取抓取记录失败")
+	}
+	exported := make([]types.ExportedCrawlRun, 0, len(runs))
+	for _, run := range runs {
+		item := types.ExportedCrawlRun{CrawlRun: run, RequestedURLs: make([]string, 0), Options: json.RawMessage(run.Options)}
+		if err := json.Unmarshal([]byte(run.RequestedURLs), &item.RequestedURLs); err != nil {
+			zap.L().Warn("抓取记录中的链接地址格式不正确", zap.Int64("id", run.ID), zap.Error(err))
+		}
+		if !json.Valid(item.Options) {
+			item.Options = json.RawMessage("{}")
+		}
+
+		articles := make([]types.CrawlRunArticle, 0)
+		if err := svc.DB.SelectContext(ctx, &articles, "SELECT * FROM crawl_run_articles WHERE run_id = ? ORDER BY id", run.ID); err != nil {
+			return nil, errors.Wrap(err, "读取文章抓取结果失败")
+		}
+		item.Articles = make([]types.ExportedCrawlRunArticle, 0, len(articles))
+		for _, article := range articles {
+			exportedArticle := types.ExportedCrawlRunArticle{CrawlRunArticle: article, Files: make([]string, 0)}
+			if err := json.Unmarshal([]byte(article.Files), &exportedArticle.Files); err != nil {
+				zap.L().Warn("文章抓取结果中的文件列表格式不正确", zap.Int64("id", article.ID), zap.Error(err))
+			}
+			item.Articles = append(item.Articles, exportedArticle)
+		}
+		exported = append(exported, item)
+	}
+	return exported, nil
+}
+
+// importCrawlRuns 导入抓取记录及每篇文章的结果，记录中的目录和文件路径会替换为本机的路径
+// 合并时跳过开始时间和请求的链接地址都相同的记录
+func (svc *DataTransferService) importCrawlRuns(ctx context.Context, tx *sqlx.Tx, bundle types.DataExportBundle, mode types.DataImportMode, remapper *pathRemapper, res *types.ImportDataResponse) (err error) {
+	if mode == types.DataImportReplace {
+		for _, table := range []string{"crawl_run_articles", "crawl_runs"} {
+			if _, err = tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+				return errors.Wrap(err, "清空本机抓取记录失败")
+			}
+		}
+	}
+
+	for _, item := range bundle.CrawlRuns {
+		run := item.CrawlRun
+		requestedURLs, _ := json.Marshal(append([]string{}, item.RequestedURLs...))
+		run.RequestedURLs = string(requestedURLs)
+
+		var exists int
+		if err = tx.GetContext(ctx, &exists, "SELECT COUNT(*) FROM crawl_runs WHERE started_at = ? AND requested_urls = ?", run.StartedAt, run.RequestedURLs); err != nil {
+			return errors.Wrap(err, "查询抓取记录失败")
+		}
+		if exists > 0 {
+			continue
+		}
+
+		// 请求参数中的图片保存目录和记录中的保持一致，重新抓取时保存到本机的目录
+		run.SavePath = remapper.remap(run.SavePath)
+		run.Options = "{}"
+		var options types.CrawlingRequest
+		if len(item.Options) > 0 && json.Unmarshal(item.Options, &options) == nil {
+			options.ImgSavePath = run.SavePath
+			optionsJSON, _ := json.Marshal(options)
+			run.Options = string(optionsJSON)
+		}
+
+		var result sql.Result
+		if result, err = tx.NamedExecContext(ctx, `
+			INSERT INTO crawl_runs (status, save_path, requested_urls, options, url_count, success_count, failed_count, img_count, processed_img_count, err_content, duration_ms, started_at, finished_at)
+			VALUES (:status, :save_path, :requested_urls, :options, :url_count, :success_count, :failed_count, :img_count, :processed_img_count, :err_content, :duration_ms, :started_at, :finished_at)
+		`, run); err != nil {
+			return errors.Wrap(err, "导入抓取记录失败")
+		}
+		var runID int64
+		if runID, err = result.LastInsertId(); err != nil {
+			return errors.Wrap(err, "获取抓取记录ID失败")
+		}
+
+		for _, exportedArticle := range item.Articles {
+			article := exportedArticle.CrawlRunArticle
+			article.RunID = runID
+			article.ImgDir = remapper.remap(article.ImgDir)
+			files := make([]string, 0, len(exportedArticle.Files))
+			for _, file := range exportedArticle.Files {
+				files = append(files, remapper.remap(file))
+			}
+			filesJSON, _ := json.Marshal(files)
+			article.Files = string(filesJSON)
+			if _, err = tx.NamedExecContext(ctx, `
+				INSERT INTO crawl_run_articles (run_id, article_key, url, title, status, img_count, img_dir, files, err_content, created_at)
+				VALUES (:run_id, :article_key, :url, :title, :status, :img_count, :img_dir, :files, :err_content, :created_at)
+			`, article); err != nil {
+				return errors.Wrap(err, "导入文章抓取结果失败")
+			}
+		}
+		res.CrawlRunCount++
+	}
+	return nil
+}
+
+// pathRemapper 把导出文件中本机不存在的目录替换为本机的目录
+// 先使用指定的替换规则，再把导出时的图片保存目录替换为本机的图片保存目录
+type pathRemapper struct {
+	rules    []types.PathRemap
+	remapped map[string]string
+	res      *types.ImportDataResponse
+}
+
+func newPathRemapper(rules map[string]string, fromSaveDir, toSaveDir string, res *types.ImportDataResponse) *pathRemapper {
+	r := &pathRemapper{remapped: make(map[string]string), res: res}
+	for from, to := range rules {
+		if from != "" && to != "" {
+			r.rules = append(r.rules, types.PathRemap{From: from, To: to})
+		}
+	}
+	// 前缀越长越先匹配
+	sort.Slice(r.rules, func(i, j int) bool {
+		return len(r.rules[i].From) > len(r.rules[j].From)
+	})
+	if fromSaveDir != "" && toSaveDir != "" {
+		r.rules = append(r.rules, types.PathRemap{From: fromSaveDir, To: toSaveDir})
+	}
+	return r
+}
+
+// remap 返回本机可以使用的路径，路径存在时不替换，无法替换时返回原路径并记录到 MissingPaths 中
+func (r *pathRemapper) remap(path string) string {
+	if path == "" || pathExists(path) {
+		return path
+	}
+	if to, ok := r.remapped[path]; ok {
+		return to
+	}
+	to := path
+	for _, rule := range r.rules {
+		if rest, ok := trimPathPrefix(path, rule.From); ok {
+			to = filepath.Join(rule.To, filepath.FromSlash(rest))
+			r.res.RemappedPaths = append(r.res.RemappedPaths, types.PathRemap{From: path, To: to})
+			break
+		}
+	}
+	if to == path {
+		r.res.MissingPaths = append(r.res.MissingPaths, path)
+	}
+	r.remapped[path] = to
+	return to
+}
+
+// trimPathPrefix 判断 path 是否位于 prefix 中，返回剩余部分，导出和导入的电脑可能使用不同的路径分隔符
+func trimPathPrefix(path, prefix string) (string, bool) {
+	path = strings.TrimRight(strings.ReplaceAll(path, `\`, "/"), "/")
+	prefix = strings.TrimRight(strings.ReplaceAll(prefix, `\`, "/"), "/")
+	if path == prefix {
+		return "", true
+	}
+	if strings.HasPrefix(path, prefix+"/") {
+		return path[len(prefix)+1:], true
+	}
+	return "", false
+}
+
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// readFailureRecords 读取目录中全部抓取失败记录的 CSV 文件，目录不存在时返回空
+func readFailureRecords(dir string) ([]types.FailureRecord, error) {
+	records := make([]types.FailureRecord, 0)
+	files, err := filepath.Glob(filepath.Join(dir, "failed_*.csv"))
+	if err != nil {
+		return records, errors.Wrap(err, "读取抓取失败记录失败")
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		rows, err := readCSVFile(file)
+		if err != nil {
+			return records, errors.Wrapf(err, "读取 %s 失败", file)
+		}
+		for i, row := range rows {
+			if i == 0 || len(row) < 3 { // 第一行为表头
+				continue
+			}
+			records = append(records, types.FailureRecord{File: filepath.Base(file), Time: row[0], URL: row[1], Error: row[2]})
+		}
+	}
+	return records, nil
+}
+
+// writeFailureRecords 按原来的文件名写入抓取失败记录，合并时跳过已有的记录，替换时覆盖同名文件，返回写入的记录数量
+func writeFailureRecords(dir string, records []types.FailureRecord, mode types.DataImportMode) (written int, err error) {
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+	byFile := make(map[string][]types.FailureRecord)
+	files := make([]string, 0)
+	for _, record := range records {
+		name := filepath.Base(filepath.FromSlash(strings.ReplaceAll(record.File, `\`, "/")))
+		if !strings.HasPrefix(name, "failed_") || !strings.HasSuffix(name, ".csv") {
+			continue
+		}
+		if _, ok := byFile[name]; !ok {
+			files = append(files, name)
+		}
+		byFile[name] = append(byFile[name], record)
+	}
+
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		rows := [][]string{{"时间戳", "URL", "错误信息"}}
+		existing := make(map[string]struct{})
+		if mode == types.DataImportMerge && pathExists(path) {
+			if rows, err = readCSVFile(path); err != nil {
+				return written, errors.Wrapf(err, "读取 %s 失败", path)
+			}
+			for _, row := range rows {
+				existing[strings.Join(row, "\x00")] = struct{}{}
+			}
+		}
+		for _, record := range byFile[name] {
+			row := []string{record.Time, record.URL, record.Error}
+			if _, ok := existing[strings.Join(row, "\x00")]; ok {
+				continue
+			}
+			existing[strings.Join(row, "\x00")] = struct{}{}
+			rows = append(rows, row)
+			written++
+		}
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		if err = writer.WriteAll(rows); err != nil {
+			return written, err
+		}
+		if err = os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return written, errors.Wrapf(err, "写入 %s 失败", path)
+		}
+	}
+	return written, nil
+}
+
+func readCSVFile(path string) ([][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	return reader.ReadAll()
+}
+
+// zipBytes 把内容压缩为只有一个文件的 zip
+func zipBytes(name string, content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	w, err := zipWriter.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(content); err != nil {
+		return nil, err
+	}
+	if err = zipWriter.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// unzipEntry 读取 zip 中指定文件的内容
+func unzipEntry(content []byte, name string) ([]byte, error) {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range reader.File {
+		if file.Name != name {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, errors.Errorf("zip 中没有 %s", name)
+}
+
+func isNoRows(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
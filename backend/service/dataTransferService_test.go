@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+)
+
+func TestExportImportData(t *testing.T) {
+	ctx := context.Background()
+	source := openTestDB(t)
+	sourceSave := filepath.Join(t.TempDir(), "source")
+	failedDir := filepath.Join(sourceSave, constant.FailedDownloadDir)
+	if err := os.MkdirAll(failedDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(failedDir, "failed_微信文章_20260101.csv"), []byte("时间戳,URL,错误信息\n2026-01-01 10:00:00,https://mp.weixin.qq.com/s/x,超时\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (&UserService{DB: source}).SetPreferenceInfo(ctx, types.SetPreferenceInfoRequest{SaveImgPath: sourceSave, DownloadTimeout: 30}); err != nil {
+		t.Fatal(err)
+	}
+	subSvc := &AlbumSubscriptionService{DB: source}
+	sub, err := subSvc.CreateSubscription(ctx, types.CreateAlbumSubscriptionRequest{AlbumURL: urls[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = subSvc.MarkArticlesSeen(ctx, sub.ID, []types.AlbumArticleInfo{{URL: "https://mp.weixin.qq.com/s/a", Title: "a"}}); err != nil {
+		t.Fatal(err)
+	}
+	run := types.AlbumSubscriptionRun{SubscriptionID: sub.ID, Status: types.AlbumSubscriptionRunSuccess, SavePath: filepath.Join(sourceSave, "album"), StartedAt: 100}
+	if err = subSvc.CreateRun(ctx, &run); err != nil {
+		t.Fatal(err)
+	}
+
+	imgDir := filepath.Join(sourceSave, "1_文章")
+	crawlRun := types.CrawlRun{
+		Status:        types.CrawlRunPartial,
+		SavePath:      sourceSave,
+		RequestedURLs: `["` + intakeURL1 + `","` + intakeURL2 + `"]`,
+		Options:       `{"img_save_path":"` + filepath.ToSlash(sourceSave) + `","timeout_seconds":30}`,
+		StartedAt:     200,
+	}
+	crawlArticles := []types.CrawlRunArticle{
+		{ArticleKey: "wx:MzIntake==_1001_1", URL: intakeURL1, Status: types.CrawlArticleSuccess, ImgDir: imgDir, Files: `["` + filepath.ToSlash(imgDir) + `"]`},
+		{ArticleKey: "wx:MzIntake==_1002_1", URL: intakeURL2, Status: types.CrawlArticleFailed, Files: "[]"},
+	}
+	if err = (&CrawlHistoryService{DB: source}).RecordRun(ctx, &crawlRun, crawlArticles); err != nil {
+		t.Fatal(err)
+	}
+
+	exportPath := filepath.Join(t.TempDir(), "export.zip")
+	exportRes, err := (&DataTransferService{DB: source}).Export(ctx, types.ExportDataRequest{Path: exportPath})
+	if err != nil || exportRes.SubscriptionCount != 1 || exportRes.CrawlHistoryCount != 1 || exportRes.CrawlRunCount != 1 || exportRes.FailureCount != 1 {
+		t.Fatalf("导出失败: %+v, %v", exportRes, err)
+	}
+	// 导出时的目录在另一台电脑上不存在
+	if err = os.RemoveAll(sourceSave); err != nil {
+		t.Fatal(err)
+	}
+
+	target := openTestDB(t)
+	targetSave := filepath.Join(t.TempDir(), "target")
+	if err = os.MkdirAll(targetSave, 0755); err != nil {
+		t.Fatal(err)
+	}
+	importSvc := &DataTransferService{DB: target}
+	res, err := importSvc.Import(ctx, types.ImportDataRequest{Path: exportPath, SaveImgPath: targetSave})
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	if !res.PreferencesSaved || res.SubscriptionCount != 1 || res.ArticleCount != 1 || res.CrawlHistoryCount != 1 || res.CrawlRunCount != 1 || res.FailureCount != 1 {
+		t.Errorf("导入结果为 %+v", res)
+	}
+	pref, _ := (&UserService{DB: target}).GetPreferenceInfo()
+	if pref == nil || pref.SaveImgPath != targetSave || pref.DownloadTimeout != 30 {
+		t.Errorf("导入的偏好设置为 %+v", pref)
+	}
+	runs, _ := (&AlbumSubscriptionService{DB: target}).ListRuns(ctx, types.ListAlbumSubscriptionRunsRequest{})
+	if len(runs) != 1 || runs[0].SavePath != filepath.Join(targetSave, "album") {
+		t.Errorf("导入的同步记录为 %+v", runs)
+	}
+	historySvc := &CrawlHistoryService{DB: target}
+	list, _ := historySvc.ListRuns(ctx, types.CrawlRunFilter{}, types.PageRequest{})
+	if list.Total != 1 {
+		t.Fatalf("导入的抓取记录为 %+v", list)
+	}
+	detail, err := historySvc.GetRun(ctx, list.Runs[0].ID)
+	if err != nil || detail.SavePath != targetSave || detail.Request.ImgSavePath != targetSave || len(detail.RequestedURLs) != 2 || len(detail.Articles) != 2 {
+		t.Errorf("导入的抓取记录详情为 %+v, %v", detail, err)
+	}
+	if err == nil && detail.Articles[0].ImgDir != filepath.Join(targetSave, "1_文章") {
+		t.Errorf("文章的图片目录没有替换为本机的目录: %s", detail.Articles[0].ImgDir)
+	}
+	// 导入的抓取成功的文章之后不再重复抓取
+	if crawled := (&IntakeService{DB: target}).crawledKeys([]string{intakeURL1, intakeURL2}); len(crawled) != 1 {
+		t.Errorf("导入后已抓取的文章为 %v", crawled)
+	}
+	failures, _ := readFailureRecords(filepath.Join(targetSave, constant.FailedDownloadDir))
+	if len(failures) != 1 || !strings.Contains(failures[0].URL, "/s/x") {
+		t.Errorf("导入的失败记录为 %+v", failures)
+	}
+
+	// 再次合并导入不会产生重复数据
+	res, err = importSvc.Import(ctx, types.ImportDataRequest{Path: exportPath, SaveImgPath: targetSave})
+	if err != nil || res.PreferencesSaved || res.SubscriptionCount != 0 || res.ArticleCount != 0 || res.CrawlHistoryCount != 0 || res.CrawlRunCount != 0 || res.FailureCount != 0 {
+		t.Errorf("重复合并导入的结果为 %+v, %v", res, err)
+	}
+	// 替换导入会覆盖本机的数据
+	res, err = importSvc.Import(ctx, types.ImportDataRequest{Path: exportPath, SaveImgPath: targetSave, Mode: types.DataImportReplace})
+	if err != nil || res.SubscriptionCount != 1 || res.CrawlHistoryCount != 1 || res.CrawlRunCount != 1 {
+		t.Errorf("替换导入的结果为 %+v, %v", res, err)
+	}
+	var subCount int
+	if err = target.Get(&subCount, "SELECT COUNT(*) FROM album_subscriptions"); err != nil || subCount != 1 {
+		t.Errorf("替换导入后有 %d 个订阅: %v", subCount, err)
+	}
+	var articleCount int
+	if err = target.Get(&articleCount, "SELECT COUNT(*) FROM crawl_run_articles"); err != nil || articleCount != 2 {
+		t.Errorf("替换导入后有 %d 条文章抓取结果: %v", articleCount, err)
+	}
+
+	// 抓取记录导入失败时，专辑订阅、偏好设置和失败记录都不导入
+	fresh := openTestDB(t)
+	if _, err = fresh.Exec("CREATE TRIGGER fail_crawl_runs BEFORE INSERT ON crawl_runs BEGIN SELECT RAISE(ABORT, 'fail'); END"); err != nil {
+		t.Fatal(err)
+	}
+	freshSave := filepath.Join(t.TempDir(), "fresh")
+	if err = os.MkdirAll(freshSave, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = (&DataTransferService{DB: fresh}).Import(ctx, types.ImportDataRequest{Path: exportPath, SaveImgPath: freshSave}); err == nil {
+		t.Fatal("抓取记录导入失败时应该返回错误")
+	}
+	if err = fresh.Get(&subCount, "SELECT COUNT(*) FROM album_subscriptions"); err != nil || subCount != 0 {
+		t.Errorf("导入失败后有 %d 个订阅: %v", subCount, err)
+	}
+	if pref, _ = (&UserService{DB: fresh}).GetPreferenceInfo(); pref != nil {
+		t.Errorf("导入失败后不应保存偏好设置: %+v", pref)
+	}
+	if pathExists(filepath.Join(freshSave, constant.FailedDownloadDir)) {
+		t.Error("导入失败后不应写入失败记录")
+	}
+}
@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/disintegration/imaging"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/global"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"github.com/pudongping/wx-graph-crawl/backend/utils"
+	"go.uber.org/zap"
+)
+
+// DuplicateImageService 计算图片的感知哈希并保存到数据库中，按汉明距离查找相似图片
+type DuplicateImageService struct {
+	DB             *sqlx.DB
+	ConcurrencyMax int // 并发计算哈希的goroutines数量
+}
+
+func NewDuplicateImageService() *DuplicateImageService {
+	return &DuplicateImageService{
+		DB:             global.DB,
+		ConcurrencyMax: 10,
+	}
+}
+
+// imageHashResult 一张图片的哈希计算结果
+type imageHashResult struct {
+	hash  types.ImageHash
+	fresh bool // 是否为本次新计算的哈希，需要保存到数据库中
+	err   error
+}
+
+// normalizeFindDuplicateImagesRequest 没有设置的参数使用默认值
+func normalizeFindDuplicateImagesRequest(req types.FindDuplicateImagesRequest) types.FindDuplicateImagesRequest {
+	if req.Algorithm == "" {
+		req.Algorithm = constant.DuplicateImageDefaultAlgorithm
+	}
+	if req.Threshold <= 0 {
+		req.Threshold = constant.DuplicateImageDefaultThreshold
+	}
+	if req.Action == "" {
+		req.Action = types.DuplicateImageReport
+	}
+	if req.Action == types.DuplicateImageMove && req.MoveDir == "" {
+		req.MoveDir = filepath.Join(req.ImgSavePath, constant.DuplicateImageDirName)
+	}
+	return req
+}
+
+// validateFindDuplicateImagesRequest 检查查找相似图片的参数
+func validateFindDuplicateImagesRequest(req types.FindDuplicateImagesRequest) error {
+	if info, err := os.Stat(req.ImgSavePath); err != nil || !info.IsDir() {
+		return errors.Errorf("图片目录 %s 不存在", req.ImgSavePath)
+	}
+	switch req.Algorithm {
+	case types.ImageHashAHash, types.ImageHashDHash, types.ImageHashPHash:
+	default:
+		return errors.Errorf("不支持的哈希算法: %s", req.Algorithm)
+	}
+	if req.Threshold > constant.DuplicateImageMaxThreshold {
+		return errors.Errorf("相似阈值不能大于 %d", constant.DuplicateImageMaxThreshold)
+	}
+	switch req.Action {
+	case types.DuplicateImageReport, types.DuplicateImageDelete, types.DuplicateImageMove:
+	default:
+		return errors.Errorf("不支持的处理方式: %s", req.Action)
+	}
+	return nil
+}
+
+// FindDuplicates 查找目录及其子目录中的相似图片，并按处理方式删除或移动每组中保留图片以外的图片
+func (svc *DuplicateImageService) FindDuplicates(ctx context.Context, req types.FindDuplicateImagesRequest) (res types.FindDuplicateImagesResponse, err error) {
+	start := time.Now()
+	req = normalizeFindDuplicateImagesRequest(req)
+	if err = validateFindDuplicateImagesRequest(req); err != nil {
+		return res, err
+	}
+	res.ImgPath = req.ImgSavePath
+	res.Algorithm = req.Algorithm
+	res.Threshold = req.Threshold
+	res.Action = req.Action
+
+	skipDirs := make([]string, 0, 1)
+	if req.MoveDir != "" {
+		skipDirs = append(skipDirs, req.MoveDir)
+	}
+	results, err := svc.HashImages(ctx, req.ImgSavePath, skipDirs...)
+	if err != nil {
+		return res, err
+	}
+
+	hashes := make([]types.ImageHash, 0, len(results))
+	for _, item := range results {
+		if item.err != nil {
+			zap.L().Error("计算图片哈希失败", zap.String("path", item.hash.Path), zap.Error(item.err))
+			res.ErrContent += item.err.Error() + " | \n"
+			continue
+		}
+		if item.fresh {
+			res.HashedCount++
+		}
+		hashes = append(hashes, item.hash)
+	}
+	res.ScannedCount = len(hashes)
+
+	res.Groups = GroupDuplicateImages(hashes, req.Algorithm, req.Threshold)
+	for i := range res.Groups {
+		group := &res.Groups[i]
+		res.DuplicateCount += len(group.Duplicates)
+		if req.Action == types.DuplicateImageReport {
+			continue
+		}
+		for j := range group.Duplicates {
+			if err = svc.handleDuplicate(ctx, req, &group.Duplicates[j]); err != nil {
+				zap.L().Error("处理相似图片失败", zap.String("path", group.Duplicates[j].Path), zap.Error(err))
+				res.ErrContent += err.Error() + " | \n"
+				continue
+			}
+			res.HandledCount++
+		}
+	}
+
+	res.CastTimeStr = time.Since(start).String()
+	return res, nil
+}
+
+// HashImages 遍历目录及其子目录，计算全部图片的感知哈希
+// 文件大小和修改时间没有变化的图片直接使用数据库中保存的哈希，新计算的哈希会保存到数据库中
+func (svc *DuplicateImageService) HashImages(ctx context.Context, rootDir string, skipDirs ...string) ([]imageHashResult, error) {
+	skip := make(map[string]struct{}, len(skipDirs))
+	for _, dir := range skipDirs {
+		skip[filepath.Clean(dir)] = struct{}{}
+	}
+
+	semaphore := make(chan struct{}, max(svc.ConcurrencyMax, 1))
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make([]imageHashResult, 0)
+	)
+	err := filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path == rootDir {
+				return nil
+			}
+			if _, ok := skip[filepath.Clean(path)]; ok || info.Name() == constant.CropBackupDirName || info.Name() == constant.DuplicateImageDirName || info.Name() == constant.BundleDirName {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(path string, info os.FileInfo) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			result, ok := svc.hashFile(ctx, path, info)
+			if !ok {
+				return
+			}
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}(path, info)
+		return nil
+	})
+	wg.Wait()
+	if err != nil {
+		return nil, errors.Wrap(err, "遍历目录时出错")
+	}
+
+	// 数据库写入集中在一个事务中完成，避免并发写入时锁表
+	if err = svc.saveHashes(ctx, results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// hashFile 计算一张图片的哈希，第二个返回值为 false 时表示不是图片
+func (svc *DuplicateImageService) hashFile(ctx context.Context, path string, info os.FileInfo) (res imageHashResult, ok bool) {
+	res.hash = types.ImageHash{Path: path, Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+
+	var cached types.ImageHash
+	err := svc.DB.GetContext(ctx, &cached, "SELECT * FROM image_hashes WHERE path = ?", path)
+	if err == nil && cached.Size == res.hash.Size && cached.ModTime == res.hash.ModTime {
+		res.hash = cached
+		return res, true
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		zap.L().Warn("查询保存的图片哈希失败", zap.String("path", path), zap.Error(err))
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		res.err = errors.Wrap(err, "打开文件失败")
+		return res, true
+	}
+	_, _, err = image.DecodeConfig(file)
+	file.Close()
+	if err != nil {
+		return res, false // 不是图片或不支持的图片格式
+	}
+
+	img, err := imaging.Open(path)
+	if err != nil {
+		res.err = errors.Wrapf(err, "解码图片 %s 失败", path)
+		return res, true
+	}
+	res.hash.Width, res.hash.Height = img.Bounds().Dx(), img.Bounds().Dy()
+	res.hash.AHash = formatImageHash(utils.AHash(img))
+	res.hash.DHash = formatImageHash(utils.DHash(img))
+	res.hash.PHash = formatImageHash(utils.PHash(img))
+	res.fresh = true
+	return res, true
+}
+
+// saveHashes 保存新计算的哈希，已有记录的图片更新原来的记录
+func (svc *DuplicateImageService) saveHashes(ctx context.Context, results []imageHashResult) error {
+	tx, err := svc.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "开启事务失败")
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	for i := range results {
+		if !results[i].fresh || results[i].err != nil {
+			continue
+		}
+		hash := &results[i].hash
+		hash.CreatedAt, hash.UpdatedAt = now, now
+		_, err = tx.NamedExecContext(ctx, `
+			INSERT INTO image_hashes (path, size, mod_time, width, height, ahash, dhash, phash, created_at, updated_at)
+			VALUES (:path, :size, :mod_time, :width, :height, :ahash, :dhash, :phash, :created_at, :updated_at)
+			ON CONFLICT (path) DO UPDATE SET
+				size = excluded.size, mod_time = excluded.mod_time, width = excluded.width, height = excluded.height,
+				ahash = excluded.ahash, dhash = excluded.dhash, phash = excluded.phash, updated_at = excluded.updated_at
+		`, hash)
+		if err != nil {
+			return errors.Wrap(err, "保存图片哈希失败")
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return errors.Wrap(err, "保存图片哈希失败")
+	}
+	return nil
+}
+
+// handleDuplicate 删除或移动一张相似图片，并删除它的哈希记录
+func (svc *DuplicateImageService) handleDuplicate(ctx context.Context, req types.FindDuplicateImagesRequest, item *types.DuplicateImage) error {
+	switch req.Action {
+	case types.DuplicateImageDelete:
+		if err := os.Remove(item.Path); err != nil {
+			return errors.Wrapf(err, "删除图片 %s 失败", item.Path)
+		}
+		zap.L().Info("删除相似图片", zap.String("path", item.Path))
+	case types.DuplicateImageMove:
+		rel, err := filepath.Rel(req.ImgSavePath, item.Path)
+		if err != nil {
+			return errors.Wrapf(err, "计算图片 %s 的相对路径失败", item.Path)
+		}
+		dst := filepath.Join(req.MoveDir, rel)
+		if _, err = os.Stat(dst); err == nil {
+			return errors.Errorf("移动图片 %s 失败，%s 已存在", item.Path, dst)
+		}
+		if err = utils.MkdirIfNotExist(filepath.Dir(dst)); err != nil {
+			return errors.Wrap(err, "创建目录失败")
+		}
+		if err = moveFile(item.Path, dst); err != nil {
+			return errors.Wrapf(err, "移动图片 %s 失败", item.Path)
+		}
+		item.MovedTo = dst
+		zap.L().Info("移动相似图片", zap.String("path", item.Path), zap.String("dst", dst))
+	}
+
+	if _, err := svc.DB.ExecContext(ctx, "DELETE FROM image_hashes WHERE path = ?", item.Path); err != nil {
+		zap.L().Warn("删除图片哈希记录失败", zap.String("path", item.Path), zap.Error(err))
+	}
+	return nil
+}
+
+// moveFile 移动文件，目标目录在其他磁盘上无法直接重命名时，先复制再删除原文件
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := utils.CopyFile(src, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// GroupDuplicateImages 将图片按相似程度分组，每组保留分辨率最大的一张，组内其他图片和保留的图片的汉明距离都不大于阈值
+// 相似关系不传递：A 和 B 相似、B 和 C 相似时，C 不一定和 A 分到一组，避免删除或移动和保留的图片并不相似的图片
+func GroupDuplicateImages(hashes []types.ImageHash, algorithm types.ImageHashAlgorithm, threshold int) []types.DuplicateImageGroup {
+	// 按保留的优先级排序：分辨率大的优先，分辨率相同时文件较大的优先，都相同时按路径排序
+	sort.Slice(hashes, func(i, j int) bool {
+		hi, hj := hashes[i], hashes[j]
+		if pi, pj := hi.Width*hi.Height, hj.Width*hj.Height; pi != pj {
+			return pi > pj
+		}
+		if hi.Size != hj.Size {
+			return hi.Size > hj.Size
+		}
+		return hi.Path < hj.Path
+	})
+	values := make([]uint64, len(hashes))
+	for i, h := range hashes {
+		values[i] = parseImageHash(imageHashOf(h, algorithm))
+	}
+
+	// 依次以还没有分组的优先级最高的图片为中心，和它相似的图片分到同一组
+	grouped := make([]bool, len(hashes))
+	groups := make([]types.DuplicateImageGroup, 0)
+	for keep := range hashes {
+		if grouped[keep] {
+			continue
+		}
+		grouped[keep] = true
+		group := types.DuplicateImageGroup{
+			Keep:       newDuplicateImage(hashes[keep], 0),
+			Duplicates: make([]types.DuplicateImage, 0),
+		}
+		for i := keep + 1; i < len(hashes); i++ {
+			if grouped[i] {
+				continue
+			}
+			if distance := utils.HammingDistance(values[keep], values[i]); distance <= threshold {
+				grouped[i] = true
+				group.Duplicates = append(group.Duplicates, newDuplicateImage(hashes[i], distance))
+			}
+		}
+		if len(group.Duplicates) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Keep.Path < groups[j].Keep.Path })
+	return groups
+}
+
+func newDuplicateImage(h types.ImageHash, distance int) types.DuplicateImage {
+	return types.DuplicateImage{Path: h.Path, Width: h.Width, Height: h.Height, Size: h.Size, Distance: distance}
+}
+
+// imageHashOf 取出指定算法的哈希
+func imageHashOf(h types.ImageHash, algorithm types.ImageHashAlgorithm) string {
+	switch algorithm {
+	case types.ImageHashAHash:
+		return h.AHash
+	case types.ImageHashDHash:
+		return h.DHash
+	default:
+		return h.PHash
+	}
+}
+
+// formatImageHash 哈希保存为16位十六进制字符串，避免 SQLite 中有符号整数溢出
+func formatImageHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+func parseImageHash(s string) uint64 {
+	hash, err := strconv.ParseUint(strings.TrimSpace(s), 16, 64)
+	if err != nil {
+		zap.L().Warn("图片哈希格式不正确", zap.String("hash", s))
+	}
+	return hash
+}
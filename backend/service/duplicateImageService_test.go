@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+)
+
+func TestFindDuplicateImages(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	// 生成带有色块的图片，seed 不同时色块的位置不同
+	pattern := func(width, height, seed int) *image.NRGBA {
+		img := imaging.New(width, height, color.White)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if cx, cy := x*8/width, y*8/height; (cx*3+cy*5+seed)%4 == 0 {
+					img.Set(x, y, color.NRGBA{R: uint8(40 * cx), G: uint8(30 * cy), B: 200, A: 255})
+				}
+			}
+		}
+		return img
+	}
+	files := map[string]*image.NRGBA{
+		"a.png":     pattern(640, 480, 1),
+		"sub/b.jpg": pattern(320, 240, 1),
+		"c.png":     pattern(640, 480, 2),
+	}
+	for name, img := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := imaging.Save(img, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "note.txt"), []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := &DuplicateImageService{DB: openTestDB(t), ConcurrencyMax: 2}
+	res, err := svc.FindDuplicates(ctx, types.FindDuplicateImagesRequest{ImgSavePath: root})
+	if err != nil {
+		t.Fatalf("查找相似图片失败: %v", err)
+	}
+	if res.ScannedCount != 3 || res.HashedCount != 3 || res.DuplicateCount != 1 || len(res.Groups) != 1 {
+		t.Fatalf("查找结果不正确: %+v", res)
+	}
+	group := res.Groups[0]
+	if group.Keep.Path != filepath.Join(root, "a.png") || group.Duplicates[0].Path != filepath.Join(root, "sub", "b.jpg") {
+		t.Errorf("应该保留分辨率较大的图片: %+v", group)
+	}
+
+	// 文件没有变化时使用保存的哈希
+	res, err = svc.FindDuplicates(ctx, types.FindDuplicateImagesRequest{ImgSavePath: root, Algorithm: types.ImageHashDHash})
+	if err != nil || res.HashedCount != 0 || len(res.Groups) != 1 {
+		t.Fatalf("应该使用保存的哈希: %v, %+v", err, res)
+	}
+
+	if _, err = svc.FindDuplicates(ctx, types.FindDuplicateImagesRequest{ImgSavePath: root, Algorithm: "md5"}); err == nil {
+		t.Error("不支持的哈希算法应该返回错误")
+	}
+
+	// 移动到默认目录中，保持原来的目录结构，之后不再被检查
+	res, err = svc.FindDuplicates(ctx, types.FindDuplicateImagesRequest{ImgSavePath: root, Action: types.DuplicateImageMove})
+	if err != nil || res.HandledCount != 1 {
+		t.Fatalf("移动相似图片失败: %v, %+v", err, res)
+	}
+	moved := filepath.Join(root, constant.DuplicateImageDirName, "sub", "b.jpg")
+	if res.Groups[0].Duplicates[0].MovedTo != moved {
+		t.Errorf("移动后的路径不正确: %+v", res.Groups[0].Duplicates[0])
+	}
+	if _, err = os.Stat(moved); err != nil {
+		t.Errorf("图片没有移动到 %s: %v", moved, err)
+	}
+	res, err = svc.FindDuplicates(ctx, types.FindDuplicateImagesRequest{ImgSavePath: root})
+	if err != nil || res.ScannedCount != 2 || len(res.Groups) != 0 {
+		t.Fatalf("移动后不应该再有相似图片: %v, %+v", err, res)
+	}
+}
+
+func TestGroupDuplicateImages(t *testing.T) {
+	// a 和 b 相差2位，b 和 c 相差2位，a 和 c 相差4位
+	hashes := []types.ImageHash{
+		{Path: "c.jpg", Width: 100, Height: 100, PHash: formatImageHash(0b1111)},
+		{Path: "b.jpg", Width: 100, Height: 100, PHash: formatImageHash(0b0011)},
+		{Path: "a.jpg", Width: 200, Height: 200, PHash: formatImageHash(0b0000)},
+	}
+	groups := GroupDuplicateImages(hashes, types.ImageHashPHash, 2)
+	if len(groups) != 1 || groups[0].Keep.Path != "a.jpg" || len(groups[0].Duplicates) != 1 {
+		t.Fatalf("分组不正确: %+v", groups)
+	}
+	if d := groups[0].Duplicates[0]; d.Path != "b.jpg" || d.Distance != 2 {
+		t.Errorf("只有和保留的图片相似的图片才能分到一组: %+v", d)
+	}
+
+	if groups = GroupDuplicateImages(hashes, types.ImageHashPHash, 4); len(groups) != 1 || len(groups[0].Duplicates) != 2 {
+		t.Errorf("阈值足够大时应该分到一组: %+v", groups)
+	}
+}
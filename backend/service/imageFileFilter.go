@@ -0,0 +1,141 @@
+package service
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+)
+
+var (
+	// defaultImageIncludes 没有设置 Include 时处理的文件
+	defaultImageIncludes = []string{"*.jpg", "*.jpeg", "*.png", "*.gif", "*.webp", "*.bmp", "*.tif", "*.tiff"}
+	// defaultImageExcludes 抓取时在图片目录中生成的 js、css、下载失败记录、文案目录、网页文件和发布包目录
+	defaultImageExcludes = []string{"js", "css", constant.FailedDownloadDir, constant.TextContentFileDir, "*.html", constant.BundleDirName}
+)
+
+// ValidateImageFileFilter 检查过滤条件中的匹配规则和文章目录
+func ValidateImageFileFilter(rootDir string, filter types.ImageFileFilter) error {
+	for _, pattern := range append(append([]string{}, filter.Include...), filter.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.Errorf("匹配规则 %s 格式不正确", pattern)
+		}
+	}
+	if filter.MaxDepth < 0 {
+		return errors.New("目录层数不能为负数")
+	}
+	_, err := imageFilterDirs(rootDir, filter.Dirs)
+	return err
+}
+
+// imageFilterDirs 把文章目录转换为绝对路径，必须位于图片目录中，已经包含在其他文章目录中的会被去掉
+func imageFilterDirs(rootDir string, dirs []string) ([]string, error) {
+	if len(dirs) == 0 {
+		return []string{rootDir}, nil
+	}
+	result := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(rootDir, dir)
+		}
+		dir = filepath.Clean(dir)
+		rel, err := filepath.Rel(rootDir, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, errors.Errorf("文章目录 %s 不在图片目录 %s 中", dir, rootDir)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, errors.Errorf("文章目录 %s 不存在", dir)
+		}
+		result = append(result, dir)
+	}
+
+	unique := make([]string, 0, len(result))
+	for _, dir := range result {
+		contained := false
+		for _, other := range result {
+			if other != dir && strings.HasPrefix(dir, other+string(filepath.Separator)) {
+				contained = true
+				break
+			}
+		}
+		if !contained && !slices.Contains(unique, dir) {
+			unique = append(unique, dir)
+		}
+	}
+	return unique, nil
+}
+
+// WalkImageFiles 按过滤条件遍历图片目录中的文件，skipDir 返回 true 的目录会被跳过
+// fn 返回 filepath.SkipAll 时停止遍历
+func WalkImageFiles(rootDir string, filter types.ImageFileFilter, skipDir func(path string, info os.FileInfo) bool, fn func(path string, info os.FileInfo) error) error {
+	dirs, err := imageFilterDirs(rootDir, filter.Dirs)
+	if err != nil {
+		return err
+	}
+	includes := filter.Include
+	if len(includes) == 0 {
+		includes = defaultImageIncludes
+	}
+	excludes := filter.Exclude
+	if !filter.NoDefaultExcludes {
+		excludes = append(append([]string{}, excludes...), defaultImageExcludes...)
+	}
+
+	stopped := false
+	for _, dir := range dirs {
+		err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(rootDir, p)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			depth := strings.Count(rel, "/") + 1
+
+			if info.IsDir() {
+				if p == dir {
+					return nil
+				}
+				if (skipDir != nil && skipDir(p, info)) || matchImageFilter(excludes, rel) ||
+					(filter.MaxDepth > 0 && depth >= filter.MaxDepth) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if (filter.MaxDepth > 0 && depth > filter.MaxDepth) || matchImageFilter(excludes, rel) || !matchImageFilter(includes, rel) {
+				return nil
+			}
+			if err = fn(p, info); errors.Is(err, filepath.SkipAll) {
+				stopped = true
+			}
+			return err
+		})
+		if err != nil || stopped {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchImageFilter 相对路径或文件名是否匹配其中一个规则，不区分大小写
+func matchImageFilter(patterns []string, rel string) bool {
+	rel = strings.ToLower(rel)
+	name := path.Base(rel)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(filepath.ToSlash(pattern))
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
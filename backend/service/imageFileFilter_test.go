@@ -0,0 +1,66 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+)
+
+func TestWalkImageFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{
+		"1/1.jpeg", "1/2.JPEG", "1/skip.png", "2/1.jpeg", "2/deep/1.jpeg",
+		"js/x.png", "failed_downloads/x.jpg", "content/a.txt", "a.html",
+	} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	walk := func(filter types.ImageFileFilter) []string {
+		t.Helper()
+		var files []string
+		err := WalkImageFiles(root, filter, nil, func(path string, info os.FileInfo) error {
+			rel, _ := filepath.Rel(root, path)
+			files = append(files, filepath.ToSlash(rel))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("遍历失败: %v", err)
+		}
+		sort.Strings(files)
+		return files
+	}
+
+	cases := []struct {
+		name   string
+		filter types.ImageFileFilter
+		want   []string
+	}{
+		{"默认跳过抓取生成的目录", types.ImageFileFilter{}, []string{"1/1.jpeg", "1/2.JPEG", "1/skip.png", "2/1.jpeg", "2/deep/1.jpeg"}},
+		{"排除文件", types.ImageFileFilter{Exclude: []string{"skip.*"}}, []string{"1/1.jpeg", "1/2.JPEG", "2/1.jpeg", "2/deep/1.jpeg"}},
+		{"目录层数", types.ImageFileFilter{MaxDepth: 2}, []string{"1/1.jpeg", "1/2.JPEG", "1/skip.png", "2/1.jpeg"}},
+		{"文章目录", types.ImageFileFilter{Dirs: []string{"2", filepath.Join(root, "2", "deep")}}, []string{"2/1.jpeg", "2/deep/1.jpeg"}},
+		{"包含规则", types.ImageFileFilter{Include: []string{"*.png"}, NoDefaultExcludes: true}, []string{"1/skip.png", "js/x.png"}},
+		{"相对路径规则", types.ImageFileFilter{Include: []string{"1/*.jpeg"}}, []string{"1/1.jpeg", "1/2.JPEG"}},
+	}
+	for _, c := range cases {
+		if got := walk(c.filter); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: 结果为 %v，应该为 %v", c.name, got, c.want)
+		}
+	}
+
+	if err := ValidateImageFileFilter(root, types.ImageFileFilter{Dirs: []string{".."}}); err == nil {
+		t.Error("图片目录外的文章目录应该返回错误")
+	}
+	if err := ValidateImageFileFilter(root, types.ImageFileFilter{Include: []string{"[a-"}}); err == nil {
+		t.Error("格式不正确的匹配规则应该返回错误")
+	}
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/disintegration/imaging"
+	"github.com/pkg/errors"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"go.uber.org/zap"
+	_ "golang.org/x/image/webp" // 注册 webp 解码器，用于将 webp 图片转换为 JPEG
+)
+
+// ImagePipelineService 图片处理：缩小、重新压缩、转换格式、自动旋转和去掉元数据，处理后的图片覆盖原图
+// backup 方式与裁剪使用相同的日志和原图备份，可以通过撤销裁剪来撤销
+type ImagePipelineService struct {
+	RootDir        string                     // 要处理的图片目录
+	ConcurrencyMax int                        // 并发处理的goroutines数量
+	Options        types.ImagePipelineOptions // 处理步骤
+	Mode           types.CropMode             // 保存方式，只支持 backup 和 overwrite，为空时和 overwrite 相同
+	JournalDir     string                     // backup 方式的日志和原图备份目录
+	Filter         types.ImageFileFilter      // 要处理的文件范围，只在处理整个目录时使用
+
+	journal *cropJournal // backup 方式的日志
+}
+
+func NewImagePipelineService(rootDir string, concurrencyMax int, options types.ImagePipelineOptions) *ImagePipelineService {
+	return &ImagePipelineService{
+		RootDir:        rootDir,
+		ConcurrencyMax: concurrencyMax,
+		Options:        options,
+	}
+}
+
+// ImagePipelineEnabled 是否设置了至少一个处理步骤
+func ImagePipelineEnabled(o *types.ImagePipelineOptions) bool {
+	return o != nil && (o.MaxDimension > 0 || o.JPEGQuality > 0 || o.PNGCompression != "" ||
+		len(o.ConvertToJPEG) > 0 || o.AutoOrient || o.StripMetadata)
+}
+
+// ValidateImagePipelineOptions 检查处理步骤的参数
+func ValidateImagePipelineOptions(o types.ImagePipelineOptions) error {
+	if o.MaxDimension < 0 {
+		return errors.New("最大边长不能为负数")
+	}
+	if o.JPEGQuality < 0 || o.JPEGQuality > 100 {
+		return errors.New("JPEG 质量必须在 1 到 100 之间")
+	}
+	if _, ok := pngCompressionLevels[o.PNGCompression]; o.PNGCompression != "" && !ok {
+		return errors.Errorf("不支持的 PNG 压缩级别: %s", o.PNGCompression)
+	}
+	for _, format := range o.ConvertToJPEG {
+		switch format {
+		case types.ImageFormatPNG, types.ImageFormatWebP, types.ImageFormatGIF:
+		default:
+			return errors.Errorf("不支持将 %s 转换为 JPEG", format)
+		}
+	}
+	return nil
+}
+
+var pngCompressionLevels = map[types.PNGCompression]png.CompressionLevel{
+	types.PNGCompressionDefault: png.DefaultCompression,
+	types.PNGCompressionNone:    png.NoCompression,
+	types.PNGCompressionSpeed:   png.BestSpeed,
+	types.PNGCompressionBest:    png.BestCompression,
+}
+
+// RunPipeline 按过滤条件处理目录及其子目录中的图片，跳过原图备份、相似图片和发布包目录
+func (svc *ImagePipelineService) RunPipeline() ([]types.ImagePipelineResult, error) {
+	if err := ValidateImagePipelineOptions(svc.Options); err != nil {
+		return nil, err
+	}
+	switch svc.Mode {
+	case "", types.CropModeOverwrite:
+	case types.CropModeBackup:
+		if svc.JournalDir == "" {
+			return nil, errors.New("没有设置原图备份目录")
+		}
+		journal, err := openCropJournal(svc.JournalDir)
+		if err != nil {
+			return nil, err
+		}
+		defer journal.Close()
+		svc.journal = journal
+	default:
+		return nil, errors.Errorf("图片处理不支持的保存方式: %s", svc.Mode)
+	}
+
+	paths := make([]string, 0)
+	err := WalkImageFiles(svc.RootDir, svc.Filter, func(path string, info os.FileInfo) bool {
+		return info.Name() == constant.CropBackupDirName || info.Name() == constant.DuplicateImageDirName
+	}, func(path string, info os.FileInfo) error {
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "遍历目录时出错")
+	}
+
+	return svc.ProcessFiles(paths), nil
+}
+
+// ProcessFiles 并发处理指定的文件，不是图片的文件会被忽略，不出现在结果中
+func (svc *ImagePipelineService) ProcessFiles(paths []string) []types.ImagePipelineResult {
+	semaphore := make(chan struct{}, max(svc.ConcurrencyMax, 1))
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make([]types.ImagePipelineResult, 0, len(paths))
+	)
+	for _, path := range paths {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(path string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			result, ok := svc.processFile(path)
+			if !ok {
+				return
+			}
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}(path)
+	}
+	wg.Wait()
+	return results
+}
+
+// processFile 处理一张图片，第二个返回值为 false 时表示不是图片
+func (svc *ImagePipelineService) processFile(path string) (res types.ImagePipelineResult, ok bool) {
+	res.ImgPath, res.OutputPath = path, path
+
+	file, err := os.Open(path)
+	if err != nil {
+		res.Err = errors.Wrap(err, "打开文件失败")
+		return res, true
+	}
+	config, format, err := image.DecodeConfig(file)
+	file.Close()
+	if err != nil {
+		return res, false // 不是图片或不支持的图片格式
+	}
+	res.Format = types.ImageFormat(format)
+	res.OutputFmt = res.Format
+	if info, err := os.Stat(path); err == nil {
+		res.BytesBefore, res.BytesAfter = info.Size(), info.Size()
+	}
+
+	// 确定需要执行的步骤，没有需要执行的步骤时不重新保存图片
+	o := svc.Options
+	convert := slices.Contains(o.ConvertToJPEG, res.Format)
+	resize := o.MaxDimension > 0 && max(config.Width, config.Height) > o.MaxDimension
+	orient := o.AutoOrient && res.Format == types.ImageFormatJPEG
+	recompress := (res.Format == types.ImageFormatJPEG && o.JPEGQuality > 0) ||
+		(res.Format == types.ImageFormatPNG && o.PNGCompression != "")
+	switch {
+	case convert:
+		res.OutputFmt = types.ImageFormatJPEG
+	case !resize && !orient && !recompress && !o.StripMetadata:
+		res.Skipped = true
+		return res, true
+	case res.Format == types.ImageFormatGIF:
+		// 只有转换格式时才处理 GIF，避免丢失动图
+		res.Skipped = true
+		return res, true
+	case res.Format == types.ImageFormatWebP:
+		res.OutputFmt = types.ImageFormatJPEG // 无法保存为 webp
+	case res.Format != types.ImageFormatJPEG && res.Format != types.ImageFormatPNG:
+		res.Skipped = true
+		return res, true
+	}
+
+	img, err := imaging.Open(path, imaging.AutoOrientation(orient))
+	if err != nil {
+		res.Err = errors.Wrap(err, "解码图片失败")
+		return res, true
+	}
+	if resize {
+		img = imaging.Fit(img, o.MaxDimension, o.MaxDimension, imaging.Lanczos)
+	}
+	if res.OutputFmt == types.ImageFormatJPEG && res.Format != types.ImageFormatJPEG {
+		// JPEG 不支持透明，透明的部分填充为白色
+		bg := imaging.New(img.Bounds().Dx(), img.Bounds().Dy(), color.White)
+		img = imaging.Overlay(bg, img, image.Pt(0, 0), 1.0)
+	}
+
+	if res.OutputFmt != res.Format {
+		res.OutputPath = strings.TrimSuffix(path, filepath.Ext(path)) + ".jpg"
+		if _, err = os.Stat(res.OutputPath); err == nil {
+			res.Err = errors.Errorf("转换格式失败，%s 已存在", filepath.Base(res.OutputPath))
+			return res, true
+		}
+	}
+	if err = svc.backup(path, res.OutputPath); err != nil {
+		res.Err = errors.Wrapf(err, "保存图片 %s 失败", filepath.Base(path))
+		return res, true
+	}
+	if err = svc.save(img, res.OutputPath, res.OutputFmt); err != nil {
+		res.Err = err
+		return res, true
+	}
+	if res.OutputPath != path {
+		if err = os.Remove(path); err != nil {
+			zap.L().Warn("删除转换前的图片失败", zap.String("path", path), zap.Error(err))
+		}
+	}
+	if info, err := os.Stat(res.OutputPath); err == nil {
+		res.BytesAfter = info.Size()
+	}
+
+	zap.L().Info("图片处理成功", zap.String("path", res.OutputPath), zap.Int64("before", res.BytesBefore), zap.Int64("after", res.BytesAfter))
+	return res, true
+}
+
+// backup 在保存处理后的图片之前备份原图并写入日志，overwrite 方式不备份
+func (svc *ImagePipelineService) backup(path, outputPath string) error {
+	if svc.journal == nil {
+		return nil
+	}
+	return svc.journal.Backup(svc.RootDir, path, outputPath)
+}
+
+// save 先写入临时文件再替换，避免保存失败时损坏原图
+func (svc *ImagePipelineService) save(img image.Image, path string, format types.ImageFormat) error {
+	var (
+		imgFormat = imaging.JPEG
+		opts      []imaging.EncodeOption
+	)
+	if format == types.ImageFormatPNG {
+		imgFormat = imaging.PNG
+		if level, ok := pngCompressionLevels[svc.Options.PNGCompression]; ok {
+			opts = append(opts, imaging.PNGCompressionLevel(level))
+		}
+	} else {
+		quality := svc.Options.JPEGQuality
+		if quality <= 0 {
+			quality = constant.ImagePipelineDefaultJPEGQuality
+		}
+		opts = append(opts, imaging.JPEGQuality(quality))
+	}
+
+	return saveImageFile(img, path, imgFormat, opts...)
+}
+
+// saveImageFile 先写入临时文件再替换目标文件，保存失败时不会损坏原图
+func saveImageFile(img image.Image, path string, format imaging.Format, opts ...imaging.EncodeOption) error {
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return errors.Wrap(err, "创建临时文件失败")
+	}
+	if err = imaging.Encode(file, img, format, opts...); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return errors.Wrap(err, "保存图片失败")
+	}
+	if err = file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return errors.Wrap(err, "保存图片失败")
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return errors.Wrap(err, "替换原图失败")
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+)
+
+func TestImagePipeline(t *testing.T) {
+	root := t.TempDir()
+	save := func(name string, width, height int) string {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := imaging.Save(image.NewNRGBA(image.Rect(0, 0, width, height)), path); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	save("a.png", 2000, 1000)
+	save("sub/b.jpg", 800, 600)
+	save("c.gif", 100, 100)
+	if err := os.WriteFile(filepath.Join(root, "content.txt"), []byte("不是图片"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewImagePipelineService(root, 2, types.ImagePipelineOptions{
+		MaxDimension:  1000,
+		ConvertToJPEG: []types.ImageFormat{types.ImageFormatPNG},
+	})
+	results, err := svc.RunPipeline()
+	if err != nil {
+		t.Fatalf("处理图片失败: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("应该有 3 张图片的处理结果: %+v", results)
+	}
+	byName := make(map[string]types.ImagePipelineResult)
+	for _, res := range results {
+		if res.Err != nil {
+			t.Fatalf("处理 %s 失败: %v", res.ImgPath, res.Err)
+		}
+		byName[filepath.Base(res.ImgPath)] = res
+	}
+
+	// png 缩小并转换为 jpg，原文件被删除
+	if res := byName["a.png"]; res.Skipped || res.OutputFmt != types.ImageFormatJPEG || filepath.Base(res.OutputPath) != "a.jpg" {
+		t.Errorf("a.png 的处理结果不正确: %+v", res)
+	}
+	if _, err = os.Stat(filepath.Join(root, "a.png")); !os.IsNotExist(err) {
+		t.Errorf("转换后应该删除原来的 png: %v", err)
+	}
+	img, err := imaging.Open(filepath.Join(root, "a.jpg"))
+	if err != nil || img.Bounds().Dx() != 1000 || img.Bounds().Dy() != 500 {
+		t.Errorf("a.jpg 应该缩小为 1000x500: %v", err)
+	}
+	// 不需要处理的图片保持不变
+	if !byName["b.jpg"].Skipped || !byName["c.gif"].Skipped {
+		t.Errorf("b.jpg 和 c.gif 不需要处理: %+v %+v", byName["b.jpg"], byName["c.gif"])
+	}
+
+	// 只处理指定的文件：重新压缩 jpg
+	svc.Options = types.ImagePipelineOptions{JPEGQuality: 60, StripMetadata: true}
+	results = svc.ProcessFiles([]string{filepath.Join(root, "sub", "b.jpg"), filepath.Join(root, "content.txt")})
+	if len(results) != 1 || results[0].Skipped || results[0].Err != nil || results[0].OutputPath != filepath.Join(root, "sub", "b.jpg") {
+		t.Errorf("b.jpg 应该重新压缩: %+v", results)
+	}
+
+	if err = ValidateImagePipelineOptions(types.ImagePipelineOptions{JPEGQuality: 101}); err == nil {
+		t.Error("JPEG 质量超过 100 时应该返回错误")
+	}
+	if err = ValidateImagePipelineOptions(types.ImagePipelineOptions{ConvertToJPEG: []types.ImageFormat{types.ImageFormatJPEG}}); err == nil {
+		t.Error("不能将 JPEG 转换为 JPEG")
+	}
+}
+
+func TestImagePipelineBackup(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	for _, name := range []string{"1/a.png", "2/b.png", "_duplicates/c.png", "js/d.png"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := imaging.Save(image.NewNRGBA(image.Rect(0, 0, 200, 100)), path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	jobSvc := &CropJobService{DB: openTestDB(t)}
+	job, err := jobSvc.CreateJob(ctx, root, types.CropModeBackup, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := NewImagePipelineService(root, 2, types.ImagePipelineOptions{
+		MaxDimension:  100,
+		ConvertToJPEG: []types.ImageFormat{types.ImageFormatPNG},
+	})
+	svc.Mode, svc.JournalDir = types.CropModeBackup, job.JournalDir
+	svc.Filter = types.ImageFileFilter{Exclude: []string{"2"}}
+	results, err := svc.RunPipeline()
+	if err != nil {
+		t.Fatalf("处理图片失败: %v", err)
+	}
+	// 只处理文章目录中没有被过滤掉的图片，跳过相似图片目录和抓取时生成的目录
+	if len(results) != 1 || results[0].Err != nil || results[0].OutputPath != filepath.Join(root, "1", "a.jpg") {
+		t.Fatalf("只应该处理 1/a.png: %+v", results)
+	}
+
+	// 撤销后恢复原图，删除转换出的 JPEG
+	undo, err := jobSvc.UndoCrop(ctx, job.JobID)
+	if err != nil || undo.RestoredCount != 1 || undo.ErrContent != "" {
+		t.Fatalf("撤销图片处理失败: %+v, %v", undo, err)
+	}
+	if img, err := imaging.Open(filepath.Join(root, "1", "a.png")); err != nil || img.Bounds().Dx() != 200 {
+		t.Errorf("撤销后应该恢复原图: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(root, "1", "a.jpg")); !os.IsNotExist(err) {
+		t.Errorf("撤销后应该删除转换出的 JPEG: %v", err)
+	}
+}
@@ -7,7 +7,9 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/global"
 	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"github.com/pudongping/wx-graph-crawl/backend/utils"
 	"go.uber.org/zap"
 )
 
@@ -20,6 +22,14 @@ func NewImageService() *ImageService {
 
 func (svc *ImageService) Crawling(ctx context.Context, req types.CrawlingRequest) (res types.CrawlingResponse, err error) {
 	start := time.Now()
+	var (
+		crawlUrls     []string
+		spiderResults []types.CrawlResult
+	)
+	// 保存抓取记录，抓取出错时也保存，便于之后查看和重新抓取
+	defer func() {
+		recordCrawlRun(ctx, req, crawlUrls, res, spiderResults, start, err)
+	}()
 	httpClientTimeout := time.Duration(req.TimeoutSeconds) * time.Second
 	textContentFilePath := filepath.Join(req.ImgSavePath, constant.TextContentFileName) // 文字内容保存的路径
 	res.TextContentSavePath = textContentFilePath
@@ -29,8 +39,18 @@ func (svc *ImageService) Crawling(ctx context.Context, req types.CrawlingRequest
 
 	res.WordDocsSavePath = textContentFileDir // Word文档保存在文本保存路径下
 
-	crawlerImgSvc := NewCrawlerImgService(req.ImgUrls, httpClientTimeout, req.ImgSavePath, textContentFilePath, textContentFileDir)
-	var spiderResults []types.CrawlResult
+	// 合并专辑文章，并记录每篇文章的专辑序号（按文章标识记录，同一篇文章的不同地址可以对应上）
+	crawlUrls = append([]string{}, req.ImgUrls...)
+	albumIndexes := make(map[string]int, len(req.AlbumArticles))
+	for _, article := range req.AlbumArticles {
+		crawlUrls = append(crawlUrls, article.URL)
+		albumIndexes[utils.WXArticleKey(article.URL)] = article.Index
+	}
+	// 同一篇文章只抓取一次，短链接在抓取时从文章页面中解析出文章标识后再去重
+	crawlUrls = dedupeWXArticleURLs(crawlUrls)
+
+	crawlerImgSvc := NewCrawlerImgService(crawlUrls, httpClientTimeout, req.ImgSavePath, textContentFilePath, textContentFileDir)
+	crawlerImgSvc.AlbumIndexes = albumIndexes
 	spiderResults, err = crawlerImgSvc.RunSpiderImg()
 	if err != nil {
 		zap.L().Error("爬取图片失败", zap.Error(err))
@@ -39,6 +59,8 @@ func (svc *ImageService) Crawling(ctx context.Context, req types.CrawlingRequest
 
 	// 统计 抓取的链接地址数量 抓取成功并保存成功的图片数量
 	wordDocsCount := int64(0)
+	crawledURLs := make([]string, 0, len(spiderResults))
+	crawledKeys := make([]string, 0, len(spiderResults)*2)
 	for _, item := range spiderResults {
 		res.CrawlUrlCount++
 		res.CrawlImgCount += int64(len(item.ImgSavePathSuccess))
@@ -48,9 +70,47 @@ func (svc *ImageService) Crawling(ctx context.Context, req types.CrawlingRequest
 		if item.Err != nil {
 			zap.L().Error("抓取失败", zap.Int("Num", item.Number), zap.String("Url", item.URL), zap.Error(item.Err))
 			res.ErrContent += item.Err.Error() + " | \n"
+			continue
+		}
+		crawledURLs = append(crawledURLs, item.URL)
+		// 短链接同时记录解析出的文章标识和短链接本身的标识
+		crawledKeys = append(crawledKeys, crawlResultKey(item), utils.WXArticleKey(item.URL))
+	}
+	// 和其他地址指向同一篇文章而跳过的短链接，保留的地址抓取成功时也算抓取成功
+	succeeded := make(map[string]struct{}, len(crawledURLs))
+	for _, u := range crawledURLs {
+		succeeded[u] = struct{}{}
+	}
+	for dupURL, keptURL := range crawlerImgSvc.DuplicateURLs {
+		if _, ok := succeeded[keptURL]; ok {
+			crawledURLs = append(crawledURLs, dupURL)
+			crawledKeys = append(crawledKeys, utils.WXArticleKey(dupURL))
 		}
 	}
 	res.WordDocsCount = wordDocsCount
+	res.SucceededURLs = crawledURLs
+	RecordCrawledArticles(crawledKeys)
+
+	// 抓取后自动处理图片
+	var renames map[string]string
+	if postProcess := svc.postProcessOptions(req); ImagePipelineEnabled(postProcess) {
+		imgPaths := make([]string, 0, res.CrawlImgCount)
+		for _, item := range spiderResults {
+			imgPaths = append(imgPaths, item.ImgLocalPaths...)
+		}
+		pipelineResults := NewImagePipelineService(req.ImgSavePath, 10, *postProcess).ProcessFiles(imgPaths)
+		renames = pipelineRenames(pipelineResults)
+		pipelineRes := svc.summarizePipeline(pipelineResults)
+		res.ProcessedImgCount = pipelineRes.ProcessedCount
+		res.ErrContent += pipelineRes.ErrContent
+	}
+
+	// 记录文章的标题、文案和图片顺序，制作发布包时使用
+	if err = UpdateCrawlManifest(req.ImgSavePath, spiderResults, renames); err != nil {
+		zap.L().Error("更新抓取记录失败", zap.Error(err))
+		res.ErrContent += err.Error() + " | \n"
+		err = nil
+	}
 
 	castTime := time.Since(start)
 	res.CastTimeStr = castTime.String()
@@ -61,7 +121,30 @@ func (svc *ImageService) Crawling(ctx context.Context, req types.CrawlingRequest
 func (svc *ImageService) Cropping(ctx context.Context, req types.CroppingRequest) (res types.CroppingResponse, err error) {
 	start := time.Now()
 	concurrencyMax := 10 // 并发数
-	cropSvc := NewCropImgService(req.ImgSavePath, concurrencyMax, req.BottomPixel)
+	cropSvc := NewCropImgServiceWithGeometry(req.ImgSavePath, concurrencyMax, CropGeometryFromRequest(req))
+	// 默认备份原图，裁剪错了可以撤销
+	cropSvc.Mode = req.Mode
+	if cropSvc.Mode == "" {
+		cropSvc.Mode = types.CropModeBackup
+	}
+	cropSvc.OutputDir = req.OutputDir
+	cropSvc.Filter = req.Filter
+	if err = cropSvc.Validate(); err != nil {
+		return res, errors.Wrap(err, "裁剪图片失败")
+	}
+
+	jobSvc := NewCropJobService()
+	var job types.CropJob
+	if cropSvc.Mode != types.CropModeOverwrite {
+		job, err = jobSvc.CreateJob(ctx, req.ImgSavePath, cropSvc.Mode, req.OutputDir)
+		if err != nil {
+			zap.L().Error("创建裁剪任务失败", zap.Error(err))
+			return res, errors.Wrap(err, "裁剪图片失败")
+		}
+		cropSvc.JournalDir = job.JournalDir
+		res.JobID, res.BackupDir = job.JobID, job.JournalDir
+	}
+
 	cropResults, err := cropSvc.RunCropImg()
 	if err != nil {
 		zap.L().Error("裁剪失败", zap.Error(err))
@@ -69,6 +152,9 @@ func (svc *ImageService) Cropping(ctx context.Context, req types.CroppingRequest
 	}
 
 	res.CropImgPath = req.ImgSavePath
+	if cropSvc.Mode == types.CropModeOutputDir {
+		res.CropImgPath = req.OutputDir
+	}
 	// 统计裁剪成功的图片数量
 	for _, item := range cropResults {
 		if item.Err != nil {
@@ -77,6 +163,14 @@ func (svc *ImageService) Cropping(ctx context.Context, req types.CroppingRequest
 		} else {
 			res.CropImgCount++
 		}
+		if item.AutoDetected {
+			res.AutoDetected++
+		}
+	}
+	if job.JobID != "" {
+		if err = jobSvc.FinishJob(ctx, job.JobID, res.CropImgCount); err != nil {
+			zap.L().Error("更新裁剪任务失败", zap.Error(err))
+		}
 	}
 	castTime := time.Since(start)
 	res.CastTimeStr = castTime.String()
@@ -84,17 +178,228 @@ func (svc *ImageService) Cropping(ctx context.Context, req types.CroppingRequest
 	return res, nil
 }
 
+// ProcessImages 按处理步骤处理目录中的图片
+func (svc *ImageService) ProcessImages(ctx context.Context, req types.ImagePipelineRequest) (res types.ImagePipelineResponse, err error) {
+	start := time.Now()
+	concurrencyMax := 10 // 并发数
+	pipelineSvc := NewImagePipelineService(req.ImgSavePath, concurrencyMax, req.Options)
+	// 默认备份原图，和裁剪一样可以撤销
+	pipelineSvc.Mode = req.Mode
+	if pipelineSvc.Mode == "" {
+		pipelineSvc.Mode = types.CropModeBackup
+	}
+	if pipelineSvc.Mode != types.CropModeBackup && pipelineSvc.Mode != types.CropModeOverwrite {
+		return res, errors.Errorf("图片处理不支持的保存方式: %s", pipelineSvc.Mode)
+	}
+	if err = ValidateImagePipelineOptions(req.Options); err != nil {
+		return res, errors.Wrap(err, "处理图片失败")
+	}
+	pipelineSvc.Filter = req.Filter
+
+	jobSvc := NewCropJobService()
+	var job types.CropJob
+	if pipelineSvc.Mode == types.CropModeBackup {
+		job, err = jobSvc.CreateJob(ctx, req.ImgSavePath, pipelineSvc.Mode, "")
+		if err != nil {
+			zap.L().Error("创建图片处理任务失败", zap.Error(err))
+			return res, errors.Wrap(err, "处理图片失败")
+		}
+		pipelineSvc.JournalDir = job.JournalDir
+	}
+
+	results, err := pipelineSvc.RunPipeline()
+	if err != nil {
+		zap.L().Error("处理图片失败", zap.Error(err))
+		return res, errors.Wrap(err, "处理图片失败")
+	}
+	if err = RemapCrawlManifest(req.ImgSavePath, pipelineRenames(results)); err != nil {
+		zap.L().Warn("更新抓取记录中的图片路径失败", zap.Error(err))
+	}
+	res = svc.summarizePipeline(results)
+	res.ImgPath = req.ImgSavePath
+	res.JobID, res.BackupDir = job.JobID, job.JournalDir
+	if job.JobID != "" {
+		if err = jobSvc.FinishJob(ctx, job.JobID, res.ProcessedCount); err != nil {
+			zap.L().Error("更新图片处理任务失败", zap.Error(err))
+		}
+	}
+	res.CastTimeStr = time.Since(start).String()
+	return res, nil
+}
+
+// Watermarking 给目录中的图片添加文字或图片水印
+func (svc *ImageService) Watermarking(ctx context.Context, req types.WatermarkingRequest) (res types.WatermarkingResponse, err error) {
+	start := time.Now()
+	concurrencyMax := 10 // 并发数
+	watermarkSvc := NewWatermarkService(req.ImgSavePath, concurrencyMax, req.Options)
+	// 默认备份原图，和裁剪一样可以撤销
+	watermarkSvc.Mode = req.Mode
+	if watermarkSvc.Mode == "" {
+		watermarkSvc.Mode = types.CropModeBackup
+	}
+	if watermarkSvc.Mode != types.CropModeBackup && watermarkSvc.Mode != types.CropModeOverwrite {
+		return res, errors.Errorf("添加水印不支持的保存方式: %s", watermarkSvc.Mode)
+	}
+	if err = ValidateWatermarkOptions(watermarkSvc.Options); err != nil {
+		return res, errors.Wrap(err, "添加水印失败")
+	}
+
+	jobSvc := NewCropJobService()
+	var job types.CropJob
+	if watermarkSvc.Mode == types.CropModeBackup {
+		job, err = jobSvc.CreateJob(ctx, req.ImgSavePath, watermarkSvc.Mode, "")
+		if err != nil {
+			zap.L().Error("创建水印任务失败", zap.Error(err))
+			return res, errors.Wrap(err, "添加水印失败")
+		}
+		watermarkSvc.JournalDir = job.JournalDir
+		res.JobID, res.BackupDir = job.JobID, job.JournalDir
+	}
+
+	results, err := watermarkSvc.RunWatermark()
+	if err != nil {
+		zap.L().Error("添加水印失败", zap.Error(err))
+		return res, errors.Wrap(err, "添加水印失败")
+	}
+
+	res.ImgPath = req.ImgSavePath
+	renames := make(map[string]string)
+	for _, item := range results {
+		switch {
+		case item.Err != nil:
+			zap.L().Error("添加水印失败", zap.String("ImgPath", item.ImgPath), zap.Error(item.Err))
+			res.ErrContent += item.Err.Error() + " | \n"
+		case item.Skipped:
+			res.SkippedImgCount++
+		default:
+			res.WatermarkImgCount++
+			if item.OutputPath != item.ImgPath {
+				renames[item.ImgPath] = item.OutputPath
+			}
+		}
+	}
+	if err = RemapCrawlManifest(req.ImgSavePath, renames); err != nil {
+		zap.L().Warn("更新抓取记录中的图片路径失败", zap.Error(err))
+	}
+	if job.JobID != "" {
+		if err = jobSvc.FinishJob(ctx, job.JobID, res.WatermarkImgCount); err != nil {
+			zap.L().Error("更新水印任务失败", zap.Error(err))
+		}
+	}
+	res.CastTimeStr = time.Since(start).String()
+	return res, nil
+}
+
+// summarizePipeline 统计图片处理结果
+func (svc *ImageService) summarizePipeline(results []types.ImagePipelineResult) (res types.ImagePipelineResponse) {
+	for _, item := range results {
+		res.BytesBefore += item.BytesBefore
+		res.BytesAfter += item.BytesAfter
+		switch {
+		case item.Err != nil:
+			zap.L().Error("处理图片失败", zap.String("ImgPath", item.ImgPath), zap.Error(item.Err))
+			res.ErrContent += item.Err.Error() + " | \n"
+		case item.Skipped:
+			res.SkippedCount++
+		default:
+			res.ProcessedCount++
+		}
+	}
+	return res
+}
+
+// pipelineRenames 处理图片时改了名字的图片，key 为原图路径
+func pipelineRenames(results []types.ImagePipelineResult) map[string]string {
+	renames := make(map[string]string)
+	for _, item := range results {
+		if item.Err == nil && item.OutputPath != "" && item.OutputPath != item.ImgPath {
+			renames[item.ImgPath] = item.OutputPath
+		}
+	}
+	return renames
+}
+
+// postProcessOptions 抓取后的图片处理步骤，请求中没有设置时使用偏好设置
+func (svc *ImageService) postProcessOptions(req types.CrawlingRequest) *types.ImagePipelineOptions {
+	if req.PostProcess != nil || global.DB == nil {
+		return req.PostProcess
+	}
+	pref, err := NewUserService().GetPreferenceInfo()
+	if err != nil || pref == nil {
+		return nil
+	}
+	return pref.PostProcess
+}
+
+// PreviewCrop 预览裁剪效果，不修改任何文件
+func (svc *ImageService) PreviewCrop(ctx context.Context, path string, req types.PreviewCropRequest) (res types.PreviewCropResponse, err error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = constant.CropPreviewDefaultLimit
+	}
+	limit = min(limit, constant.CropPreviewMaxLimit)
+	thumbnailSize := req.ThumbnailSize
+	if thumbnailSize <= 0 {
+		thumbnailSize = constant.CropPreviewThumbnailSize
+	}
+
+	cropSvc := NewCropImgServiceWithGeometry(path, 1, CropGeometryFromRequest(req.CroppingRequest))
+	cropSvc.OutputDir = req.OutputDir
+	cropSvc.Filter = req.Filter
+	if err = ValidateCropGeometry(cropSvc.Geometry); err != nil {
+		return res, errors.Wrap(err, "预览裁剪失败")
+	}
+	res.Items, err = cropSvc.Preview(path, limit, thumbnailSize)
+	if err != nil {
+		return res, errors.Wrap(err, "预览裁剪失败")
+	}
+	return res, nil
+}
+
 func (svc *ImageService) Shuffling(ctx context.Context, req types.ShufflingRequest) (res types.ShufflingResponse, err error) {
 	start := time.Now()
 	moveImgSvc := NewMoveImgService(req.ImgSavePath, req.MaxNumImage)
-	err = moveImgSvc.RunMoveImg()
+	moveImgSvc.DryRun = req.DryRun
+	moveImgSvc.Seed = req.Seed
+	moveImgSvc.Filter = req.Filter
+	if req.Order != "" {
+		moveImgSvc.Order = req.Order
+	}
+	if req.SplitStrategy != "" {
+		moveImgSvc.SplitStrategy = req.SplitStrategy
+	}
+	if req.SplitDirTemplate != "" {
+		moveImgSvc.SplitDirTemplate = req.SplitDirTemplate
+	}
+	journal, err := moveImgSvc.Shuffle()
 	if err != nil {
 		zap.L().Error("移动图片失败", zap.Error(err))
 		return res, errors.Wrap(err, "移动图片失败")
 	}
 	castTime := time.Since(start)
 	res.ShuffleImgPath = req.ImgSavePath
+	res.DryRun = req.DryRun
+	res.JobID = journal.JobID
+	res.Order, res.Seed = journal.Order, journal.Seed
+	res.Moves = journal.Moves
+	if !req.DryRun && len(journal.Moves) > 0 {
+		res.JournalPath = ShuffleJournalPath(req.ImgSavePath, journal.JobID)
+		res.MovedCount = len(journal.Moves)
+	}
 	res.CastTimeStr = castTime.String()
 
 	return res, nil
 }
+
+// UndoShuffle 按打乱日志撤销打乱
+func (svc *ImageService) UndoShuffle(ctx context.Context, journalPath string) (res types.UndoShuffleResponse, err error) {
+	start := time.Now()
+	res.JournalPath = journalPath
+	res.RestoredCount, err = UndoShuffle(journalPath)
+	if err != nil {
+		zap.L().Error("撤销打乱失败", zap.Error(err))
+		return res, errors.Wrap(err, "撤销打乱失败")
+	}
+	res.CastTimeStr = time.Since(start).String()
+	return res, nil
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/global"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"github.com/pudongping/wx-graph-crawl/backend/utils"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"go.uber.org/zap"
+)
+
+// crawledArticles 本次运行中已经抓取过的文章标识
+var crawledArticles = struct {
+	sync.RWMutex
+	keys map[string]struct{}
+}{keys: make(map[string]struct{})}
+
+// RecordCrawledArticles 记录已经抓取过的文章标识，之后拖拽或复制相同的文章时会提示已抓取
+func RecordCrawledArticles(keys []string) {
+	crawledArticles.Lock()
+	defer crawledArticles.Unlock()
+	for _, key := range keys {
+		crawledArticles.keys[key] = struct{}{}
+	}
+}
+
+// IntakeService 拖拽文件和剪贴板中的URL导入，复用文件导入的解析和校验流程
+type IntakeService struct {
+	DB *sqlx.DB
+}
+
+func NewIntakeService() *IntakeService {
+	return &IntakeService{
+		DB: global.DB,
+	}
+}
+
+// RegisterFileDrop 监听拖拽到窗口中的文件，导入其中的URL后推送 constant.IntakeEventFileDrop 事件给前端
+func (svc *IntakeService) RegisterFileDrop(ctx context.Context) {
+	runtime.OnFileDrop(ctx, func(x, y int, paths []string) {
+		zap.L().Info("收到拖拽的文件", zap.Strings("paths", paths))
+		runtime.EventsEmit(ctx, constant.IntakeEventFileDrop, svc.ImportDroppedFiles(paths))
+	})
+}
+
+// ImportDroppedFiles 依次导入拖拽的文件，合并所有文件中的URL并去重
+func (svc *IntakeService) ImportDroppedFiles(paths []string) (event types.IntakeEvent) {
+	event.Source = types.IntakeSourceFileDrop
+	event.FilePaths = paths
+
+	importSvc := NewURLImportService()
+	var (
+		candidates []types.ImportedURL
+		errs       []string
+	)
+	for _, path := range paths {
+		res, err := importSvc.ImportFile(path)
+		if err != nil {
+			zap.L().Error("导入拖拽的文件失败", zap.String("path", path), zap.Error(err))
+			errs = append(errs, err.Error())
+			continue
+		}
+		// 各个文件中被拒绝的URL直接保留，可以抓取的和重复的统一重新去重
+		event.Rejected = append(event.Rejected, res.Rejected...)
+		candidates = append(candidates, res.Accepted...)
+		candidates = append(candidates, res.Duplicates...)
+	}
+	event.ErrContent = strings.Join(errs, " | \n")
+
+	merged := classifyImportedURLs(candidates)
+	event.Duplicates = merged.Duplicates
+	event.Accepted, event.AlreadyCrawled = svc.splitCrawled(merged.Accepted)
+	if event.Rejected == nil {
+		event.Rejected = make([]types.ImportedURL, 0)
+	}
+	return event
+}
+
+// ImportText 导入一段文本（如剪贴板内容）中的文章链接
+func (svc *IntakeService) ImportText(text string) (event types.IntakeEvent) {
+	event.Source = types.IntakeSourceClipboard
+	res, _ := NewURLImportService().Import(types.URLImportFormatMarkdown, []byte(text))
+	event.Rejected = res.Rejected
+	event.Duplicates = res.Duplicates
+	event.Accepted, event.AlreadyCrawled = svc.splitCrawled(res.Accepted)
+	return event
+}
+
+// MergePendingURLs 将新的URL合并到待抓取列表中，跳过已在列表中或已经抓取过的文章
+func (svc *IntakeService) MergePendingURLs(req types.MergePendingURLsRequest) (res types.MergePendingURLsResponse) {
+	res.URLs = make([]string, 0, len(req.Queued)+len(req.Incoming))
+	res.Added = make([]string, 0, len(req.Incoming))
+	res.Duplicates = make([]string, 0)
+	res.AlreadyCrawled = make([]string, 0)
+
+	queued := make(map[string]struct{}, len(req.Queued))
+	for _, u := range req.Queued {
+		u = strings.TrimSpace(u)
+		if u == "" {
+			continue
+		}
+		queued[utils.WXArticleKey(u)] = struct{}{}
+		res.URLs = append(res.URLs, u)
+	}
+
+	crawled := svc.crawledKeys(req.Incoming)
+	for _, u := range req.Incoming {
+		u = strings.TrimSpace(u)
+		if u == "" {
+			continue
+		}
+		key := utils.WXArticleKey(u)
+		if _, ok := queued[key]; ok {
+			res.Duplicates = append(res.Duplicates, u)
+			continue
+		}
+		if _, ok := crawled[key]; ok {
+			res.AlreadyCrawled = append(res.AlreadyCrawled, u)
+			continue
+		}
+		queued[key] = struct{}{}
+		res.URLs = append(res.URLs, u)
+		res.Added = append(res.Added, u)
+	}
+	return res
+}
+
+// splitCrawled 区分出已经抓取过的文章
+func (svc *IntakeService) splitCrawled(items []types.ImportedURL) (fresh, crawled []types.ImportedURL) {
+	urls := make([]string, 0, len(items))
+	for _, item := range items {
+		urls = append(urls, item.URL)
+	}
+	crawledKeys := svc.crawledKeys(urls)
+
+	fresh = make([]types.ImportedURL, 0, len(items))
+	crawled = make([]types.ImportedURL, 0)
+	for _, item := range items {
+		if _, ok := crawledKeys[utils.WXArticleKey(item.URL)]; ok {
+			crawled = append(crawled, item)
+			continue
+		}
+		fresh = append(fresh, item)
+	}
+	return fresh, crawled
+}
+
+// crawledKeys 查询哪些文章已经抓取过：本次运行中抓取过的，以及抓取记录中抓取成功的
+// 专辑订阅同步过的文章只是加入了抓取队列，不一定抓取成功，不算已抓取
+func (svc *IntakeService) crawledKeys(urls []string) map[string]struct{} {
+	result := make(map[string]struct{})
+	keys := make([]string, 0, len(urls))
+	crawledArticles.RLock()
+	for _, u := range urls {
+		key := utils.WXArticleKey(u)
+		keys = append(keys, key)
+		if _, ok := crawledArticles.keys[key]; ok {
+			result[key] = struct{}{}
+		}
+	}
+	crawledArticles.RUnlock()
+
+	if svc.DB == nil || len(keys) == 0 {
+		return result
+	}
+	// 短链接记录的是解析出的文章标识，同时按地址查询
+	query, args, err := sqlx.In(
+		"SELECT DISTINCT article_key, url FROM crawl_run_articles WHERE status = ? AND (article_key IN (?) OR url IN (?))",
+		types.CrawlArticleSuccess, keys, urls,
+	)
+	if err != nil {
+		zap.L().Error("构建已抓取文章查询失败", zap.Error(err))
+		return result
+	}
+	var seen []struct {
+		ArticleKey string `db:"article_key"`
+		URL        string `db:"url"`
+	}
+	if err = svc.DB.Select(&seen, svc.DB.Rebind(query), args...); err != nil {
+		zap.L().Error("查询已抓取的文章失败", zap.Error(err))
+		return result
+	}
+	for _, article := range seen {
+		result[article.ArticleKey] = struct{}{}
+		result[utils.WXArticleKey(article.URL)] = struct{}{}
+	}
+	return result
+}
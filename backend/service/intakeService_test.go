@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"github.com/pudongping/wx-graph-crawl/backend/utils"
+)
+
+const (
+	intakeURL1 = "https://mp.weixin.qq.com/s?__biz=MzIntake==&mid=1001&idx=1&sn=aaa"
+	intakeURL2 = "https://mp.weixin.qq.com/s?__biz=MzIntake==&mid=1002&idx=1&sn=bbb"
+	intakeURL3 = "https://mp.weixin.qq.com/s?__biz=MzIntake==&mid=1003&idx=1&sn=ccc"
+	intakeURL4 = "https://mp.weixin.qq.com/s?__biz=MzIntake==&mid=1004&idx=1&sn=ddd"
+)
+
+func TestIntakeImportDroppedFiles(t *testing.T) {
+	db := openTestDB(t)
+	// 专辑订阅同步过但还没抓取成功的文章，以及抓取失败的文章，都不算已抓取
+	if _, err := db.Exec("INSERT INTO album_subscription_articles (subscription_id, article_key, url, msgid, title, created_at) VALUES (?, ?, ?, '', '', 0)",
+		-1, "wx:MzIntake==_1002_1", intakeURL2); err != nil {
+		t.Fatalf("写入已同步文章失败: %v", err)
+	}
+	for key, status := range map[string]types.CrawlArticleStatus{
+		"wx:MzIntake==_1001_1": types.CrawlArticleFailed,
+		"wx:MzIntake==_1003_1": types.CrawlArticleSuccess,
+	} {
+		if _, err := db.Exec("INSERT INTO crawl_run_articles (run_id, article_key, status) VALUES (?, ?, ?)", -1, key, status); err != nil {
+			t.Fatalf("写入抓取记录失败: %v", err)
+		}
+	}
+	RecordCrawledArticles([]string{utils.WXArticleKey(intakeURL4)})
+
+	dir := t.TempDir()
+	txtPath := filepath.Join(dir, "urls.txt")
+	csvPath := filepath.Join(dir, "urls.csv")
+	if err := os.WriteFile(txtPath, []byte(intakeURL1+"\nhttps://example.com/a\n"+intakeURL3+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(csvPath, []byte("title,url\na,"+intakeURL1+"&chksm=1\nb,"+intakeURL2+"\nd,"+intakeURL4+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := &IntakeService{DB: db}
+	event := svc.ImportDroppedFiles([]string{txtPath, csvPath, filepath.Join(dir, "missing.txt")})
+	if event.Source != types.IntakeSourceFileDrop {
+		t.Errorf("事件来源不正确: %s", event.Source)
+	}
+	if got := importedURLs(event.Accepted); !reflect.DeepEqual(got, []string{intakeURL1, intakeURL2}) {
+		t.Errorf("可以抓取的地址不正确: %v", got)
+	}
+	if got := importedURLs(event.AlreadyCrawled); !reflect.DeepEqual(got, []string{intakeURL3, intakeURL4}) {
+		t.Errorf("已抓取的地址不正确: %v", got)
+	}
+	if len(event.Rejected) != 1 || len(event.Duplicates) != 1 {
+		t.Errorf("被拒绝 %d 个，重复 %d 个", len(event.Rejected), len(event.Duplicates))
+	}
+	if event.ErrContent == "" {
+		t.Error("不存在的文件应该返回错误信息")
+	}
+
+	merged := svc.MergePendingURLs(types.MergePendingURLsRequest{
+		Queued:   []string{intakeURL1},
+		Incoming: []string{intakeURL1 + "#rd", intakeURL2, intakeURL3, intakeURL2},
+	})
+	if !reflect.DeepEqual(merged.URLs, []string{intakeURL1, intakeURL2}) || !reflect.DeepEqual(merged.Added, []string{intakeURL2}) {
+		t.Errorf("合并后的列表不正确: %+v", merged)
+	}
+	if len(merged.Duplicates) != 2 || !reflect.DeepEqual(merged.AlreadyCrawled, []string{intakeURL3}) {
+		t.Errorf("重复或已抓取的地址不正确: %+v", merged)
+	}
+}
+
+func TestClipboardWatcherPoll(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		text   = "之前复制的内容"
+		events []types.IntakeEvent
+	)
+	w := NewClipboardWatcher()
+	w.Interval = 10 * time.Millisecond
+	w.Intake = &IntakeService{}
+	w.ReadText = func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return text, nil
+	}
+	w.Emit = func(ctx context.Context, event types.IntakeEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+
+	ctx := context.Background()
+	w.poll(ctx)
+	text = "看看这篇 " + intakeURL1 + " 还有 https://example.com"
+	w.poll(ctx)
+	w.poll(ctx) // 内容没有变化
+	text = "[同一篇](" + intakeURL1 + "&scene=1) " + intakeURL2
+	w.poll(ctx)
+	text = intakeURL2 // 已经提示过
+
+	w.poll(ctx)
+	if len(events) != 2 {
+		t.Fatalf("应该推送 2 次事件，实际 %d 次", len(events))
+	}
+	if got := importedURLs(events[0].Accepted); !reflect.DeepEqual(got, []string{intakeURL1}) {
+		t.Errorf("第一次推送的地址不正确: %v", got)
+	}
+	if got := importedURLs(events[1].Accepted); !reflect.DeepEqual(got, []string{intakeURL2}) {
+		t.Errorf("第二次推送的地址不正确: %v", got)
+	}
+
+	w.Start(ctx)
+	if !w.Enabled() {
+		t.Error("开始监听后应该处于开启状态")
+	}
+	w.Stop()
+	if w.Enabled() {
+		t.Error("停止监听后应该处于关闭状态")
+	}
+}
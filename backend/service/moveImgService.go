@@ -1,19 +1,22 @@
 package service
 
 import (
+	"encoding/json"
 	"fmt"
+	"image"
 	"io/fs"
-	"io/ioutil"
 	"math/rand"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
-	"sync"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
 	"github.com/pudongping/wx-graph-crawl/backend/utils"
 	"go.uber.org/zap"
 )
@@ -23,185 +26,505 @@ var (
 )
 
 type MoveImgService struct {
-	DirPath     string
-	MaxNumImage int
+	DirPath          string
+	MaxNumImage      int
+	Order            types.ShuffleOrder         // 排列方式
+	Seed             int64                      // 随机种子，0 表示使用当前时间
+	SplitStrategy    types.ShuffleSplitStrategy // 拆分方式
+	SplitDirTemplate string                     // 拆分后的子目录名模板
+	Filter           types.ImageFileFilter      // 要打乱的文件范围
+	DryRun           bool                       // 只生成移动计划，不移动图片
 }
 
 func NewMoveImgService(dirPath string, maxNumImage int) *MoveImgService {
 	return &MoveImgService{
-		DirPath:     dirPath,     // 设置要处理的目录路径
-		MaxNumImage: maxNumImage, // 每个文件夹中最多可放的图片数量
+		DirPath:          dirPath,     // 设置要处理的目录路径
+		MaxNumImage:      maxNumImage, // 每个文件夹中最多可放的图片数量
+		Order:            constant.ShuffleDefaultOrder,
+		SplitStrategy:    constant.ShuffleDefaultSplitStrategy,
+		SplitDirTemplate: constant.ShuffleDefaultSplitDirTemplate,
 	}
 }
 
+// Validate 检查排列方式、拆分方式和子目录名模板
+func (svc *MoveImgService) Validate() error {
+	switch svc.Order {
+	case types.ShuffleOrderRandom, types.ShuffleOrderOriginal, types.ShuffleOrderTime, types.ShuffleOrderSize,
+		types.ShuffleOrderAspectRatio, types.ShuffleOrderInterleave:
+	default:
+		return errors.Errorf("不支持的排列方式: %s", svc.Order)
+	}
+	switch svc.SplitStrategy {
+	case types.ShuffleSplitBalanced, types.ShuffleSplitFillFirst:
+	default:
+		return errors.Errorf("不支持的拆分方式: %s", svc.SplitStrategy)
+	}
+	if !strings.Contains(svc.SplitDirTemplate, constant.ShuffleSplitDirIndexVar) {
+		return errors.Errorf("子目录名模板中必须包含 %s", constant.ShuffleSplitDirIndexVar)
+	}
+	if err := ValidateImageFileFilter(svc.DirPath, svc.Filter); err != nil {
+		return err
+	}
+	name := renderSplitDirName(svc.SplitDirTemplate, "dir", 2, 2, 1)
+	if strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return errors.Errorf("子目录名模板 %s 不能包含路径分隔符", svc.SplitDirTemplate)
+	}
+	return nil
+}
+
 func (svc *MoveImgService) RunMoveImg() error {
+	_, err := svc.Shuffle()
+	return err
+}
+
+// Shuffle 打乱图片顺序并重新命名，超过数量上限的目录拆分为子目录
+// 移动前先写入打乱日志，移动分两步：先全部移动到临时文件名，再移动到目标位置，中途失败时会还原已经移动的图片
+func (svc *MoveImgService) Shuffle() (journal types.ShuffleJournal, err error) {
+	now := time.Now()
+	journal = types.ShuffleJournal{
+		JobID:       fmt.Sprintf("%s%03d", now.Format(constant.CropJobIDTimeLayout), now.Nanosecond()/int(time.Millisecond)),
+		DirPath:     svc.DirPath,
+		MaxNumImage: svc.MaxNumImage,
+		CreatedAt:   now.Unix(),
+	}
+
+	if err = svc.Validate(); err != nil {
+		return journal, err
+	}
+	if svc.Seed == 0 {
+		// 随机种子会显示在前端，限制在 JavaScript 可以精确表示的整数范围内
+		svc.Seed = now.UnixNano() & (1<<53 - 1)
+	}
+	journal.Order, journal.Seed = svc.Order, svc.Seed
+	journal.SplitStrategy, journal.SplitDirTemplate = svc.SplitStrategy, svc.SplitDirTemplate
+
 	zap.L().Info("开始处理图片", zap.String("dirPath", svc.DirPath))
-	imgChanges, err := svc.WalkAllImages()
+	journal.Moves, err = svc.PlanMoves(journal.JobID)
 	if err != nil {
-		return errors.Wrap(err, "遍历图片时出错")
+		return journal, err
+	}
+	if svc.DryRun || len(journal.Moves) == 0 {
+		return journal, nil
 	}
 
-	zap.L().Info("开始打乱图片顺序并更改名称", zap.String("dirPath", svc.DirPath))
-	errorSlices := svc.RenameImages(imgChanges)
-	for _, errorItem := range errorSlices {
-		zap.L().Info("图片改名失败", zap.Error(errorItem))
+	journalPath := ShuffleJournalPath(svc.DirPath, journal.JobID)
+	if err = writeShuffleJournal(journalPath, journal); err != nil {
+		return journal, err
 	}
 
-	zap.L().Info("开始拆分图片", zap.String("dirPath", svc.DirPath))
-	if err := svc.DisassembleDir(imgChanges); err != nil {
-		return errors.Wrap(err, "拆分图片时出错")
+	zap.L().Info("开始打乱图片顺序并更改名称", zap.String("dirPath", svc.DirPath), zap.Int("count", len(journal.Moves)))
+	if err = applyShuffleMoves(journal.Moves, false); err != nil {
+		return journal, errors.Wrap(err, "移动图片时出错，已还原移动过的图片")
 	}
+	remapCrawlManifestMoves(svc.DirPath, journal.Moves, false)
 
-	zap.L().Info("图片处理完成", zap.String("dirPath", svc.DirPath))
-	return nil
+	journal.FinishedAt = time.Now().Unix()
+	if err = writeShuffleJournal(journalPath, journal); err != nil {
+		return journal, err
+	}
+	zap.L().Info("图片处理完成", zap.String("dirPath", svc.DirPath), zap.String("journal", journalPath))
+	return journal, nil
 }
 
 func (svc *MoveImgService) isImageFile(filename string) bool {
-	// 支持 JPEG, PNG, GIF 等格式
-	return reImg.MatchString(filename)
+	// 支持 JPEG, PNG, GIF 等格式，跳过打乱中断时留下的临时文件
+	return reImg.MatchString(filename) && !strings.HasPrefix(filename, constant.ShuffleTmpPrefix)
 }
 
-func (svc *MoveImgService) randomizeImgFiles(files []string) map[string]string {
-	rg := rand.New(rand.NewSource(time.Now().UnixNano())) // 设置随机种子确保每次执行的随机化不同
-	rg.Shuffle(len(files), func(i, j int) {
-		files[i], files[j] = files[j], files[i]
+// WalkAllImages 按过滤条件遍历目录，按目录返回其中的图片，图片按文件名中的序号排列
+func (svc *MoveImgService) WalkAllImages() (dirImages [][]string, err error) {
+	dirIndex := make(map[string]int)
+	err = WalkImageFiles(svc.DirPath, svc.Filter, func(path string, info fs.FileInfo) bool {
+		return info.Name() == constant.CropBackupDirName || info.Name() == constant.DuplicateImageDirName || info.Name() == constant.BundleDirName
+	}, func(path string, info fs.FileInfo) error {
+		if !svc.isImageFile(info.Name()) {
+			return nil
+		}
+		dir := filepath.Dir(path)
+		i, ok := dirIndex[dir]
+		if !ok {
+			i = len(dirImages)
+			dirIndex[dir] = i
+			dirImages = append(dirImages, nil)
+		}
+		dirImages[i] = append(dirImages[i], path)
+		return nil
 	})
+	for _, files := range dirImages {
+		sortNatural(files)
+	}
+	return
+}
 
-	// 创建一个临时映射，以避免直接覆盖文件
-	tempMap := make(map[string]string)
-	mux := sync.Mutex{}
-	for index, file := range files {
-		ext := filepath.Ext(file)
-		// 重新生成新的文件名，避免修改文件名称时，出现命名冲突
-		randomNumber := utils.GenRandomNumber(1, 100)
-		newName := fmt.Sprintf("%s/%d_%d%s", filepath.Dir(file), index+1, randomNumber, ext)
-		mux.Lock()
-		tempMap[file] = newName
-		mux.Unlock()
-	}
-
-	return tempMap
-}
-
-func (svc *MoveImgService) WalkAllImages() (imgChanges []map[string]string, err error) {
-	// 使用filepath.Walk递归遍历目录
-	err = filepath.Walk(svc.DirPath, func(path string, info fs.FileInfo, err error) error {
-		if err != nil {
-			return errors.Wrapf(err, "Walk 出错：%s", path)
-		}
-		if info.IsDir() {
-			// 因为这里只考虑了图片会放到文件夹下，所有只考虑文件夹下中的图片
-			files, err := ioutil.ReadDir(path)
-			if err != nil {
-				return errors.Wrap(err, "读取目录出错")
+// PlanMoves 生成移动计划：每个目录中的图片按排列方式重新排列后按序号重新命名，超过数量上限时拆分到子目录中
+// 轮流排列时，所有目录中的图片合并到图片目录中一起排列和拆分
+func (svc *MoveImgService) PlanMoves(jobID string) ([]types.ShuffleMove, error) {
+	dirImages, err := svc.WalkAllImages()
+	if err != nil {
+		return nil, errors.Wrap(err, "遍历图片时出错")
+	}
+
+	rg := rand.New(rand.NewSource(svc.Seed))
+	moves := make([]types.ShuffleMove, 0)
+	if svc.Order == types.ShuffleOrderInterleave {
+		if files := interleaveImages(dirImages); len(files) > 0 {
+			moves = svc.planDirMoves(svc.DirPath, files)
+		}
+	} else {
+		for _, files := range dirImages {
+			// 只有一张图片的目录不需要打乱
+			if len(files) < 2 {
+				continue
 			}
-			var imageFiles []string
-			for _, file := range files {
-				if file.IsDir() {
-					continue
-				}
-				if svc.isImageFile(file.Name()) {
-					// 收集符合条件的图片文件
-					imageFiles = append(imageFiles, filepath.Join(path, file.Name()))
-				}
+			moves = append(moves, svc.planDirMoves(filepath.Dir(files[0]), svc.orderImages(files, rg))...)
+		}
+	}
+	for i := range moves {
+		moves[i].Tmp = filepath.Join(filepath.Dir(moves[i].From), fmt.Sprintf("%s%s_%d%s", constant.ShuffleTmpPrefix, jobID, i, filepath.Ext(moves[i].From)))
+	}
+
+	if err = checkShuffleMoves(moves); err != nil {
+		return nil, err
+	}
+	return moves, nil
+}
+
+// orderImages 按排列方式重新排列一个目录中的图片，排序值相同时保持文章中的原始顺序
+func (svc *MoveImgService) orderImages(files []string, rg *rand.Rand) []string {
+	var key func(path string) float64
+	switch svc.Order {
+	case types.ShuffleOrderRandom:
+		rg.Shuffle(len(files), func(i, j int) {
+			files[i], files[j] = files[j], files[i]
+		})
+		return files
+	case types.ShuffleOrderTime:
+		key = func(path string) float64 {
+			if t, ok := utils.ReadExifDateTime(path); ok {
+				return float64(t.UnixNano())
+			}
+			if info, err := os.Stat(path); err == nil {
+				return float64(info.ModTime().UnixNano())
 			}
-			// 打乱图片文件，并修改文件名称
-			if len(imageFiles) > 1 {
-				ret := svc.randomizeImgFiles(imageFiles)
-				imgChanges = append(imgChanges, ret)
+			return 0
+		}
+	case types.ShuffleOrderSize:
+		key = func(path string) float64 {
+			if info, err := os.Stat(path); err == nil {
+				return float64(info.Size())
 			}
+			return 0
 		}
+	case types.ShuffleOrderAspectRatio:
+		key = imageAspectRatio
+	default:
+		return files
+	}
 
-		return nil
-	})
+	keys := make(map[string]float64, len(files))
+	for _, file := range files {
+		keys[file] = key(file)
+	}
+	sort.SliceStable(files, func(i, j int) bool { return keys[files[i]] < keys[files[j]] })
+	return files
+}
 
-	return
+// imageAspectRatio 图片的宽高比，无法读取时返回 0
+func imageAspectRatio(path string) float64 {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+	config, _, err := image.DecodeConfig(file)
+	if err != nil || config.Height == 0 {
+		return 0
+	}
+	return float64(config.Width) / float64(config.Height)
 }
 
-func (svc *MoveImgService) RenameImages(imgChanges []map[string]string) (errorSlices []error) {
-	// 执行重命名
-	for _, tempMap := range imgChanges {
-		for original, newName := range tempMap {
-			if err := os.Rename(original, newName); err != nil {
-				errorSlices = append(errorSlices, errors.Wrapf(err, "%s 改名时，改成 %s 出错", original, newName))
-			} else {
-				zap.L().Info("图片改名成功", zap.String("original", original), zap.String("newName", newName))
+// interleaveImages 多个目录中的图片轮流排列：每个目录的第一张、每个目录的第二张……
+func interleaveImages(dirImages [][]string) []string {
+	files := make([]string, 0)
+	for i := 0; ; i++ {
+		added := false
+		for _, dirFiles := range dirImages {
+			if i < len(dirFiles) {
+				files = append(files, dirFiles[i])
+				added = true
 			}
 		}
+		if !added {
+			return files
+		}
 	}
+}
 
-	return
+// sortNatural 按文件名中的数字大小排序，如 2.jpeg 排在 10.jpeg 之前
+func sortNatural(files []string) {
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := filepath.Base(files[i]), filepath.Base(files[j])
+		na, errA := strconv.Atoi(strings.TrimSuffix(a, filepath.Ext(a)))
+		nb, errB := strconv.Atoi(strings.TrimSuffix(b, filepath.Ext(b)))
+		switch {
+		case errA == nil && errB == nil:
+			return na < nb
+		case errA == nil || errB == nil:
+			return errA == nil // 纯数字的文件名排在前面
+		default:
+			return a < b
+		}
+	})
 }
 
-func (svc *MoveImgService) DisassembleDir(imgChanges []map[string]string) error {
-	for _, item := range imgChanges {
-		if err := svc.disassembleDirItem(item); err != nil {
-			return errors.Wrap(err, "拆分目录时出现异常")
+// planDirMoves 按打乱后的顺序重新命名一个目录中的图片，图片数量超过上限时按拆分方式分到多个子目录中
+func (svc *MoveImgService) planDirMoves(parentPath string, files []string) []types.ShuffleMove {
+	imageCount := len(files)
+	sizes := SplitSizes(imageCount, svc.MaxNumImage, svc.SplitStrategy)
+
+	moves := make([]types.ShuffleMove, 0, imageCount)
+	i := 0
+	for dirIndex, size := range sizes {
+		dir := parentPath
+		if len(sizes) > 1 {
+			dir = filepath.Join(parentPath, renderSplitDirName(svc.SplitDirTemplate, filepath.Base(parentPath), imageCount, len(sizes), dirIndex+1))
+		}
+		for _, file := range files[i : i+size] {
+			i++
+			moves = append(moves, types.ShuffleMove{
+				From: file,
+				To:   filepath.Join(dir, fmt.Sprintf("%d%s", i, filepath.Ext(file))),
+			})
 		}
 	}
-
-	return nil
+	return moves
 }
 
-func (svc *MoveImgService) getRandomImgPath(imgs map[string]string) string {
-	values := make([]string, 0, len(imgs))
-	for _, v := range imgs {
-		values = append(values, v)
+// SplitSizes 计算拆分后每个子目录中的图片数量，子目录数量为 ceil(count/max)，不拆分时只返回一个元素
+func SplitSizes(count, maxNum int, strategy types.ShuffleSplitStrategy) []int {
+	if maxNum <= 0 || count <= maxNum {
+		return []int{count}
 	}
+	total := (count + maxNum - 1) / maxNum
+	sizes := make([]int, total)
+	for i := range sizes {
+		if strategy == types.ShuffleSplitFillFirst {
+			sizes[i] = min(maxNum, count-i*maxNum)
+			continue
+		}
+		// 平均分配，前面的子目录多放一张
+		sizes[i] = count / total
+		if i < count%total {
+			sizes[i]++
+		}
+	}
+	return sizes
+}
 
-	rg := rand.New(rand.NewSource(time.Now().UnixNano())) // 初始化随机数生成器
-	randomIndex := rg.Intn(len(values))                   // 获取一个随机索引
-	return values[randomIndex]
+// renderSplitDirName 按模板生成拆分后的子目录名
+func renderSplitDirName(template, name string, count, total, index int) string {
+	return strings.NewReplacer(
+		"{name}", name,
+		"{count}", strconv.Itoa(count),
+		"{total}", strconv.Itoa(total),
+		constant.ShuffleSplitDirIndexVar, strconv.Itoa(index),
+	).Replace(template)
 }
 
-func (svc *MoveImgService) disassembleDirItem(imgs map[string]string) error {
-	imageCount := len(imgs)
-	if svc.MaxNumImage <= 0 {
-		return nil
+// checkShuffleMoves 检查目标位置是否重复，或者被不参与打乱的文件占用
+func checkShuffleMoves(moves []types.ShuffleMove) error {
+	sources := make(map[string]struct{}, len(moves))
+	for _, m := range moves {
+		sources[m.From] = struct{}{}
+	}
+	targets := make(map[string]struct{}, len(moves))
+	for _, m := range moves {
+		if _, ok := targets[m.To]; ok {
+			return errors.Errorf("打乱后有多张图片需要移动到 %s", m.To)
+		}
+		targets[m.To] = struct{}{}
+		if _, ok := sources[m.To]; ok {
+			continue
+		}
+		if _, err := os.Stat(m.To); err == nil {
+			return errors.Errorf("%s 已存在，无法移动 %s", m.To, m.From)
+		}
 	}
-	if imageCount <= svc.MaxNumImage {
+	return nil
+}
+
+// applyShuffleMoves 分两步移动图片：先移动到临时文件名，再移动到目标位置
+// reverse 为 true 时从目标位置移动回原来的位置；任何一步失败时按相反的顺序还原已经完成的移动
+func applyShuffleMoves(moves []types.ShuffleMove, reverse bool) (err error) {
+	type rename struct{ src, dst string }
+	done := make([]rename, 0, len(moves)*2)
+	defer func() {
+		if err == nil {
+			return
+		}
+		for i := len(done) - 1; i >= 0; i-- {
+			if rbErr := os.Rename(done[i].dst, done[i].src); rbErr != nil {
+				zap.L().Error("还原图片失败", zap.String("path", done[i].dst), zap.String("dst", done[i].src), zap.Error(rbErr))
+			}
+		}
+	}()
+	do := func(src, dst string) error {
+		if err := os.Rename(src, dst); err != nil {
+			return errors.Wrapf(err, "%s ==> %s 移动失败", src, dst)
+		}
+		done = append(done, rename{src: src, dst: dst})
 		return nil
 	}
 
-	var err error
-	// 因为微信中一篇小绿书的图片最多只能有 20 张，因此就拆成 2 个文件夹好了
-	halfCount := imageCount / 2
-	imageFiles := make([]string, 0, imageCount)
-	for _, v := range imgs {
-		imageFiles = append(imageFiles, v)
+	for _, m := range moves {
+		src := m.From
+		if reverse {
+			src = m.To
+		}
+		if err = do(src, m.Tmp); err != nil {
+			return err
+		}
 	}
+	for _, m := range moves {
+		dst := m.To
+		if reverse {
+			dst = m.From
+		}
+		if err = utils.MkdirIfNotExist(filepath.Dir(dst)); err != nil {
+			return errors.Wrap(err, "创建目录出错")
+		}
+		if err = do(m.Tmp, dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ShuffleJournalPath 打乱日志的路径
+func ShuffleJournalPath(dirPath, jobID string) string {
+	return filepath.Join(dirPath, constant.CropBackupDirName, constant.ShuffleJournalPrefix+jobID+".json")
+}
 
-	// 创建新的子目录
-	parentPath := filepath.Dir(imageFiles[0]) // 随便取一个图片，从而获得父目录
-	subDir1Name := filepath.Join(parentPath, strconv.Itoa(imageCount)+"_1")
-	subDir2Name := filepath.Join(parentPath, strconv.Itoa(imageCount)+"_2")
+func writeShuffleJournal(path string, journal types.ShuffleJournal) error {
+	if err := utils.MkdirIfNotExist(filepath.Dir(path)); err != nil {
+		return errors.Wrap(err, "创建打乱日志目录失败")
+	}
+	content, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "序列化打乱日志失败")
+	}
+	if err = os.WriteFile(path, content, 0644); err != nil {
+		return errors.Wrap(err, "写入打乱日志失败")
+	}
+	return nil
+}
 
-	err = utils.MkdirIfNotExist(subDir1Name)
+func readShuffleJournal(path string) (journal types.ShuffleJournal, err error) {
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return errors.Wrap(err, "创建目录1出错")
+		return journal, errors.Wrap(err, "读取打乱日志失败")
 	}
-	err = utils.MkdirIfNotExist(subDir2Name)
+	if err = json.Unmarshal(content, &journal); err != nil {
+		return journal, errors.Wrap(err, "解析打乱日志失败")
+	}
+	return journal, nil
+}
+
+// UndoShuffle 按打乱日志把图片移动回原来的位置，并删除打乱时创建的空目录
+func UndoShuffle(journalPath string) (restored int, err error) {
+	journal, err := readShuffleJournal(journalPath)
 	if err != nil {
-		return errors.Wrap(err, "创建目录2出错")
+		return 0, err
+	}
+	if journal.UndoneAt > 0 {
+		return 0, errors.Errorf("打乱任务 %s 已经撤销过了", journal.JobID)
+	}
+
+	moves, pending := journal.Moves, []types.ShuffleMove(nil)
+	if journal.FinishedAt == 0 {
+		moves, pending = interruptedShuffleMoves(journal.Moves)
 	}
 
-	// 对图片进行排序并移动
-	sort.Strings(imageFiles)
-	for i, imageFile := range imageFiles {
-		imgFileName := filepath.Base(imageFile)
-		var dstPath string
-		if i < halfCount {
-			dstPath = filepath.Join(subDir1Name, imgFileName)
-		} else {
-			dstPath = filepath.Join(subDir2Name, imgFileName)
+	if err = applyShuffleMoves(moves, true); err != nil {
+		return 0, errors.Wrap(err, "撤销打乱失败")
+	}
+	restored += len(moves)
+	// 还在临时文件名的图片，原来的位置已经空出来了，直接移动回去
+	for _, m := range pending {
+		if err = os.Rename(m.Tmp, m.From); err != nil {
+			return restored, errors.Wrapf(err, "%s ==> %s 移动失败", m.Tmp, m.From)
 		}
+		restored++
+	}
 
-		// 移动图片
-		if err = os.Rename(imageFile, dstPath); err != nil {
-			return errors.Wrapf(err, "%s ==> %s 移动失败 %v", imageFile, dstPath, err)
+	// 拆分目录时创建的子目录，移走图片后为空的删除
+	dirs := make(map[string]struct{})
+	for _, m := range journal.Moves {
+		if dir := filepath.Dir(m.To); dir != filepath.Dir(m.From) {
+			dirs[dir] = struct{}{}
 		}
+	}
+	sortedDirs := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sortedDirs = append(sortedDirs, dir)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(sortedDirs)))
+	for _, dir := range sortedDirs {
+		_ = os.Remove(dir) // 目录不为空时不会删除
+	}
 
+	// 打乱中断时抓取记录还没有更新，不需要改回去
+	if journal.FinishedAt > 0 {
+		remapCrawlManifestMoves(journal.DirPath, journal.Moves, true)
 	}
 
-	return nil
+	journal.UndoneAt = time.Now().Unix()
+	if err = writeShuffleJournal(journalPath, journal); err != nil {
+		return restored, err
+	}
+	zap.L().Info("撤销打乱完成", zap.String("journal", journalPath), zap.Int("restored", restored))
+	return restored, nil
+}
+
+// interruptedShuffleMoves 打乱过程中被中断时，找出已经移动到目标位置的图片和还在临时文件名的图片
+// 移动分两步按顺序进行：第一步中断时，前面的图片在临时文件名，后面的还在原来的位置；
+// 第二步中断时，前面的图片在目标位置，后面的还在临时文件名
+func interruptedShuffleMoves(all []types.ShuffleMove) (moved, pending []types.ShuffleMove) {
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	if len(all) == 0 {
+		return nil, nil
+	}
+
+	secondStep := !exists(all[0].Tmp) && exists(all[len(all)-1].Tmp)
+	if !secondStep && !exists(all[0].Tmp) {
+		// 临时文件都不存在：要么还没有开始移动，要么第二步已经全部完成，只是没来得及更新日志
+		secondStep = true
+		for _, m := range all {
+			if !exists(m.To) {
+				secondStep = false
+				break
+			}
+		}
+		fromMissing := false
+		for _, m := range all {
+			if !exists(m.From) {
+				fromMissing = true
+				break
+			}
+		}
+		secondStep = secondStep && fromMissing
+	}
+
+	for _, m := range all {
+		switch {
+		case exists(m.Tmp):
+			pending = append(pending, m)
+		case secondStep:
+			moved = append(moved, m)
+		}
+	}
+	return moved, pending
 }
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+)
+
+func TestSplitSizes(t *testing.T) {
+	cases := []struct {
+		count, max int
+		strategy   types.ShuffleSplitStrategy
+		want       []int
+	}{
+		{70, 20, types.ShuffleSplitBalanced, []int{18, 18, 17, 17}},
+		{70, 20, types.ShuffleSplitFillFirst, []int{20, 20, 20, 10}},
+		{40, 20, types.ShuffleSplitBalanced, []int{20, 20}},
+		{21, 20, types.ShuffleSplitFillFirst, []int{20, 1}},
+		{20, 20, types.ShuffleSplitBalanced, []int{20}},
+		{5, 0, types.ShuffleSplitBalanced, []int{5}},
+	}
+	for _, c := range cases {
+		if got := SplitSizes(c.count, c.max, c.strategy); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("SplitSizes(%d, %d, %s) = %v，应该为 %v", c.count, c.max, c.strategy, got, c.want)
+		}
+	}
+}
+
+func TestShuffleSplitDirTemplate(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < 7; i++ {
+		if err := os.WriteFile(filepath.Join(root, string(rune('a'+i))+".jpg"), []byte{byte(i)}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := NewMoveImgService(root, 3)
+	svc.SplitStrategy = types.ShuffleSplitFillFirst
+	svc.SplitDirTemplate = "part{index}of{total}"
+	svc.DryRun = true
+	journal, err := svc.Shuffle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int)
+	for _, m := range journal.Moves {
+		counts[filepath.Base(filepath.Dir(m.To))]++
+	}
+	if want := map[string]int{"part1of3": 3, "part2of3": 3, "part3of3": 1}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("拆分结果不正确: %v", counts)
+	}
+
+	svc.SplitDirTemplate = "{count}"
+	if _, err = svc.Shuffle(); err == nil {
+		t.Error("模板中没有 {index} 时应该返回错误")
+	}
+	svc.SplitDirTemplate = "a/{index}"
+	if _, err = svc.Shuffle(); err == nil {
+		t.Error("模板中包含路径分隔符时应该返回错误")
+	}
+}
+
+func TestShuffleAndUndo(t *testing.T) {
+	root := t.TempDir()
+	// 原来的文件名与打乱后的文件名相同，用文件内容区分图片
+	files := map[string]string{
+		"1.jpg":     "a",
+		"2.jpg":     "b",
+		"3.png":     "c",
+		"sub/1.jpg": "d",
+		"sub/x.jpg": "e",
+		"sub/y.gif": "f",
+		"note.txt":  "not an image",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertFiles := func(stage string) {
+		t.Helper()
+		for name, content := range files {
+			got, err := os.ReadFile(filepath.Join(root, name))
+			if err != nil || string(got) != content {
+				t.Errorf("%s: %s 的内容应该为 %q，实际为 %q, %v", stage, name, content, got, err)
+			}
+		}
+	}
+
+	svc := NewMoveImgService(root, 2)
+	svc.DryRun = true
+	journal, err := svc.Shuffle()
+	if err != nil || len(journal.Moves) != 6 {
+		t.Fatalf("生成移动计划失败: %v, %+v", err, journal)
+	}
+	assertFiles("只生成移动计划")
+
+	svc.DryRun = false
+	journal, err = svc.Shuffle()
+	if err != nil || journal.FinishedAt == 0 {
+		t.Fatalf("打乱失败: %v, %+v", err, journal)
+	}
+	contents := make(map[string]struct{})
+	for _, m := range journal.Moves {
+		got, err := os.ReadFile(m.To)
+		if err != nil {
+			t.Fatalf("打乱后的图片 %s 不存在: %v", m.To, err)
+		}
+		contents[string(got)] = struct{}{}
+	}
+	if len(contents) != 6 {
+		t.Errorf("打乱后有图片被覆盖: %v", contents)
+	}
+	// 超过上限的目录拆分为子目录
+	if _, err = os.Stat(filepath.Join(root, "3_1")); err != nil {
+		t.Errorf("应该拆分目录: %v", err)
+	}
+
+	journalPath := ShuffleJournalPath(root, journal.JobID)
+	restored, err := UndoShuffle(journalPath)
+	if err != nil || restored != 6 {
+		t.Fatalf("撤销打乱失败: %v, %d", err, restored)
+	}
+	assertFiles("撤销打乱")
+	if _, err = os.Stat(filepath.Join(root, "3_1")); !os.IsNotExist(err) {
+		t.Errorf("撤销后应该删除拆分时创建的目录: %v", err)
+	}
+	if _, err = UndoShuffle(journalPath); err == nil {
+		t.Error("重复撤销应该返回错误")
+	}
+}
+
+func TestUndoInterruptedShuffle(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"1.jpg", "2.jpg", "3.jpg"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	journal, err := NewMoveImgService(root, 0).Shuffle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 模拟第二步移动了一张图片后程序退出：第一张在目标位置，其余的还在临时文件名
+	for _, m := range journal.Moves[1:] {
+		if err = os.Rename(m.To, m.Tmp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	journal.FinishedAt = 0
+	journalPath := ShuffleJournalPath(root, journal.JobID)
+	if err = writeShuffleJournal(journalPath, journal); err != nil {
+		t.Fatal(err)
+	}
+
+	if restored, err := UndoShuffle(journalPath); err != nil || restored != 3 {
+		t.Fatalf("撤销中断的打乱失败: %v, %d", err, restored)
+	}
+	for _, name := range []string{"1.jpg", "2.jpg", "3.jpg"} {
+		if got, err := os.ReadFile(filepath.Join(root, name)); err != nil || string(got) != name {
+			t.Errorf("%s 没有恢复: %q, %v", name, got, err)
+		}
+	}
+}
+
+func TestShuffleOrder(t *testing.T) {
+	root := t.TempDir()
+	// 两篇文章，文件大小与序号相反
+	for _, article := range []string{"1", "2"} {
+		for i, name := range []string{"1.jpeg", "2.jpeg", "10.jpeg"} {
+			path := filepath.Join(root, article, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, make([]byte, 10-i), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	plan := func(order types.ShuffleOrder, seed int64) []types.ShuffleMove {
+		t.Helper()
+		svc := NewMoveImgService(root, 0)
+		svc.Order, svc.Seed, svc.DryRun = order, seed, true
+		journal, err := svc.Shuffle()
+		if err != nil {
+			t.Fatalf("%s: 生成移动计划失败: %v", order, err)
+		}
+		if journal.Order != order || (seed != 0 && journal.Seed != seed) {
+			t.Errorf("打乱日志中应该记录排列方式和随机种子: %+v", journal)
+		}
+		return journal.Moves
+	}
+	froms := func(moves []types.ShuffleMove) (names []string) {
+		for _, m := range moves {
+			rel, _ := filepath.Rel(root, m.From)
+			names = append(names, filepath.ToSlash(rel))
+		}
+		return names
+	}
+	tos := func(moves []types.ShuffleMove) (names []string) {
+		for _, m := range moves {
+			rel, _ := filepath.Rel(root, m.To)
+			names = append(names, filepath.ToSlash(rel))
+		}
+		return names
+	}
+
+	// 临时路径中带有按时间生成的任务编号，只比较移动的来源和目标
+	if a, b := plan(types.ShuffleOrderRandom, 42), plan(types.ShuffleOrderRandom, 42); !reflect.DeepEqual(froms(a), froms(b)) || !reflect.DeepEqual(tos(a), tos(b)) {
+		t.Errorf("相同的随机种子应该得到相同的结果: %v => %v, %v => %v", froms(a), tos(a), froms(b), tos(b))
+	}
+	if got, want := froms(plan(types.ShuffleOrderOriginal, 0)), []string{"1/1.jpeg", "1/2.jpeg", "1/10.jpeg", "2/1.jpeg", "2/2.jpeg", "2/10.jpeg"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("原始顺序不正确: %v", got)
+	}
+	if got, want := froms(plan(types.ShuffleOrderSize, 0))[:3], []string{"1/10.jpeg", "1/2.jpeg", "1/1.jpeg"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("按文件大小排列不正确: %v", got)
+	}
+	moves := plan(types.ShuffleOrderInterleave, 0)
+	if got, want := froms(moves), []string{"1/1.jpeg", "2/1.jpeg", "1/2.jpeg", "2/2.jpeg", "1/10.jpeg", "2/10.jpeg"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("轮流排列不正确: %v", got)
+	}
+	if moves[1].To != filepath.Join(root, "2.jpeg") {
+		t.Errorf("轮流排列时应该合并到图片目录中: %s", moves[1].To)
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+)
+
+// settingRule 设置项的定义和类型、范围以外的校验
+type settingRule struct {
+	types.SettingDefinition
+	check func(value any) string // 返回错误信息，没有错误时返回空字符串
+}
+
+// preferenceSettings 偏好设置中的全部设置项，新增设置项时在这里登记
+var preferenceSettings = []settingRule{
+	{
+		SettingDefinition: types.SettingDefinition{
+			Key:         "save_img_path",
+			Label:       "图片保存路径",
+			Type:        types.SettingTypeString,
+			Default:     "",
+			Description: "抓取的图片和文案保存的目录，必须是绝对路径",
+		},
+		check: func(value any) string {
+			if path := value.(string); path != "" && !filepath.IsAbs(path) {
+				return "必须是绝对路径"
+			}
+			return ""
+		},
+	},
+	{
+		SettingDefinition: types.SettingDefinition{
+			Key:         "download_timeout",
+			Label:       "下载超时时间（秒）",
+			Type:        types.SettingTypeInt,
+			Default:     constant.SettingDownloadTimeoutDefault,
+			Min:         intPtr(constant.SettingDownloadTimeoutMin),
+			Max:         intPtr(constant.SettingDownloadTimeoutMax),
+			Description: "抓取文章和下载图片的超时时间",
+		},
+	},
+	{
+		SettingDefinition: types.SettingDefinition{
+			Key:         "crop_img_bottom_pixel",
+			Label:       "裁剪底部像素",
+			Type:        types.SettingTypeInt,
+			Default:     constant.SettingCropImgBottomPixelDefault,
+			Min:         intPtr(constant.SettingCropImgBottomPixelMin),
+			Max:         intPtr(constant.SettingCropImgBottomPixelMax),
+			Description: "裁剪图片时去掉底部多少像素（水印区域）",
+		},
+	},
+	{
+		SettingDefinition: types.SettingDefinition{
+			Key:         "post_process",
+			Label:       "抓取后处理图片",
+			Type:        types.SettingTypeObject,
+			Default:     nil,
+			Description: "抓取后自动执行的图片处理步骤，为空时不处理",
+		},
+		check: func(value any) string {
+			var options types.ImagePipelineOptions
+			if err := remarshal(value, &options); err != nil {
+				return "格式不正确"
+			}
+			if err := ValidateImagePipelineOptions(options); err != nil {
+				return err.Error()
+			}
+			return ""
+		},
+	},
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+// SettingsSchema 返回全部设置项的定义，前端按定义渲染表单
+func SettingsSchema() []types.SettingDefinition {
+	definitions := make([]types.SettingDefinition, 0, len(preferenceSettings))
+	for _, rule := range preferenceSettings {
+		definitions = append(definitions, rule.SettingDefinition)
+	}
+	return definitions
+}
+
+// ValidatePreferenceInfo 按设置项的定义校验偏好设置，整数为 0 时使用默认值
+// 校验失败时返回 *types.SettingsValidationError，包含每个设置项的错误
+func ValidatePreferenceInfo(req *types.SetPreferenceInfoRequest) error {
+	values := make(map[string]any)
+	if err := remarshal(req, &values); err != nil {
+		return errors.Wrap(err, "解析偏好设置失败")
+	}
+
+	var fieldErrors []types.SettingFieldError
+	for _, rule := range preferenceSettings {
+		value, message := rule.validate(values[rule.Key])
+		if message != "" {
+			fieldErrors = append(fieldErrors, types.SettingFieldError{Field: rule.Key, Message: message})
+			continue
+		}
+		values[rule.Key] = value
+	}
+	if len(fieldErrors) > 0 {
+		return &types.SettingsValidationError{Fields: fieldErrors}
+	}
+	return remarshal(values, req)
+}
+
+// validate 检查值的类型和范围，返回填充默认值后的值和错误信息
+func (rule settingRule) validate(value any) (any, string) {
+	if value == nil {
+		if rule.Type == types.SettingTypeObject {
+			return nil, ""
+		}
+		value = rule.Default
+	}
+	switch rule.Type {
+	case types.SettingTypeString:
+		if _, ok := value.(string); !ok {
+			return nil, "必须是字符串"
+		}
+	case types.SettingTypeBool:
+		if _, ok := value.(bool); !ok {
+			return nil, "必须是布尔值"
+		}
+	case types.SettingTypeObject:
+		if _, ok := value.(map[string]any); !ok {
+			return nil, "格式不正确"
+		}
+	case types.SettingTypeInt:
+		number, ok := toFloat(value)
+		if !ok || number != math.Trunc(number) {
+			return nil, "必须是整数"
+		}
+		if number == 0 {
+			return rule.Default, ""
+		}
+		if rule.Min != nil && number < float64(*rule.Min) {
+			return nil, fmt.Sprintf("不能小于 %d", *rule.Min)
+		}
+		if rule.Max != nil && number > float64(*rule.Max) {
+			return nil, fmt.Sprintf("不能大于 %d", *rule.Max)
+		}
+	}
+	if rule.check != nil {
+		if message := rule.check(value); message != "" {
+			return nil, message
+		}
+	}
+	return value, ""
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// remarshal 通过 JSON 把 from 转换为 to 的类型
+func remarshal(from, to any) error {
+	content, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, to)
+}
@@ -0,0 +1,539 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/pkg/errors"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"github.com/pudongping/wx-graph-crawl/backend/utils"
+)
+
+// URLImportService 从不同格式的文件中导入URL
+type URLImportService struct {
+}
+
+func NewURLImportService() *URLImportService {
+	return &URLImportService{}
+}
+
+// ImportFile 读取文件，根据扩展名和内容识别格式并提取其中的URL
+func (svc *URLImportService) ImportFile(path string) (res types.SelectFileResponse, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return res, errors.Wrap(err, "读取URL文件，打开文件时")
+	}
+
+	res, err = svc.Import(detectURLImportFormat(path, content), content)
+	if err != nil {
+		return res, err
+	}
+	res.FilePath = path
+	return res, nil
+}
+
+// Import 按指定格式提取URL，并区分出可以抓取的、被拒绝的和重复的URL
+func (svc *URLImportService) Import(format types.URLImportFormat, content []byte) (res types.SelectFileResponse, err error) {
+	content = bytes.TrimPrefix(content, []byte(utf8BOM))
+
+	var candidates []types.ImportedURL
+	switch format {
+	case types.URLImportFormatCSV:
+		candidates, err = extractCSVURLs(content)
+	case types.URLImportFormatJSON:
+		candidates, err = extractJSONURLs(content)
+	case types.URLImportFormatHTML:
+		candidates, err = extractHTMLURLs(content)
+	case types.URLImportFormatMarkdown:
+		candidates = extractMarkdownURLs(content)
+	default:
+		format = types.URLImportFormatText
+		candidates, err = extractTextURLs(content)
+	}
+	if err != nil {
+		return res, errors.Wrapf(err, "解析%s文件失败", format)
+	}
+
+	res = classifyImportedURLs(candidates)
+	res.Format = format
+	return res, nil
+}
+
+// detectURLImportFormat 优先根据扩展名识别格式，扩展名无法识别时根据内容判断
+func detectURLImportFormat(path string, content []byte) types.URLImportFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return types.URLImportFormatCSV
+	case ".json":
+		return types.URLImportFormatJSON
+	case ".html", ".htm":
+		return types.URLImportFormatHTML
+	case ".md", ".markdown":
+		return types.URLImportFormatMarkdown
+	case ".txt":
+		return types.URLImportFormatText
+	}
+
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(content, []byte(utf8BOM)))
+	lower := bytes.ToLower(trimmed)
+	switch {
+	case len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') && json.Valid(trimmed):
+		return types.URLImportFormatJSON
+	case bytes.HasPrefix(lower, []byte("<!doctype")) || bytes.HasPrefix(lower, []byte("<html")) || bytes.Contains(lower, []byte("<a ")):
+		return types.URLImportFormatHTML
+	case bytes.Contains(trimmed, []byte("](")):
+		return types.URLImportFormatMarkdown
+	}
+	return types.URLImportFormatText
+}
+
+// classifyImportedURLs 校验并去重，保持在文件中出现的顺序
+func classifyImportedURLs(candidates []types.ImportedURL) (res types.SelectFileResponse) {
+	res.Accepted = make([]types.ImportedURL, 0, len(candidates))
+	res.Rejected = make([]types.ImportedURL, 0)
+	res.Duplicates = make([]types.ImportedURL, 0)
+
+	seen := make(map[string]struct{}, len(candidates))
+	for _, item := range candidates {
+		item.URL = cleanImportedURL(item.URL)
+		item.Title = strings.TrimSpace(item.Title)
+		if reason := validateImportedURL(item.URL); reason != "" {
+			item.Reason = reason
+			res.Rejected = append(res.Rejected, item)
+			continue
+		}
+		// 使用规范化后的地址，同一篇文章的不同写法视为重复
+		key := item.URL
+		if u, err := utils.NormalizeWXArticleURL(item.URL); err == nil {
+			item.URL, key = u.Canonical, u.Key
+		}
+		if _, ok := seen[key]; ok {
+			item.Reason = types.URLRejectDuplicate
+			res.Duplicates = append(res.Duplicates, item)
+			continue
+		}
+		seen[key] = struct{}{}
+		res.Accepted = append(res.Accepted, item)
+	}
+	return res
+}
+
+// cleanImportedURL 去除空白、尖括号，并还原HTML转义的 &amp;
+func cleanImportedURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	rawURL = strings.TrimPrefix(rawURL, "<")
+	rawURL = strings.TrimSuffix(rawURL, ">")
+	return unescapeAmp(strings.TrimSpace(rawURL))
+}
+
+// validateImportedURL 检查是否为可以抓取的微信公众号文章地址，可以抓取时返回空字符串
+func validateImportedURL(rawURL string) types.URLRejectReason {
+	lower := strings.ToLower(rawURL)
+	if !strings.HasPrefix(lower, "https://") && !strings.HasPrefix(lower, "http://") {
+		return types.URLRejectNotHTTP
+	}
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || parsedURL.Host == "" || strings.ContainsAny(rawURL, " \t\r\n") {
+		return types.URLRejectInvalid
+	}
+	if !strings.Contains(strings.ToLower(parsedURL.Host)+parsedURL.Path, constant.WXMPTWDomain) {
+		return types.URLRejectNotWXURL
+	}
+	return ""
+}
+
+// extractTextURLs 每行一个URL，跳过空行
+func extractTextURLs(content []byte) ([]types.ImportedURL, error) {
+	var candidates []types.ImportedURL
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue // 跳过空行
+		}
+		candidates = append(candidates, types.ImportedURL{URL: line, Line: lineNo})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "读取URL文件，扫描文件时")
+	}
+	return candidates, nil
+}
+
+// extractCSVURLs 读取CSV文件
+// 表头中有“地址”或 url/link 列时只读取该列，并尝试读取“序号”和“标题”列；否则读取所有以 http 开头的单元格
+func extractCSVURLs(content []byte) ([]types.ImportedURL, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	var (
+		candidates                    []types.ImportedURL
+		urlCol, titleCol, indexCol    = -1, -1, -1
+		isFirstRecord, hasHeaderField = true, false
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		lineNo, _ := reader.FieldPos(0)
+
+		if isFirstRecord {
+			isFirstRecord = false
+			for i, cell := range record {
+				switch strings.ToLower(strings.TrimSpace(cell)) {
+				case "地址", "链接", "url", "link", "href":
+					urlCol, hasHeaderField = i, true
+				case "标题", "title", "name":
+					titleCol = i
+				case "序号", "index":
+					indexCol = i
+				}
+			}
+			if hasHeaderField {
+				continue
+			}
+		}
+
+		if urlCol >= 0 {
+			if urlCol >= len(record) || strings.TrimSpace(record[urlCol]) == "" {
+				continue
+			}
+			item := types.ImportedURL{URL: record[urlCol], Line: lineNo}
+			if titleCol >= 0 && titleCol < len(record) {
+				item.Title = record[titleCol]
+			}
+			if indexCol >= 0 && indexCol < len(record) {
+				item.Index = parseImportedIndex(record[indexCol])
+			}
+			candidates = append(candidates, item)
+			continue
+		}
+
+		for _, cell := range record {
+			if strings.HasPrefix(strings.ToLower(strings.TrimSpace(cell)), "http") {
+				candidates = append(candidates, types.ImportedURL{URL: cell, Line: lineNo})
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// extractJSONURLs 读取JSON文件
+// 支持URL字符串数组、包含 url/link/href 字段的对象数组，以及专辑导出的 JSON 文件
+func extractJSONURLs(content []byte) ([]types.ImportedURL, error) {
+	var data interface{}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+
+	var candidates []types.ImportedURL
+	walkJSONURLs(data, &candidates)
+	return candidates, nil
+}
+
+// walkJSONURLs 递归查找JSON中的URL，对象中只读取 url/link/href 字段，数组中读取字符串元素
+func walkJSONURLs(value interface{}, candidates *[]types.ImportedURL) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, elem := range v {
+			if s, ok := elem.(string); ok {
+				*candidates = append(*candidates, types.ImportedURL{URL: s})
+				continue
+			}
+			walkJSONURLs(elem, candidates)
+		}
+	case map[string]interface{}:
+		item := types.ImportedURL{}
+		for _, key := range []string{"url", "link", "href"} {
+			if s, ok := v[key].(string); ok && s != "" {
+				item.URL = s
+				break
+			}
+		}
+		if item.URL != "" {
+			for _, key := range []string{"title", "name"} {
+				if s, ok := v[key].(string); ok {
+					item.Title = s
+					break
+				}
+			}
+			if n, ok := v["index"].(json.Number); ok {
+				item.Index = parseImportedIndex(n.String())
+			}
+			*candidates = append(*candidates, item)
+			return
+		}
+
+		// 专辑导出结果中的 urls 与 articles 重复，有 articles 时只读取 articles
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			if key == "urls" && v["articles"] != nil {
+				continue
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walkJSONURLs(v[key], candidates)
+		}
+	}
+}
+
+// extractHTMLURLs 读取浏览器导出的书签文件或普通网页中的链接
+func extractHTMLURLs(content []byte) ([]types.ImportedURL, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []types.ImportedURL
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		candidates = append(candidates, types.ImportedURL{URL: href, Title: s.Text()})
+	})
+	return candidates, nil
+}
+
+// parseImportedIndex 解析专辑序号，解析失败时返回0
+func parseImportedIndex(s string) int {
+	index := 0
+	for _, r := range strings.TrimSpace(s) {
+		if r < '0' || r > '9' {
+			return 0
+		}
+		index = index*10 + int(r-'0')
+		if index > 1<<30 {
+			return 0
+		}
+	}
+	return index
+}
+
+// extractMarkdownURLs 读取Markdown文档中的链接
+// 支持行内链接 [文字](地址 "标题")、自动链接 <地址>、引用定义 [名称]: 地址 和直接写出的地址，跳过代码块和行内代码
+func extractMarkdownURLs(content []byte) []types.ImportedURL {
+	var (
+		candidates []types.ImportedURL
+		fence      string // 当前所在代码块的围栏，为空时表示不在代码块中
+	)
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lineNo := i + 1
+		trimmed := strings.TrimSpace(line)
+
+		// 围栏代码块
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		// 引用定义
+		if label, dest, ok := parseMarkdownReferenceDefinition(trimmed); ok {
+			candidates = append(candidates, types.ImportedURL{URL: dest, Title: label, Line: lineNo})
+			continue
+		}
+
+		candidates = append(candidates, scanMarkdownInline(line, lineNo)...)
+	}
+	return candidates
+}
+
+// parseMarkdownReferenceDefinition 解析 [名称]: 地址 "标题" 形式的引用定义
+func parseMarkdownReferenceDefinition(line string) (label, dest string, ok bool) {
+	if !strings.HasPrefix(line, "[") {
+		return "", "", false
+	}
+	end := strings.Index(line, "]:")
+	if end <= 1 {
+		return "", "", false
+	}
+	fields := strings.Fields(line[end+2:])
+	if len(fields) == 0 {
+		return "", "", false
+	}
+	return line[1:end], fields[0], true
+}
+
+// scanMarkdownInline 逐字符扫描一行中的行内链接、自动链接和直接写出的地址
+func scanMarkdownInline(line string, lineNo int) []types.ImportedURL {
+	var candidates []types.ImportedURL
+	for i := 0; i < len(line); {
+		switch c := line[i]; {
+		case c == '`':
+			// 跳过行内代码，反引号数量需要一致
+			run := countMarkdownRun(line[i:], '`')
+			closing := strings.Index(line[i+run:], strings.Repeat("`", run))
+			if closing < 0 {
+				i += run
+				continue
+			}
+			i += run + closing + run
+		case c == '[':
+			textEnd := matchMarkdownBracket(line, i)
+			if textEnd < 0 || textEnd+1 >= len(line) || line[textEnd+1] != '(' {
+				i++
+				continue
+			}
+			dest, next := parseMarkdownDestination(line, textEnd+2)
+			if next < 0 {
+				i++
+				continue
+			}
+			if i == 0 || line[i-1] != '!' { // 图片不是文章链接
+				candidates = append(candidates, types.ImportedURL{URL: dest, Title: line[i+1 : textEnd], Line: lineNo})
+			}
+			i = next
+		case c == '<':
+			end := strings.IndexByte(line[i:], '>')
+			inner := ""
+			if end > 0 {
+				inner = line[i+1 : i+end]
+			}
+			if hasHTTPPrefix(inner) && !strings.ContainsAny(inner, " \t") {
+				candidates = append(candidates, types.ImportedURL{URL: inner, Line: lineNo})
+				i += end + 1
+				continue
+			}
+			i++
+		case hasHTTPPrefix(line[i:]) && (i == 0 || !isURLWordChar(line[i-1])):
+			end := i
+			for end < len(line) && line[end] > ' ' && line[end] < 0x7f && line[end] != '<' && line[end] != '>' {
+				end++
+			}
+			candidates = append(candidates, types.ImportedURL{URL: trimBareURL(line[i:end]), Line: lineNo})
+			i = end
+		default:
+			i++
+		}
+	}
+	return candidates
+}
+
+// matchMarkdownBracket 找到与 start 位置的 [ 匹配的 ]，支持嵌套，找不到时返回 -1
+func matchMarkdownBracket(line string, start int) int {
+	depth := 0
+	for i := start; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseMarkdownDestination 解析行内链接括号中的地址，返回地址和右括号之后的位置，格式不正确时返回 -1
+func parseMarkdownDestination(line string, start int) (string, int) {
+	i := start
+	for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+		i++
+	}
+
+	var dest string
+	if i < len(line) && line[i] == '<' {
+		end := strings.IndexByte(line[i:], '>')
+		if end < 0 {
+			return "", -1
+		}
+		dest = line[i+1 : i+end]
+		i += end + 1
+	} else {
+		// 地址中可以包含成对的括号
+		depth, begin := 0, i
+		for ; i < len(line); i++ {
+			c := line[i]
+			if c == ' ' || c == '\t' {
+				break
+			}
+			if c == '(' {
+				depth++
+			} else if c == ')' {
+				if depth == 0 {
+					break
+				}
+				depth--
+			}
+		}
+		dest = line[begin:i]
+	}
+
+	// 跳过可选的标题，找到右括号
+	for depth := 0; i < len(line); i++ {
+		switch line[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return dest, i + 1
+			}
+			depth--
+		}
+	}
+	return "", -1
+}
+
+// trimBareURL 去除直接写出的地址末尾的标点符号，保留成对的右括号
+func trimBareURL(u string) string {
+	for len(u) > 0 {
+		last := u[len(u)-1]
+		if strings.IndexByte(".,;:!?'\"*_", last) >= 0 {
+			u = u[:len(u)-1]
+			continue
+		}
+		if last == ')' && strings.Count(u, ")") > strings.Count(u, "(") {
+			u = u[:len(u)-1]
+			continue
+		}
+		break
+	}
+	return u
+}
+
+func countMarkdownRun(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+func hasHTTPPrefix(s string) bool {
+	lower := strings.ToLower(s[:min(len(s), 8)])
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+func isURLWordChar(c byte) bool {
+	return c == '/' || c == '=' || c == '_' || c == '-' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+)
+
+const (
+	importURL1 = "https://mp.weixin.qq.com/s?__biz=Mzg5MzgxMTIyOQ==&mid=2247522881&idx=1&sn=5a6c2c1ec214ec411fcff1a2a9a7053c"
+	importURL2 = "https://mp.weixin.qq.com/s/AbCdEf12"
+)
+
+func importedURLs(items []types.ImportedURL) []string {
+	urls := make([]string, 0, len(items))
+	for _, item := range items {
+		urls = append(urls, item.URL)
+	}
+	return urls
+}
+
+func TestURLImport(t *testing.T) {
+	cases := []struct {
+		name       string
+		format     types.URLImportFormat
+		content    string
+		accepted   []string
+		rejected   int
+		duplicates int
+	}{
+		{
+			name:     "文本文件",
+			format:   types.URLImportFormatText,
+			content:  "\n" + importURL1 + "\n  " + importURL2 + "  \nhttps://example.com/a\n这不是地址\n" + importURL2 + "\n",
+			accepted: []string{importURL1, importURL2}, rejected: 2, duplicates: 1,
+		},
+		{
+			name:   "同一篇文章的不同写法",
+			format: types.URLImportFormatText,
+			content: "http://mp.weixin.qq.com/s?__biz=Mzg5MzgxMTIyOQ==&amp;mid=2247522881&amp;idx=1&amp;sn=5a6c2c1ec214ec411fcff1a2a9a7053c&amp;chksm=c02bf533f7#rd\n" +
+				importURL1 + "&scene=21&sessionid=123#wechat_redirect\n",
+			accepted: []string{importURL1}, duplicates: 1,
+		},
+		{
+			name:     "专辑导出CSV",
+			format:   types.URLImportFormatCSV,
+			content:  utf8BOM + "序号,标题,地址,发布时间\n2,\"标题,带逗号\"," + importURL2 + ",2025-09-29\n1,第一篇,\"" + importURL1 + "\",2025-09-28\n3,没有地址,,\n",
+			accepted: []string{importURL2, importURL1},
+		},
+		{
+			name:     "没有表头的CSV",
+			format:   types.URLImportFormatCSV,
+			content:  "a," + importURL1 + "\nb,https://example.com\n",
+			accepted: []string{importURL1}, rejected: 1,
+		},
+		{
+			name:     "字符串数组JSON",
+			format:   types.URLImportFormatJSON,
+			content:  `["` + importURL1 + `", "` + importURL1 + `", "ftp://mp.weixin.qq.com/s/x"]`,
+			accepted: []string{importURL1}, rejected: 1, duplicates: 1,
+		},
+		{
+			name:     "专辑导出JSON",
+			format:   types.URLImportFormatJSON,
+			content:  `{"album_title":"干货分享集","articles":[{"index":1,"title":"a","url":"` + importURL1 + `","cover_url":"https://mmbiz.qpic.cn/a"}],"urls":["` + importURL1 + `"]}`,
+			accepted: []string{importURL1},
+		},
+		{
+			name:   "浏览器书签",
+			format: types.URLImportFormatHTML,
+			content: `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<DL><p>
+    <DT><H3>公众号</H3>
+    <DL><p>
+        <DT><A HREF="` + "https://mp.weixin.qq.com/s?__biz=Mzg5MzgxMTIyOQ==&amp;mid=2247522881&amp;idx=1&amp;sn=5a6c2c1ec214ec411fcff1a2a9a7053c" + `" ADD_DATE="1">文章一</A>
+        <DT><A HREF="https://github.com">GitHub</A>
+        <DT><A HREF="javascript:void(0)">书签脚本</A>
+    </DL><p>
+</DL>`,
+			accepted: []string{importURL1}, rejected: 2,
+		},
+		{
+			name:   "Markdown文档",
+			format: types.URLImportFormatMarkdown,
+			content: "# 合集\n" +
+				"- [文章一](" + importURL1 + " \"标题\")\n" +
+				"- <" + importURL2 + ">\n" +
+				"- 直接写出的地址：" + importURL2 + "/，以及 ![图片](https://mmbiz.qpic.cn/a.png)\n" +
+				"- `" + "https://mp.weixin.qq.com/s/InCode" + "`\n" +
+				"```\n" + "https://mp.weixin.qq.com/s/InFence" + "\n```\n" +
+				"- [带括号](https://example.com/a_(b))\n" +
+				"[ref]: https://mp.weixin.qq.com/s/Ref1\n",
+			accepted: []string{importURL1, importURL2, "https://mp.weixin.qq.com/s/Ref1"}, rejected: 1, duplicates: 1,
+		},
+	}
+
+	svc := NewURLImportService()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res, err := svc.Import(c.format, []byte(c.content))
+			if err != nil {
+				t.Fatalf("导入失败: %v", err)
+			}
+			if got := importedURLs(res.Accepted); !reflect.DeepEqual(got, c.accepted) {
+				t.Errorf("accepted 期望 %v，实际为 %v", c.accepted, got)
+			}
+			if len(res.Rejected) != c.rejected || len(res.Duplicates) != c.duplicates {
+				t.Errorf("rejected/duplicates 期望 %d/%d，实际为 %+v / %+v", c.rejected, c.duplicates, res.Rejected, res.Duplicates)
+			}
+		})
+	}
+}
+
+func TestURLImportDetails(t *testing.T) {
+	svc := NewURLImportService()
+
+	res, err := svc.Import(types.URLImportFormatCSV, []byte("序号,标题,地址\n7,第七篇,"+importURL1+"\n"))
+	if err != nil || len(res.Accepted) != 1 {
+		t.Fatalf("导入CSV失败: %+v %v", res, err)
+	}
+	if item := res.Accepted[0]; item.Index != 7 || item.Title != "第七篇" || item.Line != 2 {
+		t.Errorf("CSV导入信息不正确: %+v", item)
+	}
+
+	res, err = svc.Import(types.URLImportFormatText, []byte("https://example.com\nabc\nhttp://mp.weixin.qq.com/s/ a b"))
+	if err != nil {
+		t.Fatalf("导入文本失败: %v", err)
+	}
+	reasons := make([]types.URLRejectReason, 0)
+	for _, item := range res.Rejected {
+		reasons = append(reasons, item.Reason)
+	}
+	want := []types.URLRejectReason{types.URLRejectNotWXURL, types.URLRejectNotHTTP, types.URLRejectInvalid}
+	if !reflect.DeepEqual(reasons, want) {
+		t.Errorf("拒绝原因期望 %v，实际为 %v", want, reasons)
+	}
+
+	if _, err = svc.Import(types.URLImportFormatJSON, []byte("[1, 2")); err == nil {
+		t.Error("JSON格式不正确时应该返回错误")
+	}
+}
+
+func TestDetectURLImportFormat(t *testing.T) {
+	cases := []struct {
+		path    string
+		content string
+		want    types.URLImportFormat
+	}{
+		{"a.CSV", "", types.URLImportFormatCSV},
+		{"bookmarks.htm", "", types.URLImportFormatHTML},
+		{"notes.markdown", "", types.URLImportFormatMarkdown},
+		{"list.txt", "[1]", types.URLImportFormatText},
+		{"list", ` ["https://mp.weixin.qq.com/s/a"]`, types.URLImportFormatJSON},
+		{"list", "<!DOCTYPE NETSCAPE-Bookmark-file-1>", types.URLImportFormatHTML},
+		{"list", "- [a](https://mp.weixin.qq.com/s/a)", types.URLImportFormatMarkdown},
+		{"list", "https://mp.weixin.qq.com/s/a", types.URLImportFormatText},
+	}
+	for _, c := range cases {
+		if got := detectURLImportFormat(c.path, []byte(c.content)); got != c.want {
+			t.Errorf("detectURLImportFormat(%q) 期望 %s，实际为 %s", c.path, c.want, got)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 	"github.com/pudongping/wx-graph-crawl/backend/constant"
 	"github.com/pudongping/wx-graph-crawl/backend/global"
@@ -14,21 +15,70 @@ import (
 )
 
 type UserService struct {
+	DB *sqlx.DB
 }
 
 func NewUserService() *UserService {
-	return &UserService{}
+	return &UserService{
+		DB: global.DB,
+	}
 }
 
+// SetPreferenceInfo 校验并保存偏好设置，每次修改都会记录下来，可以回滚
+// 校验失败时返回 *types.SettingsValidationError，res.FieldErrors 中为每个设置项的错误
 func (svc *UserService) SetPreferenceInfo(ctx context.Context, req types.SetPreferenceInfoRequest) (res types.SetPreferenceInfoResponse, err error) {
 	zap.L().Info("SetPreferenceInfo", zap.Any("req", req))
-	now := time.Now().Unix()
+	// 没有传图片处理步骤时保留原来的设置
+	if req.PostProcess == nil {
+		if pref, _ := svc.GetPreferenceInfo(); pref != nil {
+			req.PostProcess = pref.PostProcess
+		}
+	}
+	if err = ValidatePreferenceInfo(&req); err != nil {
+		var validationErr *types.SettingsValidationError
+		if errors.As(err, &validationErr) {
+			res.FieldErrors = validationErr.Fields
+		}
+		zap.L().Warn("SetPreferenceInfo Validate", zap.Error(err))
+		return res, err
+	}
 	prefJson, err := json.Marshal(req)
 	if err != nil {
 		zap.L().Error("SetPreferenceInfo Marshal", zap.Error(err))
 		return res, err
 	}
 
+	res.Version, res.UpdatedTime, err = svc.saveSystemConfig(ctx, constant.SystemConfigKeyPreferenceInfo, string(prefJson))
+	if err != nil {
+		zap.L().Error("SetPreferenceInfo Exec", zap.Error(err))
+		return res, err
+	}
+
+	return
+}
+
+// saveSystemConfig 保存配置并记录修改，内容没有变化时不保存，返回保存后的版本号和更新时间
+func (svc *UserService) saveSystemConfig(ctx context.Context, key, content string) (version int, updatedAt int64, err error) {
+	tx, err := svc.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var current types.SystemConfig
+	err = tx.GetContext(ctx, &current, "SELECT * FROM system_configs WHERE key = ?", key)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, err
+	}
+	if err == nil && current.Content == content {
+		return current.Version, current.UpdatedAt, tx.Commit()
+	}
+
+	now := time.Now().Unix()
 	// 使用 UPSERT 语法更新或插入记录
 	query := `
 		INSERT INTO system_configs (key, content, version, created_at, updated_at)
@@ -38,14 +88,85 @@ func (svc *UserService) SetPreferenceInfo(ctx context.Context, req types.SetPref
 		version = version + 1,
 		updated_at = excluded.updated_at
 	`
-	_, err = global.DB.Exec(query, constant.SystemConfigKeyPreferenceInfo, string(prefJson), now, now)
+	if _, err = tx.ExecContext(ctx, query, key, content, now, now); err != nil {
+		return 0, 0, err
+	}
+	if err = tx.GetContext(ctx, &version, "SELECT version FROM system_configs WHERE key = ?", key); err != nil {
+		return 0, 0, err
+	}
+	if _, err = tx.ExecContext(ctx, "INSERT INTO system_config_histories (key, version, content, created_at) VALUES (?, ?, ?, ?)", key, version, content, now); err != nil {
+		return 0, 0, err
+	}
+	// 只保留最近的修改记录
+	if _, err = tx.ExecContext(ctx, "DELETE FROM system_config_histories WHERE key = ? AND version <= ?", key, version-constant.SettingHistoryMaxRecords); err != nil {
+		return 0, 0, err
+	}
+	return version, now, tx.Commit()
+}
+
+// ListPreferenceHistory 列出偏好设置的修改记录，最新的在前面
+func (svc *UserService) ListPreferenceHistory(ctx context.Context) ([]types.SettingHistoryItem, error) {
+	var histories []types.SettingHistory
+	err := svc.DB.SelectContext(ctx, &histories, "SELECT * FROM system_config_histories WHERE key = ? ORDER BY version DESC", constant.SystemConfigKeyPreferenceInfo)
 	if err != nil {
-		zap.L().Error("SetPreferenceInfo Exec", zap.Error(err))
+		zap.L().Error("ListPreferenceHistory", zap.Error(err))
+		return nil, err
+	}
+
+	items := make([]types.SettingHistoryItem, 0, len(histories))
+	values := make([]map[string]any, len(histories))
+	for i, history := range histories {
+		item := types.SettingHistoryItem{Version: history.Version, CreatedAt: history.CreatedAt, Current: i == 0}
+		var pref types.GetPreferenceInfoResponse
+		if err = json.Unmarshal([]byte(history.Content), &pref); err == nil {
+			pref.UpdatedTime = history.CreatedAt
+			item.Value = &pref
+		}
+		_ = json.Unmarshal([]byte(history.Content), &values[i])
+		items = append(items, item)
+	}
+	// 与上一个版本比较，找出修改了的设置项，最早的一条记录没有可比较的版本
+	for i := 0; i+1 < len(items); i++ {
+		for _, definition := range SettingsSchema() {
+			before, _ := json.Marshal(values[i+1][definition.Key])
+			after, _ := json.Marshal(values[i][definition.Key])
+			if string(before) != string(after) {
+				items[i].Changed = append(items[i].Changed, definition.Key)
+			}
+		}
+	}
+	return items, nil
+}
+
+// RollbackPreferenceInfo 把偏好设置恢复为指定版本的内容，回滚会保存为一个新的版本
+func (svc *UserService) RollbackPreferenceInfo(ctx context.Context, version int) (res types.RollbackPreferenceInfoResponse, err error) {
+	var history types.SettingHistory
+	err = svc.DB.GetContext(ctx, &history, "SELECT * FROM system_config_histories WHERE key = ? AND version = ?", constant.SystemConfigKeyPreferenceInfo, version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return res, errors.Errorf("没有找到版本 %d 的偏好设置", version)
+		}
 		return res, err
 	}
-	res.UpdatedTime = now
 
-	return
+	var req types.SetPreferenceInfoRequest
+	if err = json.Unmarshal([]byte(history.Content), &req); err != nil {
+		return res, errors.Wrapf(err, "解析版本 %d 的偏好设置失败", version)
+	}
+	if err = ValidatePreferenceInfo(&req); err != nil {
+		return res, errors.Wrapf(err, "版本 %d 的偏好设置已经不能使用", version)
+	}
+	prefJson, err := json.Marshal(req)
+	if err != nil {
+		return res, err
+	}
+	res.Version, res.UpdatedTime, err = svc.saveSystemConfig(ctx, constant.SystemConfigKeyPreferenceInfo, string(prefJson))
+	if err != nil {
+		zap.L().Error("RollbackPreferenceInfo", zap.Error(err))
+		return res, err
+	}
+	zap.L().Info("偏好设置已回滚", zap.Int("from", version), zap.Int("version", res.Version))
+	return res, nil
 }
 
 func (svc *UserService) GetPreferenceInfo() (*types.GetPreferenceInfoResponse, error) {
@@ -54,7 +175,7 @@ func (svc *UserService) GetPreferenceInfo() (*types.GetPreferenceInfoResponse, e
 		res types.GetPreferenceInfoResponse
 		sc  types.SystemConfig
 	)
-	err = global.DB.Get(&sc, "SELECT * FROM system_configs WHERE key = ?", constant.SystemConfigKeyPreferenceInfo)
+	err = svc.DB.Get(&sc, "SELECT * FROM system_configs WHERE key = ?", constant.SystemConfigKeyPreferenceInfo)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			zap.L().Info("没有找到偏好信息")
@@ -76,6 +197,13 @@ func (svc *UserService) GetPreferenceInfo() (*types.GetPreferenceInfoResponse, e
 		return nil, err
 	}
 
+	// 旧版本保存的值可能为 0，使用默认值
+	if res.DownloadTimeout == 0 {
+		res.DownloadTimeout = constant.SettingDownloadTimeoutDefault
+	}
+	if res.CropImgBottomPixel == 0 {
+		res.CropImgBottomPixel = constant.SettingCropImgBottomPixelDefault
+	}
 	res.UpdatedTime = sc.UpdatedAt
 
 	return &res, nil
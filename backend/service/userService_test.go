@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+)
+
+func TestPreferenceInfoHistory(t *testing.T) {
+	ctx := context.Background()
+	svc := &UserService{DB: openTestDB(t)}
+	dirA, dirB := filepath.Join(t.TempDir(), "a"), filepath.Join(t.TempDir(), "b")
+
+	// 校验失败时返回每个设置项的错误，不会保存
+	res, err := svc.SetPreferenceInfo(ctx, types.SetPreferenceInfoRequest{
+		SaveImgPath:     "relative/path",
+		DownloadTimeout: 1000,
+		PostProcess:     &types.ImagePipelineOptions{JPEGQuality: 200},
+	})
+	var validationErr *types.SettingsValidationError
+	if !errors.As(err, &validationErr) || len(res.FieldErrors) != 3 {
+		t.Fatalf("应该返回 3 个设置项的错误，结果为 %+v, %v", res.FieldErrors, err)
+	}
+
+	first, err := svc.SetPreferenceInfo(ctx, types.SetPreferenceInfoRequest{SaveImgPath: dirA, DownloadTimeout: 10})
+	if err != nil {
+		t.Fatalf("保存偏好设置失败: %v", err)
+	}
+	pref, err := svc.GetPreferenceInfo()
+	if err != nil || pref.CropImgBottomPixel != constant.SettingCropImgBottomPixelDefault {
+		t.Fatalf("没有设置的值应该使用默认值: %+v, %v", pref, err)
+	}
+	// 内容没有变化时不增加版本
+	if again, _ := svc.SetPreferenceInfo(ctx, types.SetPreferenceInfoRequest{SaveImgPath: dirA, DownloadTimeout: 10}); again.Version != first.Version {
+		t.Errorf("内容没有变化时版本为 %d，应该为 %d", again.Version, first.Version)
+	}
+	second, err := svc.SetPreferenceInfo(ctx, types.SetPreferenceInfoRequest{SaveImgPath: dirB, DownloadTimeout: 10})
+	if err != nil || second.Version != first.Version+1 {
+		t.Fatalf("第二次保存的版本为 %d: %v", second.Version, err)
+	}
+
+	history, err := svc.ListPreferenceHistory(ctx)
+	if err != nil || len(history) < 2 {
+		t.Fatalf("修改记录为 %+v: %v", history, err)
+	}
+	if !history[0].Current || history[0].Version != second.Version || len(history[0].Changed) != 1 || history[0].Changed[0] != "save_img_path" {
+		t.Errorf("最新的修改记录为 %+v", history[0])
+	}
+
+	rollback, err := svc.RollbackPreferenceInfo(ctx, first.Version)
+	if err != nil || rollback.Version != second.Version+1 {
+		t.Fatalf("回滚失败: %+v, %v", rollback, err)
+	}
+	if pref, _ = svc.GetPreferenceInfo(); pref.SaveImgPath != dirA {
+		t.Errorf("回滚后的图片保存路径为 %s，应该为 %s", pref.SaveImgPath, dirA)
+	}
+	if _, err = svc.RollbackPreferenceInfo(ctx, 9999); err == nil {
+		t.Error("回滚不存在的版本应该返回错误")
+	}
+}
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/disintegration/imaging"
+	"github.com/pkg/errors"
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+	"go.uber.org/zap"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// WatermarkService 给目录中的图片添加文字或图片水印，添加后覆盖原图
+// backup 方式与裁剪使用相同的日志和原图备份，可以通过撤销裁剪来撤销
+type WatermarkService struct {
+	RootDir        string                 // 要处理的图片目录
+	ConcurrencyMax int                    // 并发处理的goroutines数量
+	Options        types.WatermarkOptions // 水印设置
+	Mode           types.CropMode         // 保存方式，只支持 backup 和 overwrite
+	JournalDir     string                 // backup 方式的日志和原图备份目录
+
+	mark    image.Image  // 渲染好的水印，所有图片共用
+	journal *cropJournal // backup 方式的日志
+}
+
+func NewWatermarkService(rootDir string, concurrencyMax int, options types.WatermarkOptions) *WatermarkService {
+	return &WatermarkService{
+		RootDir:        rootDir,
+		ConcurrencyMax: concurrencyMax,
+		Options:        normalizeWatermarkOptions(options),
+	}
+}
+
+// normalizeWatermarkOptions 没有设置的参数使用默认值
+func normalizeWatermarkOptions(o types.WatermarkOptions) types.WatermarkOptions {
+	if o.Position == "" {
+		o.Position = constant.WatermarkDefaultPosition
+	}
+	if o.Margin <= 0 {
+		o.Margin = constant.WatermarkDefaultMargin
+	}
+	if o.Opacity <= 0 {
+		o.Opacity = constant.WatermarkDefaultOpacity
+	}
+	if o.Scale <= 0 {
+		o.Scale = constant.WatermarkDefaultScale
+	}
+	return o
+}
+
+// ValidateWatermarkOptions 检查水印设置
+func ValidateWatermarkOptions(o types.WatermarkOptions) error {
+	if (o.Text == "") == (o.LogoPath == "") {
+		return errors.New("请设置文字水印或图片水印中的一个")
+	}
+	switch o.Position {
+	case types.WatermarkTopLeft, types.WatermarkTopRight, types.WatermarkBottomLeft, types.WatermarkBottomRight, types.WatermarkCenter:
+	default:
+		return errors.Errorf("不支持的水印位置: %s", o.Position)
+	}
+	if o.Margin >= 0.5 {
+		return errors.New("水印边距必须小于图片宽度的一半")
+	}
+	if o.Opacity > 1 || o.Scale > 1 {
+		return errors.New("水印的不透明度和缩放比例不能大于 1")
+	}
+	if o.TextColor != "" {
+		if _, err := parseHexColor(o.TextColor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (svc *WatermarkService) RunWatermark() ([]types.WatermarkResult, error) {
+	if err := ValidateWatermarkOptions(svc.Options); err != nil {
+		return nil, err
+	}
+	switch svc.Mode {
+	case "", types.CropModeOverwrite:
+	case types.CropModeBackup:
+		if svc.JournalDir == "" {
+			return nil, errors.New("没有设置原图备份目录")
+		}
+		journal, err := openCropJournal(svc.JournalDir)
+		if err != nil {
+			return nil, err
+		}
+		defer journal.Close()
+		svc.journal = journal
+	default:
+		return nil, errors.Errorf("添加水印不支持的保存方式: %s", svc.Mode)
+	}
+	mark, err := svc.loadMark()
+	if err != nil {
+		return nil, err
+	}
+	svc.mark = mark
+	return svc.processImages(svc.RootDir, svc.ConcurrencyMax)
+}
+
+// loadMark 读取图片水印或渲染文字水印
+func (svc *WatermarkService) loadMark() (image.Image, error) {
+	if svc.Options.LogoPath != "" {
+		logo, err := imaging.Open(svc.Options.LogoPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "读取水印图片失败")
+		}
+		return logo, nil
+	}
+	return renderTextWatermark(svc.Options.Text, svc.Options.FontPath, svc.Options.TextColor)
+}
+
+// processFile 给一张图片添加水印并保存回原文件
+// GIF 动图保存后只剩第一帧，跳过；webp 无法保存，和图片处理一样转换为 JPEG
+func (svc *WatermarkService) processFile(path string, wg *sync.WaitGroup, semaphore chan struct{}, resultChan chan types.WatermarkResult) {
+	defer wg.Done()
+	defer func() { <-semaphore }()
+
+	result := types.WatermarkResult{ImgPath: path, OutputPath: path}
+	file, err := os.Open(path)
+	if err != nil {
+		result.Err = errors.Wrap(err, "打开文件失败")
+		resultChan <- result
+		return
+	}
+	_, format, err := image.DecodeConfig(file)
+	file.Close()
+	if err != nil {
+		zap.L().Info("该文件不是图片", zap.String("path", path))
+		return
+	}
+	if format == string(types.ImageFormatGIF) {
+		zap.L().Info("GIF 图片添加水印后会丢失动画，跳过", zap.String("path", path))
+		result.Skipped = true
+		resultChan <- result
+		return
+	}
+
+	img, err := imaging.Open(path)
+	if err != nil {
+		result.Err = errors.Wrap(err, "解码图片失败")
+		resultChan <- result
+		return
+	}
+	saveFormat, err := imaging.FormatFromFilename(path)
+	if format == string(types.ImageFormatWebP) || err != nil {
+		// JPEG 不支持透明，透明的部分填充为白色
+		bg := imaging.New(img.Bounds().Dx(), img.Bounds().Dy(), color.White)
+		img = imaging.Overlay(bg, img, image.Pt(0, 0), 1.0)
+		saveFormat = imaging.JPEG
+		result.OutputPath = strings.TrimSuffix(path, filepath.Ext(path)) + ".jpg"
+		if _, err = os.Stat(result.OutputPath); err == nil {
+			result.Err = errors.Errorf("转换格式失败，%s 已存在", filepath.Base(result.OutputPath))
+			resultChan <- result
+			return
+		}
+	}
+
+	if err = svc.backup(path, result.OutputPath); err != nil {
+		result.Err = errors.Wrapf(err, "保存图片 %s 失败", filepath.Base(path))
+		resultChan <- result
+		return
+	}
+	if err = saveImageFile(ApplyWatermark(img, svc.mark, svc.Options), result.OutputPath, saveFormat); err != nil {
+		result.Err = err
+		resultChan <- result
+		return
+	}
+	if result.OutputPath != path {
+		if err = os.Remove(path); err != nil {
+			zap.L().Warn("删除转换前的图片失败", zap.String("path", path), zap.Error(err))
+		}
+	}
+
+	resultChan <- result
+	zap.L().Info("添加水印成功", zap.String("path", result.OutputPath))
+}
+
+// backup 在保存添加水印后的图片之前备份原图并写入日志，保存到其他路径时撤销会删除该路径，overwrite 方式不备份
+func (svc *WatermarkService) backup(path, outputPath string) error {
+	if svc.journal == nil {
+		return nil
+	}
+	return svc.journal.Backup(svc.RootDir, path, outputPath)
+}
+
+// processImages 按默认的过滤条件遍历目录及其子目录，并发给图片添加水印，与裁剪图片使用相同的并发方式
+// 跳过原图备份、相似图片和发布包目录，以及图片目录中的水印图片本身
+func (svc *WatermarkService) processImages(rootDir string, concurrency int) (results []types.WatermarkResult, err error) {
+	semaphore := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	resultChan := make(chan types.WatermarkResult, 100)
+	// 在单独的goroutine中收集结果，避免图片数量超过通道容量时阻塞
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for result := range resultChan {
+			results = append(results, result)
+		}
+	}()
+
+	logoPath := ""
+	if svc.Options.LogoPath != "" {
+		logoPath, _ = filepath.Abs(svc.Options.LogoPath)
+	}
+	err = WalkImageFiles(rootDir, types.ImageFileFilter{}, func(path string, info os.FileInfo) bool {
+		return info.Name() == constant.CropBackupDirName || info.Name() == constant.DuplicateImageDirName
+	}, func(path string, info os.FileInfo) error {
+		if abs, _ := filepath.Abs(path); logoPath != "" && abs == logoPath {
+			return nil
+		}
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go svc.processFile(path, &wg, semaphore, resultChan)
+		return nil
+	})
+
+	wg.Wait()
+	close(resultChan)
+	<-done
+	if err != nil {
+		return nil, errors.Wrap(err, "遍历目录时出错")
+	}
+	return results, nil
+}
+
+// ApplyWatermark 按图片宽度缩放水印，并以指定的不透明度叠加到图片上
+func ApplyWatermark(img, mark image.Image, o types.WatermarkOptions) image.Image {
+	o = normalizeWatermarkOptions(o)
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	markWidth := max(1, int(float64(width)*o.Scale))
+	resized := imaging.Resize(mark, markWidth, 0, imaging.Lanczos)
+	markHeight := resized.Bounds().Dy()
+	margin := int(float64(width) * o.Margin)
+
+	var x, y int
+	switch o.Position {
+	case types.WatermarkTopLeft:
+		x, y = margin, margin
+	case types.WatermarkTopRight:
+		x, y = width-markWidth-margin, margin
+	case types.WatermarkBottomLeft:
+		x, y = margin, height-markHeight-margin
+	case types.WatermarkCenter:
+		x, y = (width-markWidth)/2, (height-markHeight)/2
+	default:
+		x, y = width-markWidth-margin, height-markHeight-margin
+	}
+	return imaging.Overlay(img, resized, image.Pt(img.Bounds().Min.X+x, img.Bounds().Min.Y+y), o.Opacity)
+}
+
+// watermarkSystemFonts 内置字体不支持文字水印中的文字（如中文）时，依次尝试的系统字体
+var watermarkSystemFonts = []string{
+	`C:\Windows\Fonts\msyh.ttc`,
+	`C:\Windows\Fonts\msyh.ttf`,
+	`C:\Windows\Fonts\simhei.ttf`,
+	`C:\Windows\Fonts\simsun.ttc`,
+	"/System/Library/Fonts/PingFang.ttc",
+	"/System/Library/Fonts/STHeiti Medium.ttc",
+	"/System/Library/Fonts/Hiragino Sans GB.ttc",
+	"/Library/Fonts/Arial Unicode.ttf",
+	"/usr/share/fonts/opentype/noto/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/noto-cjk/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/google-noto-cjk/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/truetype/wqy/wqy-microhei.ttc",
+	"/usr/share/fonts/wqy-microhei/wqy-microhei.ttc",
+}
+
+// loadWatermarkFont 加载能显示全部文字的字体
+// 设置了字体文件时只使用该文件；没有设置时先使用内置字体，内置字体缺少文字时再查找系统字体
+func loadWatermarkFont(text, fontPath string) (*sfnt.Font, error) {
+	if fontPath != "" {
+		fontBytes, err := os.ReadFile(fontPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "读取字体文件失败")
+		}
+		fonts, err := parseFontFile(fontBytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "解析字体文件失败")
+		}
+		for _, f := range fonts {
+			if missing := missingGlyph(f, text); missing == 0 {
+				return f, nil
+			}
+		}
+		return nil, errors.Errorf("字体中没有文字 %q，请设置支持该文字的字体文件", missingGlyph(fonts[0], text))
+	}
+
+	f, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return nil, errors.Wrap(err, "解析内置字体失败")
+	}
+	missing := missingGlyph(f, text)
+	if missing == 0 {
+		return f, nil
+	}
+	for _, path := range watermarkSystemFonts {
+		fontBytes, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		fonts, err := parseFontFile(fontBytes)
+		if err != nil {
+			zap.L().Warn("解析系统字体失败", zap.String("path", path), zap.Error(err))
+			continue
+		}
+		for _, f := range fonts {
+			if missingGlyph(f, text) == 0 {
+				zap.L().Info("文字水印使用系统字体", zap.String("path", path))
+				return f, nil
+			}
+		}
+	}
+	return nil, errors.Errorf("内置字体和系统字体中都没有文字 %q，请设置支持该文字的字体文件", missing)
+}
+
+// parseFontFile 解析 ttf/otf 字体文件，ttc 字体集合返回其中全部字体
+func parseFontFile(fontBytes []byte) ([]*sfnt.Font, error) {
+	collection, err := opentype.ParseCollection(fontBytes)
+	if err != nil {
+		return nil, err
+	}
+	fonts := make([]*sfnt.Font, 0, collection.NumFonts())
+	for i := 0; i < collection.NumFonts(); i++ {
+		f, err := collection.Font(i)
+		if err != nil {
+			return nil, err
+		}
+		fonts = append(fonts, f)
+	}
+	if len(fonts) == 0 {
+		return nil, errors.New("字体文件中没有字体")
+	}
+	return fonts, nil
+}
+
+// missingGlyph 返回字体中第一个没有的文字，全部都有时返回 0，字体中没有的文字会显示为方框
+func missingGlyph(f *sfnt.Font, text string) rune {
+	var buf sfnt.Buffer
+	for _, r := range text {
+		if idx, err := f.GlyphIndex(&buf, r); err != nil || (idx == 0 && !strings.ContainsRune(" \t", r)) {
+			return r
+		}
+	}
+	return 0
+}
+
+// renderTextWatermark 将文字渲染为透明背景的图片
+func renderTextWatermark(text, fontPath, textColor string) (image.Image, error) {
+	f, err := loadWatermarkFont(text, fontPath)
+	if err != nil {
+		return nil, err
+	}
+
+	col := color.Color(color.White)
+	if textColor != "" {
+		if col, err = parseHexColor(textColor); err != nil {
+			return nil, err
+		}
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: constant.WatermarkFontSize, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, errors.Wrap(err, "加载字体失败")
+	}
+	defer face.Close()
+
+	drawer := &font.Drawer{Face: face, Src: image.NewUniform(col)}
+	metrics := face.Metrics()
+	padding := constant.WatermarkFontSize / 8
+	width := drawer.MeasureString(text).Ceil() + padding*2
+	height := (metrics.Ascent + metrics.Descent).Ceil() + padding*2
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	drawer.Dst = dst
+	drawer.Dot = fixed.P(padding, padding+metrics.Ascent.Ceil())
+	drawer.DrawString(text)
+	return dst, nil
+}
+
+// parseHexColor 解析 #RRGGBB 或 #RRGGBBAA 格式的颜色
+func parseHexColor(s string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 8 {
+		return color.NRGBA{}, errors.Errorf("颜色格式不正确: %s，应为 #RRGGBB 或 #RRGGBBAA", s)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/pudongping/wx-graph-crawl/backend/types"
+)
+
+func TestWatermark(t *testing.T) {
+	root := t.TempDir()
+	black := imaging.New(400, 300, color.Black)
+	for _, name := range []string{"1.png", "2.png"} {
+		if err := imaging.Save(black, filepath.Join(root, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logoPath := filepath.Join(t.TempDir(), "logo.png")
+	if err := imaging.Save(imaging.New(40, 20, color.NRGBA{R: 255, A: 255}), logoPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// 图片水印：左上角，宽度为图片的 1/4，边距为 10 像素
+	svc := NewWatermarkService(root, 2, types.WatermarkOptions{
+		LogoPath: logoPath, Position: types.WatermarkTopLeft, Margin: 0.025, Opacity: 1, Scale: 0.25,
+	})
+	results, err := svc.RunWatermark()
+	if err != nil || len(results) != 2 {
+		t.Fatalf("添加水印失败: %v, %+v", err, results)
+	}
+	img, err := imaging.Open(filepath.Join(root, "1.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, _, _, _ := img.At(15, 15).RGBA(); r>>8 != 255 {
+		t.Errorf("水印区域应该为红色，实际为 %v", img.At(15, 15))
+	}
+	if r, _, _, _ := img.At(5, 5).RGBA(); r != 0 {
+		t.Errorf("边距区域不应该有水印，实际为 %v", img.At(5, 5))
+	}
+	if r, _, _, _ := img.At(115, 65).RGBA(); r != 0 {
+		t.Errorf("水印 100x50 之外不应该有水印，实际为 %v", img.At(115, 65))
+	}
+
+	// 文字水印：默认右下角，白色文字
+	mark, err := renderTextWatermark("wx-graph-crawl", "", "#FFFFFF")
+	if err != nil {
+		t.Fatalf("渲染文字水印失败: %v", err)
+	}
+	res := ApplyWatermark(black, mark, types.WatermarkOptions{})
+	bright := 0
+	for y := 150; y < 300; y++ {
+		for x := 200; x < 400; x++ {
+			if r, _, _, _ := res.At(x, y).RGBA(); r>>8 > 128 {
+				bright++
+			}
+		}
+	}
+	if bright == 0 {
+		t.Error("右下角应该有文字水印")
+	}
+	if r, _, _, _ := res.At(10, 10).RGBA(); r != 0 {
+		t.Error("左上角不应该有文字水印")
+	}
+
+	// 内置字体不支持中文，没有可用的系统字体时返回错误
+	systemFonts := watermarkSystemFonts
+	watermarkSystemFonts = nil
+	defer func() { watermarkSystemFonts = systemFonts }()
+	if _, err = renderTextWatermark("一安未来", "", ""); err == nil {
+		t.Error("没有支持中文的字体时应该返回错误")
+	}
+	if err = ValidateWatermarkOptions(normalizeWatermarkOptions(types.WatermarkOptions{Text: "a", LogoPath: logoPath})); err == nil {
+		t.Error("同时设置文字和图片水印时应该返回错误")
+	}
+	if err = ValidateWatermarkOptions(normalizeWatermarkOptions(types.WatermarkOptions{Text: "a", TextColor: "white"})); err == nil {
+		t.Error("颜色格式不正确时应该返回错误")
+	}
+}
+
+func TestWatermarkFiles(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	black := imaging.New(400, 300, color.Black)
+	for _, name := range []string{"1/1.png", "1/2.gif", "_duplicates/3.png", "js/4.png"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := imaging.Save(black, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 1x1 的透明 webp 图片
+	webp := []byte("RIFF\x1a\x00\x00\x00WEBPVP8L\x0d\x00\x00\x00\x2f\x00\x00\x00\x10\x07\x10\x11\x11\x88\x88\xfe\x07\x00")
+	if err := os.WriteFile(filepath.Join(root, "1", "5.webp"), webp, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 水印图片放在图片目录中时不给自己添加水印
+	logoPath := filepath.Join(root, "logo.png")
+	if err := imaging.Save(imaging.New(40, 20, color.NRGBA{R: 255, A: 255}), logoPath); err != nil {
+		t.Fatal(err)
+	}
+
+	jobSvc := &CropJobService{DB: openTestDB(t)}
+	job, err := jobSvc.CreateJob(ctx, root, types.CropModeBackup, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := NewWatermarkService(root, 2, types.WatermarkOptions{LogoPath: logoPath, Opacity: 1})
+	svc.Mode, svc.JournalDir = types.CropModeBackup, job.JournalDir
+	results, err := svc.RunWatermark()
+	if err != nil {
+		t.Fatalf("添加水印失败: %v", err)
+	}
+	outputs := make(map[string]types.WatermarkResult)
+	for _, item := range results {
+		rel, _ := filepath.Rel(root, item.ImgPath)
+		outputs[filepath.ToSlash(rel)] = item
+	}
+	if len(outputs) != 3 || outputs["1/1.png"].Err != nil || !outputs["1/2.gif"].Skipped {
+		t.Fatalf("只应该处理文章目录中的图片，GIF 应该跳过: %+v", outputs)
+	}
+	webpRes := outputs["1/5.webp"]
+	if webpRes.Err != nil || webpRes.OutputPath != filepath.Join(root, "1", "5.jpg") {
+		t.Fatalf("webp 图片应该保存为 JPEG: %+v", webpRes)
+	}
+	if _, err = os.Stat(filepath.Join(root, "1", "5.webp")); !os.IsNotExist(err) {
+		t.Errorf("转换后应该删除 webp 原图: %v", err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(root, "1", "*.tmp")); len(matches) > 0 {
+		t.Errorf("不应该留下临时文件: %v", matches)
+	}
+	if img, err := imaging.Open(filepath.Join(root, "1", "1.png")); err != nil {
+		t.Fatal(err)
+	} else if r, _, _, _ := img.At(340, 260).RGBA(); r == 0 {
+		t.Error("1.png 应该添加了水印")
+	}
+
+	// 撤销后恢复原图，删除转换出的 JPEG
+	undo, err := jobSvc.UndoCrop(ctx, job.JobID)
+	if err != nil || undo.RestoredCount != 2 || undo.ErrContent != "" {
+		t.Fatalf("撤销添加水印失败: %+v, %v", undo, err)
+	}
+	if img, err := imaging.Open(filepath.Join(root, "1", "1.png")); err != nil {
+		t.Fatal(err)
+	} else if r, _, _, _ := img.At(340, 260).RGBA(); r != 0 {
+		t.Error("撤销后 1.png 应该恢复为原图")
+	}
+	if got, err := os.ReadFile(filepath.Join(root, "1", "5.webp")); err != nil || string(got) != string(webp) {
+		t.Errorf("撤销后应该恢复 webp 原图: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(root, "1", "5.jpg")); !os.IsNotExist(err) {
+		t.Errorf("撤销后应该删除转换出的 JPEG: %v", err)
+	}
+}
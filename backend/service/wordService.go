@@ -38,8 +38,8 @@ func (svc *WordService) GenerateWordForEachArticle(results []types.CrawlResult)
 			title = fmt.Sprintf("文章_%d", result.Number)
 		}
 
-		// 处理文件名中的非法字符
-		title = sanitizeWordFilename(title)
+		// 处理文件名中的非法字符，从专辑抓取时以“序号_标题”命名，与专辑页面顺序一致
+		title = articleBaseName(sanitizeWordFilename(title), result.AlbumIndex)
 
 		// 构建Word文档路径
 		wordFilePath := filepath.Join(svc.SavePath, fmt.Sprintf("%s.docx", title))
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"github.com/pudongping/wx-graph-crawl/backend/utils"
+	"go.uber.org/zap"
+)
+
+// dedupeWXArticleURLs 按地址本身的文章标识去重，保留每篇文章第一次出现的地址
+// 短链接在抓取前无法得到文章标识，和长链接指向同一篇文章时由抓取时的 CrawlerImgService.claimArticle 去重
+func dedupeWXArticleURLs(urls []string) []string {
+	seen := make(map[string]struct{}, len(urls))
+	result := make([]string, 0, len(urls))
+	for _, rawURL := range urls {
+		key := utils.WXArticleKey(rawURL)
+		if _, ok := seen[key]; ok {
+			zap.L().Info("跳过重复的文章地址", zap.String("url", rawURL), zap.String("key", key))
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, rawURL)
+	}
+	return result
+}
+
+// articleKeyFromHTML 返回文章标识，短链接从抓取到的文章页面中解析长链接参数，解析失败时使用短链接的标识
+func articleKeyFromHTML(rawURL, html string) string {
+	u, err := utils.NormalizeWXArticleURL(rawURL)
+	if err != nil {
+		return utils.WXArticleKey(rawURL)
+	}
+	if !u.IsShort() {
+		return u.Key
+	}
+	if resolved, err := utils.ResolveWXArticleURLFromHTML(u, html); err == nil {
+		return resolved.Key
+	}
+	return u.Key
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArticleKeyFromHTML(t *testing.T) {
+	html := `<script>
+  var biz = "MzIwMzQwODg3MQ==" || "";
+  var sn = "f21300108eeb95a8dca484cd3bc0a4c3" || "" || "";
+  var mid = "2247507554" || "" || "";
+  var idx = "2" || "" || "";
+</script>`
+	longURL := "https://mp.weixin.qq.com/s?__biz=MzIwMzQwODg3MQ==&mid=2247507554&idx=2&sn=f21300108eeb95a8dca484cd3bc0a4c3&chksm=abc#rd"
+	want := "wx:MzIwMzQwODg3MQ==_2247507554_2"
+
+	cases := map[string]struct {
+		url, html, want string
+	}{
+		"长链接不需要页面":  {longURL, "", want},
+		"短链接从页面解析":  {"https://mp.weixin.qq.com/s/w-lFW8AjuHRKSY74UIGwxQ", html, want},
+		"页面中没有文章信息": {"https://mp.weixin.qq.com/s/w-lFW8AjuHRKSY74UIGwxQ", "<html></html>", "wx-short:w-lFW8AjuHRKSY74UIGwxQ"},
+	}
+	for name, c := range cases {
+		if got := articleKeyFromHTML(c.url, c.html); got != c.want {
+			t.Errorf("%s: 文章标识为 %s，期望为 %s", name, got, c.want)
+		}
+	}
+}
+
+func TestDedupeWXArticleURLs(t *testing.T) {
+	urls := []string{
+		"https://mp.weixin.qq.com/s?__biz=MzIwMzQwODg3MQ==&mid=2247507554&idx=2&sn=f21300108eeb95a8dca484cd3bc0a4c3&chksm=abc#rd",
+		"http://mp.weixin.qq.com/s?__biz=MzIwMzQwODg3MQ==&mid=2247507554&idx=2&sn=f21300108eeb95a8dca484cd3bc0a4c3",
+		"https://mp.weixin.qq.com/s/w-lFW8AjuHRKSY74UIGwxQ",
+		"https://mp.weixin.qq.com/s/w-lFW8AjuHRKSY74UIGwxQ?scene=1",
+	}
+	got := dedupeWXArticleURLs(urls)
+	// 短链接不请求页面，和长链接指向同一篇文章时留到抓取时去重
+	want := []string{urls[0], urls[2]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("去重后的地址为 %v，期望为 %v", got, want)
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// XlsxService 处理Excel（xlsx）文件生成的服务
+// xlsx 和 docx 一样是 ZIP 格式，这里只生成包含单个工作表、使用内联字符串的最简工作簿
+type XlsxService struct {
+	SheetName string // 工作表名称
+}
+
+// NewXlsxService 创建一个新的Excel文件生成服务
+func NewXlsxService(sheetName string) *XlsxService {
+	// Excel 的工作表名称不能包含 \ / ? * [ ] : 等字符，且长度不能超过31个字符
+	sheetName = strings.Map(func(r rune) rune {
+		switch r {
+		case '\\', '/', '?', '*', '[', ']', ':':
+			return '_'
+		default:
+			return r
+		}
+	}, sheetName)
+	if runes := []rune(sheetName); len(runes) > 31 {
+		sheetName = string(runes[:31])
+	}
+	if sheetName == "" {
+		sheetName = "Sheet1"
+	}
+	return &XlsxService{
+		SheetName: sheetName,
+	}
+}
+
+// CreateWorkbook 将表头和数据行写入到 filePath 对应的xlsx文件中
+func (svc *XlsxService) CreateWorkbook(filePath string, header []string, rows [][]string) error {
+	// 确保保存目录存在
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return errors.Wrap(err, "创建保存目录失败")
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return errors.Wrap(err, "创建Excel文件失败")
+	}
+	defer file.Close()
+
+	zipWriter := zip.NewWriter(file)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", svc.contentTypesXml()},
+		{"_rels/.rels", svc.relsXml()},
+		{"xl/workbook.xml", svc.workbookXml()},
+		{"xl/_rels/workbook.xml.rels", svc.workbookRelsXml()},
+		{"xl/worksheets/sheet1.xml", svc.sheetXml(header, rows)},
+	}
+	for _, f := range files {
+		writer, err := zipWriter.Create(f.name)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("创建ZIP文件条目失败: %s", f.name))
+		}
+		if _, err = writer.Write([]byte(f.content)); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("写入ZIP文件条目失败: %s", f.name))
+		}
+	}
+
+	return errors.Wrap(zipWriter.Close(), "关闭Excel文件失败")
+}
+
+// 创建[Content_Types].xml文件内容
+func (svc *XlsxService) contentTypesXml() string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+  <Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+  <Default Extension="xml" ContentType="application/xml"/>
+  <Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+  <Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+}
+
+// 创建_rels/.rels文件内容
+func (svc *XlsxService) relsXml() string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+}
+
+// 创建xl/workbook.xml文件内容
+func (svc *XlsxService) workbookXml() string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets>
+    <sheet name="%s" sheetId="1" r:id="rId1"/>
+  </sheets>
+</workbook>`, escapeXML(svc.SheetName))
+}
+
+// 创建xl/_rels/workbook.xml.rels文件内容
+func (svc *XlsxService) workbookRelsXml() string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+}
+
+// 创建xl/worksheets/sheet1.xml文件内容，所有单元格都使用内联字符串
+func (svc *XlsxService) sheetXml(header []string, rows [][]string) string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	writeRow := func(rowNum int, cells []string) {
+		sb.WriteString(fmt.Sprintf(`<row r="%d">`, rowNum))
+		for i, cell := range cells {
+			sb.WriteString(fmt.Sprintf(`<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`,
+				xlsxColumnName(i), rowNum, escapeXML(cell)))
+		}
+		sb.WriteString(`</row>`)
+	}
+
+	writeRow(1, header)
+	for i, row := range rows {
+		writeRow(i+2, row)
+	}
+
+	sb.WriteString(`</sheetData></worksheet>`)
+	return sb.String()
+}
+
+// xlsxColumnName 将从0开始的列序号转换为Excel列名，如 0 => A，26 => AA
+func xlsxColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"archive/zip"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewXlsxServiceSheetName(t *testing.T) {
+	cases := map[string]string{
+		"干货分享集":                 "干货分享集",
+		`a\b/c?d*e[f]g:h`:       "a_b_c_d_e_f_g_h",
+		"":                      "Sheet1",
+		strings.Repeat("专", 40): strings.Repeat("专", 31),
+	}
+	for name, want := range cases {
+		if got := NewXlsxService(name).SheetName; got != want {
+			t.Errorf("%q 的工作表名称为 %q，期望为 %q", name, got, want)
+		}
+	}
+}
+
+func TestXlsxColumnName(t *testing.T) {
+	cases := map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"}
+	for index, want := range cases {
+		if got := xlsxColumnName(index); got != want {
+			t.Errorf("第 %d 列的列名为 %s，期望为 %s", index, got, want)
+		}
+	}
+}
+
+func TestCreateWorkbook(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "sub", "test.xlsx")
+	header := []string{"序号", "标题"}
+	rows := [][]string{{"1", "a & <b>"}, {"2", "  前后空格  "}}
+	if err := NewXlsxService("专辑").CreateWorkbook(filePath, header, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	names := make([]string, 0, len(reader.File))
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+	wantNames := []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("xlsx 文件中的条目为 %v", names)
+	}
+	if got := readXlsxRows(t, filePath); !reflect.DeepEqual(got, append([][]string{header}, rows...)) {
+		t.Errorf("工作表内容为 %q", got)
+	}
+}
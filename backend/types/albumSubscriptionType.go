@@ -0,0 +1,63 @@
+package types
+
+// AlbumSubscription 专辑订阅
+type AlbumSubscription struct {
+	ID              int64  `db:"id" json:"id"`                             // 自增主键
+	AlbumURL        string `db:"album_url" json:"album_url"`               // 专辑首页地址
+	Biz             string `db:"biz" json:"biz"`                           // 公众号的 __biz 标识
+	AlbumID         string `db:"album_id" json:"album_id"`                 // 专辑ID
+	Title           string `db:"title" json:"title"`                       // 专辑标题
+	NickName        string `db:"nick_name" json:"nick_name"`               // 公众号名称
+	IntervalMinutes int    `db:"interval_minutes" json:"interval_minutes"` // 同步间隔（分钟）
+	Enabled         bool   `db:"enabled" json:"enabled"`                   // 是否启用
+	CrawlExisting   bool   `db:"crawl_existing" json:"crawl_existing"`     // 首次同步时是否抓取专辑中已有的文章
+	LastSyncedAt    int64  `db:"last_synced_at" json:"last_synced_at"`     // 上次同步时间
+	NextSyncAt      int64  `db:"next_sync_at" json:"next_sync_at"`         // 下次同步时间
+	CreatedAt       int64  `db:"created_at" json:"created_at"`             // 创建时间
+	UpdatedAt       int64  `db:"updated_at" json:"updated_at"`             // 更新时间
+}
+
+// AlbumSubscriptionRunStatus 专辑订阅同步记录的状态
+type AlbumSubscriptionRunStatus string
+
+const (
+	AlbumSubscriptionRunSyncing  AlbumSubscriptionRunStatus = "syncing"   // 正在同步专辑文章
+	AlbumSubscriptionRunQueued   AlbumSubscriptionRunStatus = "queued"    // 发现新文章，等待抓取
+	AlbumSubscriptionRunCrawling AlbumSubscriptionRunStatus = "crawling"  // 正在抓取新文章
+	AlbumSubscriptionRunNoChange AlbumSubscriptionRunStatus = "no_change" // 没有新发布的文章
+	AlbumSubscriptionRunBaseline AlbumSubscriptionRunStatus = "baseline"  // 首次同步，只记录已有文章，不抓取
+	AlbumSubscriptionRunSuccess  AlbumSubscriptionRunStatus = "success"   // 新文章抓取完成
+	AlbumSubscriptionRunPartial  AlbumSubscriptionRunStatus = "partial"   // 部分新文章抓取失败，失败的文章下次同步时重新抓取
+	AlbumSubscriptionRunFailed   AlbumSubscriptionRunStatus = "failed"    // 同步或抓取失败
+)
+
+// AlbumSubscriptionRun 专辑订阅的一次同步记录
+type AlbumSubscriptionRun struct {
+	ID              int64                      `db:"id" json:"id"`                               // 自增主键
+	SubscriptionID  int64                      `db:"subscription_id" json:"subscription_id"`     // 订阅ID
+	Status          AlbumSubscriptionRunStatus `db:"status" json:"status"`                       // 状态
+	ArticleCount    int                        `db:"article_count" json:"article_count"`         // 本次同步获取到的文章数量
+	NewArticleCount int                        `db:"new_article_count" json:"new_article_count"` // 新发布的文章数量
+	CrawlImgCount   int64                      `db:"crawl_img_count" json:"crawl_img_count"`     // 抓取成功的图片数量
+	SavePath        string                     `db:"save_path" json:"save_path"`                 // 抓取内容的保存目录
+	ErrContent      string                     `db:"err_content" json:"err_content"`             // 错误信息
+	StartedAt       int64                      `db:"started_at" json:"started_at"`               // 开始时间
+	FinishedAt      int64                      `db:"finished_at" json:"finished_at"`             // 结束时间
+}
+
+type CreateAlbumSubscriptionRequest struct {
+	AlbumURL        string `json:"album_url"`        // 专辑首页地址
+	IntervalMinutes int    `json:"interval_minutes"` // 同步间隔（分钟），<=0 时使用默认值
+	CrawlExisting   bool   `json:"crawl_existing"`   // 首次同步时是否抓取专辑中已有的文章，默认只抓取之后新发布的文章
+}
+
+type UpdateAlbumSubscriptionRequest struct {
+	ID              int64 `json:"id"`               // 订阅ID
+	IntervalMinutes int   `json:"interval_minutes"` // 同步间隔（分钟），<=0 时不修改
+	Enabled         bool  `json:"enabled"`          // 是否启用
+}
+
+type ListAlbumSubscriptionRunsRequest struct {
+	SubscriptionID int64 `json:"subscription_id"` // 订阅ID，为0时查询全部订阅
+	Limit          int   `json:"limit"`           // 返回的记录数量，<=0 时默认返回50条
+}
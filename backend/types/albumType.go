@@ -2,8 +2,78 @@ package types
 
 // AlbumArticleInfo 表示专辑文章的完整信息，用于导出CSV和跟踪下载状态
 type AlbumArticleInfo struct {
-	Index  int    `json:"index"`  // 序号
-	Title  string `json:"title"`  // 文章标题
-	URL    string `json:"url"`    // 文章地址
-	Status string `json:"status"` // 下载状态（可后续扩展使用）
+	Index      int    `json:"index"`       // 专辑序号，与专辑页面中的顺序一致
+	Title      string `json:"title"`       // 文章标题
+	URL        string `json:"url"`         // 文章地址
+	Status     string `json:"status"`      // 下载状态（可后续扩展使用）
+	MsgID      string `json:"msgid"`       // 文章消息ID
+	ItemIdx    string `json:"itemidx"`     // 文章在当次群发中的位置
+	CreateTime int64  `json:"create_time"` // 发布时间（Unix时间戳，秒）
+	CoverURL   string `json:"cover_url"`   // 封面图片地址
+	ReadCount  int64  `json:"read_count"`  // 阅读数，-1 表示未知
+}
+
+// AlbumExportFormat 专辑文章列表的导出格式
+type AlbumExportFormat string
+
+const (
+	AlbumExportFormatCSV  AlbumExportFormat = "csv"
+	AlbumExportFormatJSON AlbumExportFormat = "json"
+	AlbumExportFormatXLSX AlbumExportFormat = "xlsx"
+)
+
+// AlbumTruncatedReason 专辑分页提前结束的原因
+type AlbumTruncatedReason string
+
+const (
+	AlbumTruncatedNone          AlbumTruncatedReason = ""               // 已获取全部文章
+	AlbumTruncatedMaxPages      AlbumTruncatedReason = "max_pages"      // 达到最大分页请求次数
+	AlbumTruncatedRequestFailed AlbumTruncatedReason = "request_failed" // 分页请求或解析失败
+	AlbumTruncatedRepeatedPage  AlbumTruncatedReason = "repeated_page"  // 接口返回了重复数据
+	AlbumTruncatedEmptyPage     AlbumTruncatedReason = "empty_page"     // 接口提示还有数据，但返回了空列表
+)
+
+// AlbumPagingOptions 专辑分页请求参数
+type AlbumPagingOptions struct {
+	PageSize       int   `json:"page_size"`       // 每次分页请求获取的文章数量，<=0 时使用默认值
+	IntervalMillis int64 `json:"interval_millis"` // 分页请求间隔（毫秒），<=0 时使用默认值
+	MaxPages       int   `json:"max_pages"`       // 最大分页请求次数，0表示不限制
+}
+
+// AlbumFetchResult 专辑文章获取结果
+type AlbumFetchResult struct {
+	AlbumTitle      string               `json:"album_title"`      // 专辑标题
+	NickName        string               `json:"nick_name"`        // 公众号名称
+	ArticleCount    int                  `json:"article_count"`    // 专辑首页声明的文章总数
+	IsNumbered      bool                 `json:"is_numbered"`      // 专辑是否显示序号
+	IsReverse       bool                 `json:"is_reverse"`       // 专辑是否倒序展示（最新的文章在最前面）
+	URLs            []string             `json:"urls"`             // 已去重的文章地址
+	Articles        []AlbumArticleInfo   `json:"articles"`         // 文章详细信息
+	PageCount       int                  `json:"page_count"`       // 本次发起的分页请求次数
+	Truncated       bool                 `json:"truncated"`        // 是否提前结束了分页
+	TruncatedReason AlbumTruncatedReason `json:"truncated_reason"` // 提前结束的原因
+	NextBeginMsgID  string               `json:"next_begin_msgid"` // 断点续传游标（包含 msgid 和已获取文章的展示位置），提前结束时可用于继续获取
+}
+
+type GetAlbumArticlesRequest struct {
+	AlbumURL   string             `json:"album_url"`   // 专辑首页地址
+	BeginMsgID string             `json:"begin_msgid"` // 断点续传游标，为空时从头开始获取
+	Paging     AlbumPagingOptions `json:"paging"`      // 分页参数
+}
+
+type GetAlbumArticlesResponse struct {
+	AlbumFetchResult
+	ErrContent  string `json:"err_content"`   // 错误信息
+	CastTimeStr string `json:"cast_time_str"` // 耗时字符串
+}
+
+type ExportAlbumArticlesRequest struct {
+	Album     AlbumFetchResult    `json:"album"`      // 获取到的专辑文章
+	Formats   []AlbumExportFormat `json:"formats"`    // 导出格式，为空时默认导出CSV
+	ExportDir string              `json:"export_dir"` // 导出目录，为空时使用偏好设置中的保存路径
+}
+
+type ExportAlbumArticlesResponse struct {
+	ExportDir string   `json:"export_dir"` // 实际使用的导出目录
+	FilePaths []string `json:"file_paths"` // 导出的文件路径
 }
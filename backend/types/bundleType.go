@@ -0,0 +1,49 @@
+package types
+
+// BundleCoverRule 选择发布包封面图片的规则
+type BundleCoverRule string
+
+const (
+	BundleCoverFirst    BundleCoverRule = "first"    // 第一张图片（默认）
+	BundleCoverLargest  BundleCoverRule = "largest"  // 分辨率最大的图片
+	BundleCoverPortrait BundleCoverRule = "portrait" // 第一张竖图，没有竖图时使用第一张图片
+)
+
+type BuildBundlesRequest struct {
+	ImgSavePath string          `json:"img_save_path"` // 图片保存目录，需要有抓取记录
+	OutputDir   string          `json:"output_dir"`    // 发布包的输出目录，为空时输出到图片保存目录下的 _bundles 中
+	ArticleKeys []string        `json:"article_keys"`  // 要制作发布包的文章标识，为空时制作全部文章
+	MaxImages   int             `json:"max_images"`    // 每个发布包最多的图片数量，0 表示使用默认值 20，不能超过 20
+	Hashtags    []string        `json:"hashtags"`      // 追加到文案末尾的话题，不需要带 #
+	CoverRule   BundleCoverRule `json:"cover_rule"`    // 封面选择规则，默认为 first
+	Zip         bool            `json:"zip"`           // 是否打包为 zip 文件，打包后删除发布包目录
+}
+
+// PublishBundle 一个发布包，一篇文章的图片超过最多数量时会拆分为多个发布包
+type PublishBundle struct {
+	Name       string `json:"name"`        // 发布包名称，如“3_标题”，拆分时为“3_标题_1”
+	Dir        string `json:"dir"`         // 发布包目录，打包为 zip 时为空
+	ZipPath    string `json:"zip_path"`    // zip 文件路径，没有打包时为空
+	ArticleURL string `json:"article_url"` // 原文链接
+	Title      string `json:"title"`
+	ImageCount int    `json:"image_count"`
+	CoverPath  string `json:"cover_path"` // 被选为封面的原图路径
+}
+
+// BundleArticle 可以制作发布包的文章
+type BundleArticle struct {
+	ArticleKey string `json:"article_key"`
+	URL        string `json:"url"`
+	Seq        int    `json:"seq"`
+	Title      string `json:"title"`
+	ImageCount int    `json:"image_count"` // 抓取记录中仍然存在的图片数量
+	CrawledAt  int64  `json:"crawled_at"`
+}
+
+type BuildBundlesResponse struct {
+	OutputDir         string          `json:"output_dir"`
+	Bundles           []PublishBundle `json:"bundles"`
+	SkippedImageCount int             `json:"skipped_image_count"` // 抓取记录中已经不存在的图片数量（如被删除的相似图片）
+	ErrContent        string          `json:"err_content"`
+	CastTimeStr       string          `json:"cast_time_str"`
+}
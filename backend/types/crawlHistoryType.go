@@ -0,0 +1,87 @@
+package types
+
+// CrawlRunStatus 抓取记录的状态
+type CrawlRunStatus string
+
+const (
+	CrawlRunSuccess CrawlRunStatus = "success" // 全部文章抓取成功
+	CrawlRunPartial CrawlRunStatus = "partial" // 部分文章抓取失败
+	CrawlRunFailed  CrawlRunStatus = "failed"  // 全部文章抓取失败，或抓取过程出错
+)
+
+// CrawlArticleStatus 一篇文章的抓取结果
+type CrawlArticleStatus string
+
+const (
+	CrawlArticleSuccess CrawlArticleStatus = "success"
+	CrawlArticleFailed  CrawlArticleStatus = "failed"
+)
+
+// CrawlRun 一次抓取的记录
+type CrawlRun struct {
+	ID                int64          `db:"id" json:"id"`                                   // 自增主键
+	Status            CrawlRunStatus `db:"status" json:"status"`                           // 状态
+	SavePath          string         `db:"save_path" json:"save_path"`                     // 图片保存路径
+	RequestedURLs     string         `db:"requested_urls" json:"-"`                        // 请求中的链接地址（JSON 数组）
+	Options           string         `db:"options" json:"-"`                               // 请求中除链接地址以外的参数（JSON）
+	URLCount          int            `db:"url_count" json:"url_count"`                     // 去重后抓取的文章数量
+	SuccessCount      int            `db:"success_count" json:"success_count"`             // 抓取成功的文章数量
+	FailedCount       int            `db:"failed_count" json:"failed_count"`               // 抓取失败的文章数量
+	ImgCount          int64          `db:"img_count" json:"img_count"`                     // 保存成功的图片数量
+	ProcessedImgCount int64          `db:"processed_img_count" json:"processed_img_count"` // 抓取后处理过的图片数量
+	ErrContent        string         `db:"err_content" json:"err_content"`                 // 错误信息
+	DurationMs        int64          `db:"duration_ms" json:"duration_ms"`                 // 耗时（毫秒）
+	StartedAt         int64          `db:"started_at" json:"started_at"`                   // 开始时间
+	FinishedAt        int64          `db:"finished_at" json:"finished_at"`                 // 结束时间
+}
+
+// CrawlRunArticle 一次抓取中一篇文章的结果
+type CrawlRunArticle struct {
+	ID         int64              `db:"id" json:"id"`
+	RunID      int64              `db:"run_id" json:"run_id"`
+	ArticleKey string             `db:"article_key" json:"article_key"` // 文章标识
+	URL        string             `db:"url" json:"url"`
+	Title      string             `db:"title" json:"title"`
+	Status     CrawlArticleStatus `db:"status" json:"status"`
+	ImgCount   int                `db:"img_count" json:"img_count"`     // 保存成功的图片数量
+	ImgDir     string             `db:"img_dir" json:"img_dir"`         // 图片目录
+	Files      string             `db:"files" json:"-"`                 // 生成的文件和目录（JSON 数组），删除记录时可以一起删除
+	ErrContent string             `db:"err_content" json:"err_content"` // 抓取失败的原因
+	CreatedAt  int64              `db:"created_at" json:"created_at"`
+}
+
+// CrawlRunFilter 查询抓取记录的条件，为空的条件不生效
+type CrawlRunFilter struct {
+	Status      CrawlRunStatus `json:"status"`       // 状态
+	Keyword     string         `json:"keyword"`      // 在文章链接和标题中搜索
+	StartedFrom int64          `json:"started_from"` // 开始时间不早于
+	StartedTo   int64          `json:"started_to"`   // 开始时间早于
+}
+
+// PageRequest 分页参数
+type PageRequest struct {
+	Page     int `json:"page"`      // 页码，从 1 开始
+	PageSize int `json:"page_size"` // 每页数量，<=0 时使用默认值
+}
+
+type ListCrawlRunsResponse struct {
+	Runs     []CrawlRun `json:"runs"`
+	Total    int        `json:"total"`
+	Page     int        `json:"page"`
+	PageSize int        `json:"page_size"`
+}
+
+// CrawlRunDetail 抓取记录的详情，Request 可以直接用于重新抓取
+type CrawlRunDetail struct {
+	CrawlRun
+	RequestedURLs []string          `json:"requested_urls"`
+	Request       CrawlingRequest   `json:"request"`
+	Articles      []CrawlRunArticle `json:"articles"`
+}
+
+type DeleteCrawlRunResponse struct {
+	ID           int64    `json:"id"`
+	DeletedFiles []string `json:"deleted_files"` // 删除的文件和目录
+	KeptFiles    []string `json:"kept_files"`    // 被其他抓取记录使用或不在图片保存路径中而没有删除的文件
+	ErrContent   string   `json:"err_content"`
+}
@@ -0,0 +1,21 @@
+package types
+
+// CrawlManifest 图片保存目录中的抓取记录，保存每篇文章的标题、文案和图片顺序，供生成发布包等后续处理使用
+type CrawlManifest struct {
+	Version   int                    `json:"version"`
+	UpdatedAt int64                  `json:"updated_at"`
+	Articles  []CrawlManifestArticle `json:"articles"`
+}
+
+// CrawlManifestArticle 一篇文章的抓取记录，路径都是相对于图片保存目录的路径
+type CrawlManifestArticle struct {
+	ArticleKey  string   `json:"article_key"` // 文章标识
+	URL         string   `json:"url"`         // 文章链接
+	Seq         int      `json:"seq"`         // 文章序号，从专辑抓取时为专辑序号
+	Title       string   `json:"title"`       // 文章标题
+	Description string   `json:"description"` // 文章描述
+	Content     string   `json:"content"`     // 提取出的正文文案
+	ImgDir      string   `json:"img_dir"`     // 图片目录
+	Images      []string `json:"images"`      // 图片路径，按文章中的顺序排列
+	CrawledAt   int64    `json:"crawled_at"`  // 抓取时间
+}
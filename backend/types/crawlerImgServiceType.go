@@ -2,10 +2,16 @@ package types
 
 type CrawlResult struct {
 	URL                string   // 需要被抓取的原始链接地址
+	ArticleKey         string   // 文章标识，短链接抓取成功后为解析出的长链接标识
 	Number             int      // 当前子协程的编号
 	Err                error    // 抓取过程中出现的错误
 	Title              string   // 文章标题
 	Html               string   // 链接地址对应的抓取内容
-	ImgSavePathSuccess []string // 图片存储的硬盘路径地址
+	ImgSavePathSuccess []string // 文章中的图片链接地址
+	ImgLocalPaths      []string // 下载成功的图片保存路径，按文章中的顺序排列
+	ImgDir             string   // 文章图片的保存目录
+	Description        string   // 文章描述
+	ArticleText        string   // 提取出的正文文案
 	WriteContent       string   // 需要被写入的文字内容
+	AlbumIndex         int      // 专辑序号，不是从专辑抓取时为0
 }
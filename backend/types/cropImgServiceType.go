@@ -1,6 +1,92 @@
 package types
 
 type CropResult struct {
-	ImgPath string // 图片存储的硬盘路径地址
-	Err     error  // 裁剪过程中出现的错误
+	ImgPath      string // 图片存储的硬盘路径地址
+	OutputPath   string // 裁剪后的图片保存路径，原地裁剪时与 ImgPath 相同
+	Err          error  // 裁剪过程中出现的错误
+	BottomPixel  int    // 实际裁掉的底部高度（像素）
+	AutoDetected bool   // 底部高度是否为自动识别出的水印条高度，为 false 时使用的是设置的值
+}
+
+// CropMode 裁剪后图片的保存方式
+type CropMode string
+
+const (
+	CropModeBackup    CropMode = "backup"     // 原地裁剪，原图备份到 .wxgc_backup 目录中，可以撤销（默认）
+	CropModeOutputDir CropMode = "output_dir" // 裁剪后的图片保存到输出目录中并保持原来的目录结构，原图不变
+	CropModeOverwrite CropMode = "overwrite"  // 直接覆盖原图，无法撤销
+)
+
+// CropJob 一次裁剪任务，用于撤销裁剪
+type CropJob struct {
+	ID         int64    `db:"id" json:"id"`
+	JobID      string   `db:"job_id" json:"job_id"`           // 裁剪任务ID
+	RootDir    string   `db:"root_dir" json:"root_dir"`       // 裁剪的图片目录
+	Mode       CropMode `db:"mode" json:"mode"`               // 保存方式
+	OutputDir  string   `db:"output_dir" json:"output_dir"`   // 输出目录，保存方式为 output_dir 时才有
+	JournalDir string   `db:"journal_dir" json:"journal_dir"` // 裁剪日志和原图备份所在的目录
+	ImageCount int64    `db:"image_count" json:"image_count"` // 裁剪成功的图片数量
+	CreatedAt  int64    `db:"created_at" json:"created_at"`
+	UndoneAt   int64    `db:"undone_at" json:"undone_at"` // 撤销时间，0表示没有撤销
+}
+
+// CropJournalEntry 裁剪日志中的一行，在覆盖原图或写入输出文件之前记录
+type CropJournalEntry struct {
+	ImgPath    string `json:"img_path"`              // 原图路径
+	BackupPath string `json:"backup_path,omitempty"` // 原图备份路径
+	OutputPath string `json:"output_path,omitempty"` // 输出文件路径
+}
+
+// CropPreviewImage 预览图片
+type CropPreviewImage struct {
+	Width   int    `json:"width"`    // 图片宽度（像素）
+	Height  int    `json:"height"`   // 图片高度（像素）
+	DataURI string `json:"data_uri"` // base64 编码的缩略图，可以直接作为 img 的 src
+}
+
+// CropPreviewItem 一张图片的裁剪预览
+type CropPreviewItem struct {
+	ImgPath      string           `json:"img_path"`      // 图片路径
+	Before       CropPreviewImage `json:"before"`        // 裁剪前
+	After        CropPreviewImage `json:"after"`         // 裁剪后
+	BottomPixel  int              `json:"bottom_pixel"`  // 实际裁掉的底部高度（像素）
+	AutoDetected bool             `json:"auto_detected"` // 底部高度是否为自动识别出的水印条高度
+	Err          string           `json:"err"`           // 预览失败的原因
+}
+
+// CropUnit 裁剪边距的单位
+type CropUnit string
+
+const (
+	CropUnitPixel   CropUnit = "px"      // 像素
+	CropUnitPercent CropUnit = "percent" // 占图片宽度或高度的百分比
+)
+
+// CropAnchor 按比例裁剪时保留的位置
+type CropAnchor string
+
+const (
+	CropAnchorCenter      CropAnchor = "center"
+	CropAnchorTop         CropAnchor = "top"
+	CropAnchorBottom      CropAnchor = "bottom"
+	CropAnchorLeft        CropAnchor = "left"
+	CropAnchorRight       CropAnchor = "right"
+	CropAnchorTopLeft     CropAnchor = "top_left"
+	CropAnchorTopRight    CropAnchor = "top_right"
+	CropAnchorBottomLeft  CropAnchor = "bottom_left"
+	CropAnchorBottomRight CropAnchor = "bottom_right"
+)
+
+// CropGeometry 裁剪方式，依次执行：裁掉四边 -> 按比例裁剪 -> 缩小到最大宽高以内
+type CropGeometry struct {
+	Top         float64    `json:"top"`          // 裁掉顶部的大小
+	Bottom      float64    `json:"bottom"`       // 裁掉底部的大小
+	Left        float64    `json:"left"`         // 裁掉左侧的大小
+	Right       float64    `json:"right"`        // 裁掉右侧的大小
+	Unit        CropUnit   `json:"unit"`         // 四边大小的单位，默认为像素
+	AutoBottom  bool       `json:"auto_bottom"`  // 自动识别底部的水印条作为底部裁剪高度，无法识别时使用 Bottom
+	AspectRatio string     `json:"aspect_ratio"` // 目标宽高比，如 3:4、1:1、9:16，为空时不按比例裁剪
+	Anchor      CropAnchor `json:"anchor"`       // 按比例裁剪时保留的位置，默认居中
+	MaxWidth    int        `json:"max_width"`    // 最大宽度，超过时等比缩小，0表示不限制
+	MaxHeight   int        `json:"max_height"`   // 最大高度，超过时等比缩小，0表示不限制
 }
@@ -0,0 +1,113 @@
+package types
+
+import "encoding/json"
+
+// DataExportFormat 导出文件的格式
+type DataExportFormat string
+
+const (
+	DataExportJSON DataExportFormat = "json" // 一个 JSON 文件（默认）
+	DataExportZip  DataExportFormat = "zip"  // 压缩后的 JSON 文件，数据较多时使用
+)
+
+// DataImportMode 导入时如何处理本机已有的数据
+type DataImportMode string
+
+const (
+	DataImportMerge   DataImportMode = "merge"   // 合并：保留本机已有的数据，只添加本机没有的（默认）
+	DataImportReplace DataImportMode = "replace" // 替换：用导入的数据替换本机的偏好设置、专辑订阅、同步记录和抓取记录
+)
+
+// DataExportBundle 导出文件的内容，用于把设置和数据迁移到另一台电脑
+type DataExportBundle struct {
+	FormatVersion  int                    `json:"format_version"`  // 导出文件的格式版本
+	SchemaVersion  int                    `json:"schema_version"`  // 导出时的数据库版本
+	ExportedAt     int64                  `json:"exported_at"`     // 导出时间
+	SaveImgPath    string                 `json:"save_img_path"`   // 导出时的图片保存目录，导入时用于替换路径
+	Preferences    json.RawMessage        `json:"preferences"`     // 偏好设置，没有设置时为 null
+	Subscriptions  []ExportedSubscription `json:"subscriptions"`   // 专辑订阅及已同步的文章
+	CrawlHistory   []AlbumSubscriptionRun `json:"crawl_history"`   // 专辑订阅的同步记录
+	CrawlRuns      []ExportedCrawlRun     `json:"crawl_runs"`      // 抓取记录及每篇文章的结果，格式版本 2 开始包含
+	FailureRecords []FailureRecord        `json:"failure_records"` // 抓取失败记录
+}
+
+// ExportedSubscription 导出的专辑订阅，ID 只用于关联同步记录，导入时会重新分配
+type ExportedSubscription struct {
+	AlbumSubscription
+	Articles []SubscriptionArticle `json:"articles"`
+}
+
+// SubscriptionArticle 专辑订阅中已同步过的文章
+type SubscriptionArticle struct {
+	ID             int64  `db:"id" json:"-"`
+	SubscriptionID int64  `db:"subscription_id" json:"-"`
+	ArticleKey     string `db:"article_key" json:"article_key"`
+	URL            string `db:"url" json:"url"`
+	MsgID          string `db:"msgid" json:"msgid"`
+	Title          string `db:"title" json:"title"`
+	CreatedAt      int64  `db:"created_at" json:"created_at"`
+}
+
+// ExportedCrawlRun 导出的抓取记录，ID 只在导出文件中使用，导入时会重新分配
+type ExportedCrawlRun struct {
+	CrawlRun
+	RequestedURLs []string                  `json:"requested_urls"` // 请求中的链接地址
+	Options       json.RawMessage           `json:"options"`        // 请求中除链接地址以外的参数
+	Articles      []ExportedCrawlRunArticle `json:"articles"`
+}
+
+// ExportedCrawlRunArticle 导出的文章抓取结果
+type ExportedCrawlRunArticle struct {
+	CrawlRunArticle
+	Files []string `json:"files"` // 生成的文件和目录
+}
+
+// FailureRecord 图片保存目录 failed_downloads 中的一条抓取失败记录
+type FailureRecord struct {
+	File  string `json:"file"`  // 记录所在的 CSV 文件名
+	Time  string `json:"time"`  // 失败时间
+	URL   string `json:"url"`   // 失败的链接地址
+	Error string `json:"error"` // 错误信息
+}
+
+type ExportDataRequest struct {
+	Path        string           `json:"path"`          // 导出文件的保存路径
+	Format      DataExportFormat `json:"format"`        // 导出格式，默认为 json
+	SaveImgPath string           `json:"save_img_path"` // 读取失败记录的图片保存目录，为空时使用偏好设置中的目录
+}
+
+type ExportDataResponse struct {
+	Path              string `json:"path"`
+	SubscriptionCount int    `json:"subscription_count"`
+	CrawlHistoryCount int    `json:"crawl_history_count"`
+	CrawlRunCount     int    `json:"crawl_run_count"`
+	FailureCount      int    `json:"failure_count"`
+	CastTimeStr       string `json:"cast_time_str"`
+}
+
+type ImportDataRequest struct {
+	Path        string            `json:"path"`          // 导出文件的路径，支持 .json 和 .zip
+	Mode        DataImportMode    `json:"mode"`          // 导入方式，默认为 merge
+	SaveImgPath string            `json:"save_img_path"` // 本机的图片保存目录，导出文件中的目录在本机不存在时替换为此目录，为空时使用本机偏好设置中的目录
+	PathRemap   map[string]string `json:"path_remap"`    // 指定的路径替换，key 为导出文件中的目录前缀，value 为本机的目录
+}
+
+type ImportDataResponse struct {
+	Mode              DataImportMode `json:"mode"`
+	PreferencesSaved  bool           `json:"preferences_saved"` // 是否导入了偏好设置，合并时本机已有偏好设置则不导入
+	SubscriptionCount int            `json:"subscription_count"`
+	ArticleCount      int            `json:"article_count"`
+	CrawlHistoryCount int            `json:"crawl_history_count"`
+	CrawlRunCount     int            `json:"crawl_run_count"`
+	FailureCount      int            `json:"failure_count"`
+	RemappedPaths     []PathRemap    `json:"remapped_paths"` // 被替换的路径
+	MissingPaths      []string       `json:"missing_paths"`  // 本机不存在且无法替换的路径
+	Warnings          []string       `json:"warnings"`
+	CastTimeStr       string         `json:"cast_time_str"`
+}
+
+// PathRemap 一个被替换的路径
+type PathRemap struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
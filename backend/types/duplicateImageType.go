@@ -0,0 +1,72 @@
+package types
+
+// ImageHashAlgorithm 感知哈希算法
+type ImageHashAlgorithm string
+
+const (
+	ImageHashAHash ImageHashAlgorithm = "ahash" // 均值哈希，速度最快，对亮度变化敏感
+	ImageHashDHash ImageHashAlgorithm = "dhash" // 差异哈希，对缩放和压缩比较稳定
+	ImageHashPHash ImageHashAlgorithm = "phash" // 感知哈希，最准确（默认）
+)
+
+// DuplicateImageAction 找到相似图片后的处理方式
+type DuplicateImageAction string
+
+const (
+	DuplicateImageReport DuplicateImageAction = "report" // 只列出相似图片，不修改文件（默认）
+	DuplicateImageDelete DuplicateImageAction = "delete" // 删除每组中保留图片以外的图片
+	DuplicateImageMove   DuplicateImageAction = "move"   // 将每组中保留图片以外的图片移动到指定目录，保持原来的目录结构
+)
+
+// ImageHash 图片的感知哈希，文件大小和修改时间不变时直接使用保存的哈希
+type ImageHash struct {
+	ID        int64  `db:"id" json:"id"`
+	Path      string `db:"path" json:"path"`         // 图片路径
+	Size      int64  `db:"size" json:"size"`         // 文件大小（字节）
+	ModTime   int64  `db:"mod_time" json:"mod_time"` // 文件修改时间（纳秒时间戳）
+	Width     int    `db:"width" json:"width"`
+	Height    int    `db:"height" json:"height"`
+	AHash     string `db:"ahash" json:"ahash"` // 16位十六进制
+	DHash     string `db:"dhash" json:"dhash"`
+	PHash     string `db:"phash" json:"phash"`
+	CreatedAt int64  `db:"created_at" json:"created_at"`
+	UpdatedAt int64  `db:"updated_at" json:"updated_at"`
+}
+
+type FindDuplicateImagesRequest struct {
+	ImgSavePath string               `json:"img_save_path"` // 要检查的图片目录
+	Algorithm   ImageHashAlgorithm   `json:"algorithm"`     // 哈希算法，默认为 phash
+	Threshold   int                  `json:"threshold"`     // 汉明距离不大于此值时视为相似，0 表示使用默认值
+	Action      DuplicateImageAction `json:"action"`        // 处理方式，默认为 report
+	MoveDir     string               `json:"move_dir"`      // 处理方式为 move 时的目标目录，为空时移动到图片目录下的 _duplicates 中
+}
+
+// DuplicateImage 相似图片组中的一张图片
+type DuplicateImage struct {
+	Path     string `json:"path"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Size     int64  `json:"size"`
+	Distance int    `json:"distance"` // 与保留图片的汉明距离
+	MovedTo  string `json:"moved_to"` // 移动后的路径，处理方式为 move 时才有
+}
+
+// DuplicateImageGroup 一组相似图片，保留分辨率最大的一张
+type DuplicateImageGroup struct {
+	Keep       DuplicateImage   `json:"keep"`
+	Duplicates []DuplicateImage `json:"duplicates"`
+}
+
+type FindDuplicateImagesResponse struct {
+	ImgPath        string                `json:"img_path"`
+	Algorithm      ImageHashAlgorithm    `json:"algorithm"`
+	Threshold      int                   `json:"threshold"`
+	Action         DuplicateImageAction  `json:"action"`
+	ScannedCount   int                   `json:"scanned_count"`   // 检查的图片数量
+	HashedCount    int                   `json:"hashed_count"`    // 本次新计算哈希的图片数量，其余使用保存的哈希
+	Groups         []DuplicateImageGroup `json:"groups"`          // 相似图片组
+	DuplicateCount int                   `json:"duplicate_count"` // 可以删除或移动的相似图片数量
+	HandledCount   int                   `json:"handled_count"`   // 已删除或移动的图片数量
+	ErrContent     string                `json:"err_content"`
+	CastTimeStr    string                `json:"cast_time_str"`
+}
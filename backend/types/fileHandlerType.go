@@ -1,6 +1,39 @@
 package types
 
+// URLImportFormat 导入文件的格式
+type URLImportFormat string
+
+const (
+	URLImportFormatText     URLImportFormat = "txt"      // 每行一个URL的文本文件
+	URLImportFormatCSV      URLImportFormat = "csv"      // 专辑导出的CSV文件，或包含URL列的CSV文件
+	URLImportFormatJSON     URLImportFormat = "json"     // URL字符串数组，或包含 url/link/href 字段的对象
+	URLImportFormatHTML     URLImportFormat = "html"     // 浏览器导出的书签文件，或包含链接的HTML文档
+	URLImportFormatMarkdown URLImportFormat = "markdown" // 包含链接的Markdown文档
+)
+
+// URLRejectReason URL被拒绝导入的原因
+type URLRejectReason string
+
+const (
+	URLRejectNotHTTP   URLRejectReason = "not_http"      // 不是 http/https 地址
+	URLRejectInvalid   URLRejectReason = "invalid_url"   // 地址格式不正确
+	URLRejectNotWXURL  URLRejectReason = "not_wx_url"    // 不是微信公众号文章地址
+	URLRejectDuplicate URLRejectReason = "duplicate_url" // 与前面已导入的地址重复
+)
+
+// ImportedURL 从文件中读取到的单个URL
+type ImportedURL struct {
+	URL    string          `json:"url"`    // 地址
+	Title  string          `json:"title"`  // 链接文字、书签名称或文章标题
+	Index  int             `json:"index"`  // 专辑序号，只有从专辑导出文件中导入时才有
+	Line   int             `json:"line"`   // 在文件中的行号，无法确定时为0
+	Reason URLRejectReason `json:"reason"` // 被拒绝或重复的原因
+}
+
 type SelectFileResponse struct {
-	FilePath  string   `json:"file_path"`  // 选择的文件路径
-	ValidURLs []string `json:"valid_urls"` // 从文件中读取的有效URL列表
+	FilePath   string          `json:"file_path"`  // 选择的文件路径
+	Format     URLImportFormat `json:"format"`     // 识别出的文件格式
+	Accepted   []ImportedURL   `json:"accepted"`   // 可以抓取的URL
+	Rejected   []ImportedURL   `json:"rejected"`   // 被拒绝的URL及原因
+	Duplicates []ImportedURL   `json:"duplicates"` // 重复的URL
 }
@@ -0,0 +1,11 @@
+package types
+
+// ImageFileFilter 裁剪和打乱图片时的文件过滤条件
+// 匹配规则与 path.Match 相同，不区分大小写，既可以匹配文件名（如 *.jpeg），也可以匹配相对于图片目录的路径（如 1/*.jpeg）
+type ImageFileFilter struct {
+	Include           []string `json:"include"`             // 只处理匹配的文件，为空时只处理常见格式的图片
+	Exclude           []string `json:"exclude"`             // 跳过匹配的文件和目录
+	NoDefaultExcludes bool     `json:"no_default_excludes"` // 不跳过抓取时生成的 js、css、failed_downloads、content 目录和 html 文件
+	MaxDepth          int      `json:"max_depth"`           // 最多处理几层目录中的文件，1 表示只处理图片目录下的文件，0 表示不限制
+	Dirs              []string `json:"dirs"`                // 只处理这些文章目录，可以是绝对路径或相对于图片目录的路径，为空时处理整个图片目录
+}
@@ -1,40 +1,121 @@
 package types
 
 type CrawlingRequest struct {
-	ImgSavePath    string   `json:"img_save_path"`   // 图片保存路径
-	ImgUrls        []string `json:"img_urls"`        // 图片链接地址
-	TimeoutSeconds int64    `json:"timeout_seconds"` // 下载超时时间
+	ImgSavePath    string                `json:"img_save_path"`   // 图片保存路径
+	ImgUrls        []string              `json:"img_urls"`        // 图片链接地址
+	TimeoutSeconds int64                 `json:"timeout_seconds"` // 下载超时时间
+	AlbumArticles  []AlbumArticleInfo    `json:"album_articles"`  // 从专辑获取的文章，抓取时使用专辑序号命名文件
+	PostProcess    *ImagePipelineOptions `json:"post_process"`    // 抓取后对图片的处理，为空时使用偏好设置中的处理步骤
 }
 
 type CrawlingResponse struct {
-	TextContentSaveDir  string `json:"text_content_save_dir"`  // 文字内容保存目录
-	TextContentSavePath string `json:"text_content_save_path"` // 文字内容保存路径
-	WordDocsSavePath    string `json:"word_docs_save_path"`    // Word文档保存路径
-	CrawlUrlCount       int64  `json:"crawl_url_count"`        // 抓取的链接地址数量
-	CrawlImgCount       int64  `json:"crawl_img_count"`        // 抓取成功并保存成功的图片数量
-	WordDocsCount       int64  `json:"word_docs_count"`        // 生成的Word文档数量
-	ErrContent          string `json:"err_content"`            // 错误信息
-	CastTimeStr         string `json:"cast_time_str"`          // 耗时字符串
+	TextContentSaveDir  string   `json:"text_content_save_dir"`  // 文字内容保存目录
+	TextContentSavePath string   `json:"text_content_save_path"` // 文字内容保存路径
+	WordDocsSavePath    string   `json:"word_docs_save_path"`    // Word文档保存路径
+	CrawlUrlCount       int64    `json:"crawl_url_count"`        // 抓取的链接地址数量
+	CrawlImgCount       int64    `json:"crawl_img_count"`        // 抓取成功并保存成功的图片数量
+	WordDocsCount       int64    `json:"word_docs_count"`        // 生成的Word文档数量
+	ProcessedImgCount   int64    `json:"processed_img_count"`    // 抓取后处理过的图片数量
+	SucceededURLs       []string `json:"succeeded_urls"`         // 抓取成功的文章地址
+	ErrContent          string   `json:"err_content"`            // 错误信息
+	CastTimeStr         string   `json:"cast_time_str"`          // 耗时字符串
 }
 
 type CroppingRequest struct {
 	ImgSavePath string `json:"img_save_path"` // 图片保存路径
-	BottomPixel int    `json:"bottom_pixel"`  // 裁剪图片底部像素
+	BottomPixel int    `json:"bottom_pixel"`  // 裁剪图片底部像素，没有设置其他裁剪方式时使用（默认方式）
+	CropGeometry
+	Mode      CropMode        `json:"mode"`       // 保存方式，默认为 backup
+	OutputDir string          `json:"output_dir"` // 输出目录，保存方式为 output_dir 时必填
+	Filter    ImageFileFilter `json:"filter"`     // 要裁剪的文件范围
 }
 
 type CroppingResponse struct {
 	CropImgPath  string `json:"crop_img_path"`  // 裁剪图片保存路径
+	JobID        string `json:"job_id"`         // 裁剪任务ID，可以用来撤销裁剪，直接覆盖原图时为空
+	BackupDir    string `json:"backup_dir"`     // 裁剪日志和原图备份所在的目录
 	CropImgCount int64  `json:"crop_img_count"` // 裁剪成功的图片数量
+	AutoDetected int64  `json:"auto_detected"`  // 自动识别出水印条的图片数量
 	ErrContent   string `json:"err_content"`    // 错误信息
 	CastTimeStr  string `json:"cast_time_str"`  // 耗时字符串
 }
 
+type PreviewCropRequest struct {
+	CroppingRequest     // 裁剪方式，与实际裁剪使用的参数相同
+	Limit           int `json:"limit"`          // 预览目录时预览前几张图片，默认1张
+	ThumbnailSize   int `json:"thumbnail_size"` // 缩略图的最大宽高（像素）
+}
+
+type PreviewCropResponse struct {
+	Items []CropPreviewItem `json:"items"` // 每张图片的预览
+}
+
+type UndoCropResponse struct {
+	JobID         string `json:"job_id"`         // 裁剪任务ID
+	RestoredCount int64  `json:"restored_count"` // 恢复的原图数量，输出目录模式下为删除的输出文件数量
+	ErrContent    string `json:"err_content"`    // 错误信息
+}
+
+type ImagePipelineRequest struct {
+	ImgSavePath string               `json:"img_save_path"` // 图片目录
+	Options     ImagePipelineOptions `json:"options"`       // 处理步骤
+	Mode        CropMode             `json:"mode"`          // 保存方式，默认为 backup，支持 backup 和 overwrite
+	Filter      ImageFileFilter      `json:"filter"`        // 要处理的文件范围
+}
+
+type ImagePipelineResponse struct {
+	ImgPath        string `json:"img_path"`        // 图片目录
+	JobID          string `json:"job_id"`          // 任务ID，可以和裁剪任务一样撤销，直接覆盖原图或抓取后自动处理时为空
+	BackupDir      string `json:"backup_dir"`      // 原图备份所在的目录
+	ProcessedCount int64  `json:"processed_count"` // 处理成功的图片数量
+	SkippedCount   int64  `json:"skipped_count"`   // 不需要处理的图片数量
+	BytesBefore    int64  `json:"bytes_before"`    // 处理前的图片总大小
+	BytesAfter     int64  `json:"bytes_after"`     // 处理后的图片总大小
+	ErrContent     string `json:"err_content"`     // 错误信息
+	CastTimeStr    string `json:"cast_time_str"`   // 耗时字符串
+}
+
+type WatermarkingRequest struct {
+	ImgSavePath string           `json:"img_save_path"` // 图片目录
+	Options     WatermarkOptions `json:"options"`       // 水印设置
+	Mode        CropMode         `json:"mode"`          // 保存方式，默认为 backup，支持 backup 和 overwrite
+}
+
+type WatermarkingResponse struct {
+	ImgPath           string `json:"img_path"`            // 图片目录
+	JobID             string `json:"job_id"`              // 任务ID，可以和裁剪任务一样撤销，直接覆盖原图时为空
+	BackupDir         string `json:"backup_dir"`          // 原图备份所在的目录
+	WatermarkImgCount int64  `json:"watermark_img_count"` // 添加水印成功的图片数量
+	SkippedImgCount   int64  `json:"skipped_img_count"`   // 跳过的图片数量，如 GIF 动图
+	ErrContent        string `json:"err_content"`         // 错误信息
+	CastTimeStr       string `json:"cast_time_str"`       // 耗时字符串
+}
+
 type ShufflingRequest struct {
-	ImgSavePath string `json:"img_save_path"` // 图片保存路径
-	MaxNumImage int    `json:"max_num_image"` // 当一个目录中的图片超过多少张时，开始拆分目录
+	ImgSavePath      string               `json:"img_save_path"`      // 图片保存路径
+	MaxNumImage      int                  `json:"max_num_image"`      // 当一个目录中的图片超过多少张时，开始拆分目录
+	Order            ShuffleOrder         `json:"order"`              // 排列方式，默认为 random
+	Seed             int64                `json:"seed"`               // 随机种子，0 表示随机生成
+	SplitStrategy    ShuffleSplitStrategy `json:"split_strategy"`     // 拆分方式，默认为 balanced
+	SplitDirTemplate string               `json:"split_dir_template"` // 拆分后的子目录名模板，默认为 {count}_{index}
+	Filter           ImageFileFilter      `json:"filter"`             // 要打乱的文件范围
+	DryRun           bool                 `json:"dry_run"`            // 只返回移动计划，不移动图片
 }
 
 type ShufflingResponse struct {
-	ShuffleImgPath string `json:"shuffle_img_path"` // 打乱的图片路径
-	CastTimeStr    string `json:"cast_time_str"`    // 耗时字符串
+	ShuffleImgPath string        `json:"shuffle_img_path"` // 打乱的图片路径
+	DryRun         bool          `json:"dry_run"`          // 是否只返回了移动计划
+	JobID          string        `json:"job_id"`           // 打乱任务ID
+	Order          ShuffleOrder  `json:"order"`            // 排列方式
+	Seed           int64         `json:"seed"`             // 使用的随机种子，用于重现打乱结果
+	JournalPath    string        `json:"journal_path"`     // 打乱日志路径，撤销打乱时使用
+	MovedCount     int           `json:"moved_count"`      // 移动的图片数量
+	Moves          []ShuffleMove `json:"moves"`            // 移动记录，DryRun 时为计划的移动
+	CastTimeStr    string        `json:"cast_time_str"`    // 耗时字符串
+}
+
+type UndoShuffleResponse struct {
+	JournalPath   string `json:"journal_path"`   // 打乱日志路径
+	RestoredCount int    `json:"restored_count"` // 恢复的图片数量
+	CastTimeStr   string `json:"cast_time_str"`  // 耗时字符串
 }
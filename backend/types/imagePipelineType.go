@@ -0,0 +1,44 @@
+package types
+
+// ImageFormat 图片格式
+type ImageFormat string
+
+const (
+	ImageFormatJPEG ImageFormat = "jpeg"
+	ImageFormatPNG  ImageFormat = "png"
+	ImageFormatWebP ImageFormat = "webp"
+	ImageFormatGIF  ImageFormat = "gif"
+)
+
+// PNGCompression PNG 的压缩级别
+type PNGCompression string
+
+const (
+	PNGCompressionDefault PNGCompression = "default"          // 默认压缩
+	PNGCompressionNone    PNGCompression = "no_compression"   // 不压缩
+	PNGCompressionSpeed   PNGCompression = "best_speed"       // 压缩速度优先
+	PNGCompressionBest    PNGCompression = "best_compression" // 压缩率优先
+)
+
+// ImagePipelineOptions 图片处理步骤，未设置的步骤不执行，依次执行：自动旋转 -> 缩小 -> 转换格式 -> 重新压缩
+// 只要有一个步骤修改了图片，保存时就会重新编码，原图中的 EXIF 等元数据都不会保留
+type ImagePipelineOptions struct {
+	MaxDimension   int            `json:"max_dimension"`   // 最长边的最大像素，超过时等比缩小，0表示不限制
+	JPEGQuality    int            `json:"jpeg_quality"`    // JPEG 质量（1-100），设置后会重新压缩 JPEG 图片，0表示不重新压缩
+	PNGCompression PNGCompression `json:"png_compression"` // PNG 压缩级别，设置后会重新压缩 PNG 图片，为空时不重新压缩
+	ConvertToJPEG  []ImageFormat  `json:"convert_to_jpeg"` // 需要转换为 JPEG 的格式，如 webp、png；webp 图片需要修改时总是保存为 JPEG
+	AutoOrient     bool           `json:"auto_orient"`     // 按 EXIF 中的方向旋转 JPEG 图片
+	StripMetadata  bool           `json:"strip_metadata"`  // 去掉 EXIF 等元数据，图片没有其他修改时也会重新保存
+}
+
+// ImagePipelineResult 一张图片的处理结果
+type ImagePipelineResult struct {
+	ImgPath     string      // 原图路径
+	OutputPath  string      // 处理后的图片路径，转换格式时扩展名会改变
+	Format      ImageFormat // 原图格式
+	OutputFmt   ImageFormat // 处理后的格式
+	BytesBefore int64       // 处理前的文件大小
+	BytesAfter  int64       // 处理后的文件大小
+	Skipped     bool        // 不需要处理
+	Err         error       // 处理过程中出现的错误
+}
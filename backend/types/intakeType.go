@@ -0,0 +1,32 @@
+package types
+
+// IntakeSource URL的来源
+type IntakeSource string
+
+const (
+	IntakeSourceFileDrop  IntakeSource = "file_drop" // 拖拽到窗口中的文件
+	IntakeSourceClipboard IntakeSource = "clipboard" // 剪贴板中复制的链接
+)
+
+// IntakeEvent 拖拽文件或剪贴板中发现新链接时推送给前端的事件内容
+type IntakeEvent struct {
+	Source         IntakeSource  `json:"source"`          // 来源
+	FilePaths      []string      `json:"file_paths"`      // 拖拽的文件路径，来源为剪贴板时为空
+	Accepted       []ImportedURL `json:"accepted"`        // 可以加入待抓取列表的URL
+	Rejected       []ImportedURL `json:"rejected"`        // 被拒绝的URL及原因
+	Duplicates     []ImportedURL `json:"duplicates"`      // 重复的URL
+	AlreadyCrawled []ImportedURL `json:"already_crawled"` // 已经抓取过的文章
+	ErrContent     string        `json:"err_content"`     // 读取文件时的错误信息
+}
+
+type MergePendingURLsRequest struct {
+	Queued   []string `json:"queued"`   // 待抓取列表中已有的URL
+	Incoming []string `json:"incoming"` // 需要加入待抓取列表的URL
+}
+
+type MergePendingURLsResponse struct {
+	URLs           []string `json:"urls"`            // 合并后的待抓取列表
+	Added          []string `json:"added"`           // 新加入的URL
+	Duplicates     []string `json:"duplicates"`      // 已经在待抓取列表中的URL
+	AlreadyCrawled []string `json:"already_crawled"` // 已经抓取过的文章
+}
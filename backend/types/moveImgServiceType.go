@@ -0,0 +1,43 @@
+package types
+
+// ShuffleOrder 打乱时图片的排列方式
+type ShuffleOrder string
+
+const (
+	ShuffleOrderRandom      ShuffleOrder = "random"       // 随机排列（默认），设置相同的随机种子时结果相同
+	ShuffleOrderOriginal    ShuffleOrder = "original"     // 文章中的原始顺序
+	ShuffleOrderTime        ShuffleOrder = "time"         // 按拍摄时间排列，没有拍摄时间时使用下载时间
+	ShuffleOrderSize        ShuffleOrder = "size"         // 按文件大小从小到大排列
+	ShuffleOrderAspectRatio ShuffleOrder = "aspect_ratio" // 按宽高比从小到大排列，竖图在前
+	ShuffleOrderInterleave  ShuffleOrder = "interleave"   // 多篇文章的图片轮流排列，合并到图片目录中后再按数量上限拆分
+)
+
+// ShuffleSplitStrategy 目录中的图片超过数量上限时的拆分方式
+type ShuffleSplitStrategy string
+
+const (
+	ShuffleSplitBalanced  ShuffleSplitStrategy = "balanced"   // 平均分配到各个子目录中（默认），如 70 张、上限 20 张时拆分为 18、18、17、17
+	ShuffleSplitFillFirst ShuffleSplitStrategy = "fill_first" // 依次放满每个子目录，如 70 张、上限 20 张时拆分为 20、20、20、10
+)
+
+// ShuffleMove 打乱图片时的一次移动，先移动到临时文件名再移动到目标位置，避免与其他图片重名
+type ShuffleMove struct {
+	From string `json:"from"` // 原来的路径
+	To   string `json:"to"`   // 打乱后的路径
+	Tmp  string `json:"tmp"`  // 移动过程中使用的临时路径
+}
+
+// ShuffleJournal 打乱日志，在移动图片之前写入，用于撤销打乱
+type ShuffleJournal struct {
+	JobID            string               `json:"job_id"`             // 打乱任务ID
+	DirPath          string               `json:"dir_path"`           // 打乱的图片目录
+	MaxNumImage      int                  `json:"max_num_image"`      // 一个目录中最多可放的图片数量
+	Order            ShuffleOrder         `json:"order"`              // 排列方式
+	Seed             int64                `json:"seed"`               // 随机种子，使用相同的排列方式和随机种子可以重现打乱结果
+	SplitStrategy    ShuffleSplitStrategy `json:"split_strategy"`     // 拆分方式
+	SplitDirTemplate string               `json:"split_dir_template"` // 拆分后的子目录名模板
+	Moves            []ShuffleMove        `json:"moves"`              // 全部移动记录
+	CreatedAt        int64                `json:"created_at"`
+	FinishedAt       int64                `json:"finished_at"` // 全部移动完成的时间，0表示移动过程中被中断
+	UndoneAt         int64                `json:"undone_at"`   // 撤销时间，0表示没有撤销
+}
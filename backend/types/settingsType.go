@@ -0,0 +1,66 @@
+package types
+
+import "strings"
+
+// SettingType 设置项的值类型，前端按类型渲染表单控件
+type SettingType string
+
+const (
+	SettingTypeString SettingType = "string"
+	SettingTypeInt    SettingType = "int"
+	SettingTypeBool   SettingType = "bool"
+	SettingTypeObject SettingType = "object" // 嵌套的设置，如抓取后的图片处理步骤
+)
+
+// SettingDefinition 一个设置项的定义
+type SettingDefinition struct {
+	Key         string      `json:"key"`           // 设置项的 key，与偏好设置中的字段名一致
+	Label       string      `json:"label"`         // 表单中显示的名称
+	Type        SettingType `json:"type"`          // 值类型
+	Default     any         `json:"default"`       // 默认值，没有设置时使用
+	Min         *int        `json:"min,omitempty"` // 整数的最小值
+	Max         *int        `json:"max,omitempty"` // 整数的最大值
+	Description string      `json:"description"`
+}
+
+// SettingFieldError 一个设置项的校验错误
+type SettingFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// SettingsValidationError 设置校验失败，包含每个设置项的错误
+type SettingsValidationError struct {
+	Fields []SettingFieldError
+}
+
+func (e *SettingsValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+": "+field.Message)
+	}
+	return "设置校验失败，" + strings.Join(messages, "；")
+}
+
+// SettingHistory 设置的一次修改记录
+type SettingHistory struct {
+	ID        int64  `db:"id" json:"id"`
+	Key       string `db:"key" json:"key"`         // 系统配置表中的 key
+	Version   int    `db:"version" json:"version"` // 修改后的版本号
+	Content   string `db:"content" json:"content"` // 修改后的完整内容
+	CreatedAt int64  `db:"created_at" json:"created_at"`
+}
+
+// SettingHistoryItem 返回给前端的修改记录
+type SettingHistoryItem struct {
+	Version   int                        `json:"version"`
+	Value     *GetPreferenceInfoResponse `json:"value"`   // 这个版本的偏好设置
+	Changed   []string                   `json:"changed"` // 与上一个版本相比修改了的设置项
+	CreatedAt int64                      `json:"created_at"`
+	Current   bool                       `json:"current"` // 是否为当前使用的版本
+}
+
+type RollbackPreferenceInfoResponse struct {
+	Version     int   `json:"version"` // 回滚后的新版本号，回滚也会记录为一次修改
+	UpdatedTime int64 `json:"updated_time"`
+}
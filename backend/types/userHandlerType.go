@@ -1,18 +1,22 @@
 package types
 
 type SetPreferenceInfoRequest struct {
-	SaveImgPath        string `json:"save_img_path"`         // 图片保存路径
-	DownloadTimeout    int    `json:"download_timeout"`      // 下载超时时间
-	CropImgBottomPixel int    `json:"crop_img_bottom_pixel"` // 裁剪图片底部像素
+	SaveImgPath        string                `json:"save_img_path"`         // 图片保存路径
+	DownloadTimeout    int                   `json:"download_timeout"`      // 下载超时时间
+	CropImgBottomPixel int                   `json:"crop_img_bottom_pixel"` // 裁剪图片底部像素
+	PostProcess        *ImagePipelineOptions `json:"post_process"`          // 抓取后自动执行的图片处理，为空时保留原来的设置
 }
 
 type SetPreferenceInfoResponse struct {
-	UpdatedTime int64 `json:"updated_time"` // 更新时间
+	UpdatedTime int64               `json:"updated_time"` // 更新时间
+	Version     int                 `json:"version"`      // 保存后的版本号
+	FieldErrors []SettingFieldError `json:"field_errors"` // 校验失败的设置项，有错误时不会保存
 }
 
 type GetPreferenceInfoResponse struct {
-	SaveImgPath        string `json:"save_img_path"`         // 图片保存路径
-	DownloadTimeout    int    `json:"download_timeout"`      // 下载超时时间
-	CropImgBottomPixel int    `json:"crop_img_bottom_pixel"` // 裁剪图片底部像素
-	UpdatedTime        int64  `json:"updated_time"`          // 更新时间
+	SaveImgPath        string                `json:"save_img_path"`         // 图片保存路径
+	DownloadTimeout    int                   `json:"download_timeout"`      // 下载超时时间
+	CropImgBottomPixel int                   `json:"crop_img_bottom_pixel"` // 裁剪图片底部像素
+	PostProcess        *ImagePipelineOptions `json:"post_process"`          // 抓取后自动执行的图片处理
+	UpdatedTime        int64                 `json:"updated_time"`          // 更新时间
 }
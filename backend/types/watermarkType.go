@@ -0,0 +1,31 @@
+package types
+
+// WatermarkPosition 水印的位置
+type WatermarkPosition string
+
+const (
+	WatermarkTopLeft     WatermarkPosition = "top_left"
+	WatermarkTopRight    WatermarkPosition = "top_right"
+	WatermarkBottomLeft  WatermarkPosition = "bottom_left"
+	WatermarkBottomRight WatermarkPosition = "bottom_right"
+	WatermarkCenter      WatermarkPosition = "center"
+)
+
+// WatermarkOptions 水印设置，文字水印和图片水印二选一
+type WatermarkOptions struct {
+	Text      string            `json:"text"`       // 文字水印
+	FontPath  string            `json:"font_path"`  // 字体文件（ttf/otf/ttc），为空时使用内置字体，内置字体不支持的文字使用系统中的中文字体
+	TextColor string            `json:"text_color"` // 文字颜色，如 #FFFFFF、#FFFFFFCC，默认为白色
+	LogoPath  string            `json:"logo_path"`  // 图片水印（logo）的路径，建议使用透明背景的 png
+	Position  WatermarkPosition `json:"position"`   // 位置，默认为右下角
+	Margin    float64           `json:"margin"`     // 与图片边缘的距离，占图片宽度的比例，默认为 0.03
+	Opacity   float64           `json:"opacity"`    // 不透明度（0-1），默认为 0.8
+	Scale     float64           `json:"scale"`      // 水印宽度占图片宽度的比例（0-1），默认为 0.25
+}
+
+type WatermarkResult struct {
+	ImgPath    string // 图片存储的硬盘路径地址
+	OutputPath string // 添加水印后的图片路径，webp 图片会保存为 JPEG
+	Skipped    bool   // 是否跳过，GIF 动图添加水印后只剩第一帧，不处理
+	Err        error  // 添加水印过程中出现的错误
+}
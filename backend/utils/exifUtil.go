@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	exifTagDateTime         = 0x0132 // IFD0 中的修改时间
+	exifTagExifIFDPointer   = 0x8769 // Exif 子目录的偏移
+	exifTagDateTimeOriginal = 0x9003 // 拍摄时间
+	exifDateTimeLayout      = "2006:01:02 15:04:05"
+)
+
+// ReadExifDateTime 读取 JPEG 图片 EXIF 中的拍摄时间，没有拍摄时间时使用 IFD0 中的时间，都没有时第二个返回值为 false
+func ReadExifDateTime(path string) (time.Time, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer file.Close()
+
+	tiff := readExifSegment(bufio.NewReader(file))
+	if len(tiff) < 8 {
+		return time.Time{}, false
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return time.Time{}, false
+	}
+
+	ifd0 := readExifIFD(tiff, order, order.Uint32(tiff[4:8]))
+	if offset, ok := ifd0[exifTagExifIFDPointer]; ok {
+		if t, ok := parseExifTime(tiff, readExifIFD(tiff, order, offset)[exifTagDateTimeOriginal]); ok {
+			return t, true
+		}
+	}
+	return parseExifTime(tiff, ifd0[exifTagDateTime])
+}
+
+// readExifSegment 找到 JPEG 中的 APP1 Exif 段，返回其中的 TIFF 数据
+func readExifSegment(r *bufio.Reader) []byte {
+	var marker [2]byte
+	if _, err := io.ReadFull(r, marker[:]); err != nil || marker != [2]byte{0xFF, 0xD8} {
+		return nil
+	}
+	for {
+		if _, err := io.ReadFull(r, marker[:]); err != nil || marker[0] != 0xFF {
+			return nil
+		}
+		// 图片数据开始后不会再有 EXIF
+		if marker[1] == 0xDA || marker[1] == 0xD9 {
+			return nil
+		}
+		var size [2]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(size[:])) - 2
+		if length < 0 {
+			return nil
+		}
+		segment := make([]byte, length)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return nil
+		}
+		if marker[1] == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+	}
+}
+
+// readExifIFD 读取一个 IFD 中的全部标签，返回标签对应的值或值的偏移
+func readExifIFD(tiff []byte, order binary.ByteOrder, offset uint32) map[uint16]uint32 {
+	tags := make(map[uint16]uint32)
+	if int(offset)+2 > len(tiff) {
+		return tags
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := int(offset) + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		tags[order.Uint16(tiff[entry:])] = order.Uint32(tiff[entry+8:])
+	}
+	return tags
+}
+
+// parseExifTime 解析 "2006:01:02 15:04:05" 格式的时间，EXIF 中的时间没有时区，按本地时间处理
+func parseExifTime(tiff []byte, offset uint32) (time.Time, bool) {
+	end := int(offset) + len(exifDateTimeLayout)
+	if offset == 0 || end > len(tiff) {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(exifDateTimeLayout, strings.TrimRight(string(tiff[offset:end]), "\x00"), time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
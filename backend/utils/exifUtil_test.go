@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// buildExifJPEG 生成只包含 EXIF 拍摄时间的 JPEG 文件头
+func buildExifJPEG(dateTime string) []byte {
+	var tiff bytes.Buffer
+	order := binary.BigEndian
+	tiff.WriteString("MM\x00\x2a")
+	binary.Write(&tiff, order, uint32(8))
+	// IFD0：一个指向 Exif 子目录的标签
+	binary.Write(&tiff, order, uint16(1))
+	binary.Write(&tiff, order, []uint16{exifTagExifIFDPointer, 4})
+	binary.Write(&tiff, order, []uint32{1, 26})
+	binary.Write(&tiff, order, uint32(0))
+	// Exif 子目录：拍摄时间
+	binary.Write(&tiff, order, uint16(1))
+	binary.Write(&tiff, order, []uint16{exifTagDateTimeOriginal, 2})
+	binary.Write(&tiff, order, []uint32{20, 44})
+	binary.Write(&tiff, order, uint32(0))
+	tiff.WriteString(dateTime + "\x00")
+
+	var buf bytes.Buffer
+	buf.Write([]byte{0xFF, 0xD8, 0xFF, 0xE1})
+	binary.Write(&buf, order, uint16(tiff.Len()+8))
+	buf.WriteString("Exif\x00\x00")
+	buf.Write(tiff.Bytes())
+	buf.Write([]byte{0xFF, 0xDA})
+	return buf.Bytes()
+}
+
+func TestReadExifDateTime(t *testing.T) {
+	dir := t.TempDir()
+	withExif := filepath.Join(dir, "a.jpg")
+	if err := os.WriteFile(withExif, buildExifJPEG("2023:05:06 07:08:09"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := ReadExifDateTime(withExif)
+	if want := time.Date(2023, 5, 6, 7, 8, 9, 0, time.Local); !ok || !got.Equal(want) {
+		t.Errorf("拍摄时间应该为 %v，实际为 %v, %v", want, got, ok)
+	}
+
+	withoutExif := filepath.Join(dir, "b.jpg")
+	if err := os.WriteFile(withoutExif, []byte{0xFF, 0xD8, 0xFF, 0xDA}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok = ReadExifDateTime(withoutExif); ok {
+		t.Error("没有 EXIF 时应该返回 false")
+	}
+}
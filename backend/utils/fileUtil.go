@@ -1,7 +1,7 @@
 package utils
 
 import (
-	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 )
@@ -18,22 +18,36 @@ func SaveFile(content, filePath string) error {
 	return os.WriteFile(filePath, []byte(content), 0644)
 }
 
-// GetDefaultDownloadsDir 获取默认的下载目录
-func GetDefaultDownloadsDir() string {
-	homeDir, err := os.UserHomeDir()
+// CopyFile 复制文件，目标文件所在的目录不存在时会自动创建，已存在的目标文件会被覆盖
+func CopyFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
 	if err != nil {
-		return "downloads"
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
 	}
-	fmt.Printf("用户主目录：%s\n", homeDir)
+	return out.Close()
+}
 
-	wd, err := os.Getwd()
+// GetDefaultDownloadsDir 获取默认的下载目录
+// Windows、macOS 和 Linux 下均为用户主目录下的 Downloads 目录
+func GetDefaultDownloadsDir() string {
+	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "downloads"
 	}
-	fmt.Printf("当前工作目录：%s\n", wd)
 
-	// 获取当前盘符
-	drive := filepath.VolumeName(wd)
-	fmt.Printf("当前盘符：%s\n", drive)
-	return filepath.Join(drive, "\\", "downloads")
+	return filepath.Join(homeDir, "Downloads")
 }
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/disintegration/imaging"
+)
+
+// AHash 均值哈希：缩小为 8x8 灰度图，亮度高于平均值的位置为 1
+func AHash(img image.Image) uint64 {
+	pixels := grayPixels(img, 8, 8)
+	mean := 0.0
+	for _, p := range pixels {
+		mean += p
+	}
+	mean /= float64(len(pixels))
+
+	var hash uint64
+	for i, p := range pixels {
+		if p > mean {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// DHash 差异哈希：缩小为 9x8 灰度图，每行中左边比右边亮的位置为 1
+func DHash(img image.Image) uint64 {
+	pixels := grayPixels(img, 9, 8)
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if pixels[y*9+x] > pixels[y*9+x+1] {
+				hash |= 1 << uint(y*8+x)
+			}
+		}
+	}
+	return hash
+}
+
+// PHash 感知哈希：缩小为 32x32 灰度图后做离散余弦变换，取左上角 8x8 的低频部分，大于中位数的位置为 1
+func PHash(img image.Image) uint64 {
+	const size, lowSize = 32, 8
+	pixels := grayPixels(img, size, size)
+
+	// 二维 DCT 可以拆成先对每行、再对每列做一维 DCT
+	cosTable := make([]float64, size*size)
+	for u := 0; u < size; u++ {
+		for x := 0; x < size; x++ {
+			cosTable[u*size+x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * size))
+		}
+	}
+	rows := make([]float64, size*size)
+	for y := 0; y < size; y++ {
+		for u := 0; u < lowSize; u++ {
+			sum := 0.0
+			for x := 0; x < size; x++ {
+				sum += pixels[y*size+x] * cosTable[u*size+x]
+			}
+			rows[y*size+u] = sum
+		}
+	}
+	low := make([]float64, 0, lowSize*lowSize)
+	for v := 0; v < lowSize; v++ {
+		for u := 0; u < lowSize; u++ {
+			sum := 0.0
+			for y := 0; y < size; y++ {
+				sum += rows[y*size+u] * cosTable[v*size+y]
+			}
+			low = append(low, sum)
+		}
+	}
+
+	// 直流分量只反映整体亮度，不参与计算中位数
+	sorted := append([]float64{}, low[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash uint64
+	for i, c := range low {
+		if c > median {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// HammingDistance 两个哈希值不同的位数，越小表示图片越相似
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// grayPixels 将图片缩小为指定大小的灰度图，返回按行排列的亮度
+func grayPixels(img image.Image, width, height int) []float64 {
+	small := imaging.Grayscale(imaging.Resize(img, width, height, imaging.Lanczos))
+	pixels := make([]float64, 0, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pixels = append(pixels, float64(small.Pix[y*small.Stride+x*4]))
+		}
+	}
+	return pixels
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+// newHashTestImage 生成带有色块的测试图片，seed 不同时色块的位置不同
+func newHashTestImage(width, height, seed int) *image.NRGBA {
+	img := imaging.New(width, height, color.White)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			cx, cy := x*8/width, y*8/height
+			if (cx*3+cy*5+seed)%4 == 0 {
+				img.Set(x, y, color.NRGBA{R: uint8(40 * cx), G: uint8(30 * cy), B: 200, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestImageHash(t *testing.T) {
+	original := newHashTestImage(640, 480, 1)
+	resized := imaging.Resize(original, 320, 240, imaging.Lanczos)
+	brighter := imaging.AdjustBrightness(original, 10)
+	different := newHashTestImage(640, 480, 2)
+
+	for name, hash := range map[string]func(image.Image) uint64{"aHash": AHash, "dHash": DHash, "pHash": PHash} {
+		h := hash(original)
+		if d := HammingDistance(h, hash(resized)); d > 4 {
+			t.Errorf("%s: 缩小后的图片距离为 %d，应该接近", name, d)
+		}
+		if d := HammingDistance(h, hash(brighter)); d > 6 {
+			t.Errorf("%s: 调亮后的图片距离为 %d，应该接近", name, d)
+		}
+		if d := HammingDistance(h, hash(different)); d < 10 {
+			t.Errorf("%s: 不同图片的距离为 %d，应该较大", name, d)
+		}
+	}
+
+	if HammingDistance(0b1011, 0b0001) != 2 {
+		t.Error("汉明距离计算错误")
+	}
+}
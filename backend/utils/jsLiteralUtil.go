@@ -0,0 +1,969 @@
+package utils
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+/**
+微信页面中的数据并不是合法的 JSON，而是直接写在 <script> 中的 JavaScript 对象字面量，例如：
+
+	window.cgiData = {
+	    ret: '0',                                  // 单引号字符串、不带引号的键
+	    article_count: '490' * 1,                  // 字符串乘以 1 转换为数字
+	    nick_name: '一安未来' || '',               // 逻辑或
+	    total_onread: '' ? '' * 1 : -1,            // 三元表达式
+	    show_watermark: 'true' === 'true',         // 全等比较
+	    articleList: [{ title: 'xxx', }, ],        // 末尾多余的逗号
+	};
+
+文章页面中的标题和描述还会经过 HTML 解码，例如：
+
+	var msg_title = '标题 &amp; 副标题'.html(false);
+	var msg_desc = htmlDecode("描述");
+
+这里实现了一个只支持上述子集的词法分析器和求值器，按照 JavaScript 的语义计算出每个值，再序列化为 JSON。
+除了上面 HTML 解码用到的 html、htmlDecode、htmlEncode，不支持的语法（其他函数调用、变量引用等）会返回错误，而不是猜测其含义。
+*/
+
+// jsMaxDepth 对象和数组允许的最大嵌套层数，避免恶意输入导致栈溢出
+const jsMaxDepth = 512
+
+// jsUndefined 表示 JavaScript 中的 undefined，序列化为 JSON 时与 JSON.stringify 的行为一致：对象中省略该键，数组中转换为 null
+type jsUndefined struct{}
+
+type jsTokenKind int
+
+const (
+	jsTokenEOF jsTokenKind = iota
+	jsTokenPunct
+	jsTokenString
+	jsTokenNumber
+	jsTokenIdent
+)
+
+type jsToken struct {
+	kind  jsTokenKind
+	text  string  // 标点符号或标识符的原文，字符串的值
+	num   float64 // 数字的值
+	start int     // 在源码中的起始位置
+	end   int     // 在源码中的结束位置
+}
+
+// jsPunctuators 支持的标点符号，长的写在前面，保证最长匹配
+var jsPunctuators = []string{"===", "!==", "==", "!=", "||", "&&", "{", "}", "[", "]", "(", ")", ",", ":", "?", "*", "/", "+", "-", "!", ";", "."}
+
+type jsParser struct {
+	src   string
+	pos   int     // 词法分析的当前位置
+	tok   jsToken // 当前的 token
+	depth int     // 当前的嵌套层数
+}
+
+// ParseJSLiteral 从 src 的开头解析一个 JavaScript 表达式并求值
+// 返回值的类型为 nil、bool、float64、string、[]interface{} 或 map[string]interface{}，end 为表达式在 src 中结束的位置
+func ParseJSLiteral(src string) (value interface{}, end int, err error) {
+	p := &jsParser{src: src}
+	if err = p.next(); err != nil {
+		return nil, 0, err
+	}
+	value, err = p.parseExpression()
+	if err != nil {
+		return nil, 0, err
+	}
+	end = p.tok.start
+	if p.tok.kind == jsTokenEOF {
+		end = len(src)
+	}
+	return toJSONValue(value), end, nil
+}
+
+// JSLiteralToJSON 将 JavaScript 对象字面量转换为 JSON 字符串，字面量之后只允许出现分号和空白
+func JSLiteralToJSON(src string) (string, error) {
+	p := &jsParser{src: src}
+	if err := p.next(); err != nil {
+		return "", err
+	}
+	value, err := p.parseExpression()
+	if err != nil {
+		return "", err
+	}
+	if p.isPunct(";") {
+		if err = p.next(); err != nil {
+			return "", err
+		}
+	}
+	if p.tok.kind != jsTokenEOF {
+		return "", p.errorf("字面量之后出现了多余的内容")
+	}
+	return marshalJSValue(value)
+}
+
+// ExtractJSAssignmentJSON 在 content 中查找形如 `name = <字面量>` 的赋值语句，并将右侧的值转换为 JSON 字符串
+// 如 ExtractJSAssignmentJSON(html, "window.cgiData")
+func ExtractJSAssignmentJSON(content, name string) (string, error) {
+	re := regexp.MustCompile(`(^|[^\w$.])` + regexp.QuoteMeta(name) + `\s*=[^=]`)
+	loc := re.FindStringSubmatchIndex(content)
+	if loc == nil {
+		return "", errors.Errorf("未找到 %s 的赋值语句", name)
+	}
+	// 跳过等号，从右侧的值开始解析
+	start := loc[1] - 1
+
+	value, _, err := ParseJSLiteral(content[start:])
+	if err != nil {
+		return "", errors.Wrapf(err, "解析 %s 失败", name)
+	}
+	return marshalJSValue(value)
+}
+
+// marshalJSValue 将求值后的结果序列化为 JSON 字符串
+func marshalJSValue(value interface{}) (string, error) {
+	b, err := json.Marshal(toJSONValue(value))
+	if err != nil {
+		return "", errors.Wrap(err, "序列化为JSON失败")
+	}
+	return string(b), nil
+}
+
+// toJSONValue 将求值结果转换为可以被 encoding/json 序列化的值
+func toJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case jsUndefined:
+		return nil
+	case float64:
+		// JSON 中不能表示 NaN 和 Infinity，与 JSON.stringify 一致转换为 null
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = toJSONValue(v[i])
+		}
+		return v
+	case map[string]interface{}:
+		for key, item := range v {
+			if _, ok := item.(jsUndefined); ok {
+				delete(v, key)
+				continue
+			}
+			v[key] = toJSONValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func (p *jsParser) errorf(format string, args ...interface{}) error {
+	return errors.Errorf("位置 %d: "+format, append([]interface{}{p.tok.start}, args...)...)
+}
+
+func (p *jsParser) isPunct(text string) bool {
+	return p.tok.kind == jsTokenPunct && p.tok.text == text
+}
+
+func (p *jsParser) expectPunct(text string) error {
+	if !p.isPunct(text) {
+		return p.errorf("期望 %q，实际为 %q", text, p.tok.text)
+	}
+	return p.next()
+}
+
+// skipSpaceAndComments 跳过空白字符和注释
+func (p *jsParser) skipSpaceAndComments() error {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			p.pos++
+		case strings.HasPrefix(p.src[p.pos:], "//"):
+			end := strings.IndexAny(p.src[p.pos:], "\r\n")
+			if end < 0 {
+				p.pos = len(p.src)
+			} else {
+				p.pos += end
+			}
+		case strings.HasPrefix(p.src[p.pos:], "/*"):
+			end := strings.Index(p.src[p.pos+2:], "*/")
+			if end < 0 {
+				return errors.Errorf("位置 %d: 注释没有结束", p.pos)
+			}
+			p.pos += end + 4
+		case c >= utf8.RuneSelf:
+			r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+			if !unicode.IsSpace(r) && r != '\uFEFF' {
+				return nil
+			}
+			p.pos += size
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// next 读取下一个 token
+func (p *jsParser) next() error {
+	if err := p.skipSpaceAndComments(); err != nil {
+		return err
+	}
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = jsToken{kind: jsTokenEOF, start: start, end: start}
+		return nil
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case c == '\'' || c == '"':
+		s, err := p.readString(c)
+		if err != nil {
+			return err
+		}
+		p.tok = jsToken{kind: jsTokenString, text: s, start: start, end: p.pos}
+		return nil
+	case isJSDigit(c) || (c == '.' && p.pos+1 < len(p.src) && isJSDigit(p.src[p.pos+1])):
+		n, err := p.readNumber()
+		if err != nil {
+			return err
+		}
+		p.tok = jsToken{kind: jsTokenNumber, num: n, start: start, end: p.pos}
+		return nil
+	case isJSIdentStart(c):
+		for p.pos < len(p.src) && isJSIdentPart(p.src[p.pos]) {
+			p.pos++
+		}
+		p.tok = jsToken{kind: jsTokenIdent, text: p.src[start:p.pos], start: start, end: p.pos}
+		return nil
+	}
+
+	for _, punct := range jsPunctuators {
+		if strings.HasPrefix(p.src[p.pos:], punct) {
+			p.pos += len(punct)
+			p.tok = jsToken{kind: jsTokenPunct, text: punct, start: start, end: p.pos}
+			return nil
+		}
+	}
+
+	return errors.Errorf("位置 %d: 无法识别的字符 %q", start, p.src[start:start+1])
+}
+
+// readString 读取单引号或双引号字符串，处理其中的转义字符
+func (p *jsParser) readString(quote byte) (string, error) {
+	start := p.pos
+	p.pos++ // 跳过开头的引号
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch c {
+		case quote:
+			p.pos++
+			return sb.String(), nil
+		case '\n', '\r':
+			return "", errors.Errorf("位置 %d: 字符串中不能直接换行", start)
+		case '\\':
+			if err := p.readEscape(&sb); err != nil {
+				return "", err
+			}
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", errors.Errorf("位置 %d: 字符串没有结束", start)
+}
+
+// readEscape 读取一个转义序列，p.pos 指向反斜杠
+func (p *jsParser) readEscape(sb *strings.Builder) error {
+	start := p.pos
+	p.pos++
+	if p.pos >= len(p.src) {
+		return errors.Errorf("位置 %d: 转义字符不完整", start)
+	}
+	c := p.src[p.pos]
+	p.pos++
+	switch c {
+	case 'n':
+		sb.WriteByte('\n')
+	case 't':
+		sb.WriteByte('\t')
+	case 'r':
+		sb.WriteByte('\r')
+	case 'b':
+		sb.WriteByte('\b')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'v':
+		sb.WriteByte('\v')
+	case '0':
+		sb.WriteByte(0)
+	case '\r':
+		// 行尾的反斜杠表示字符串续行
+		if p.pos < len(p.src) && p.src[p.pos] == '\n' {
+			p.pos++
+		}
+	case '\n':
+	case 'x':
+		r, err := p.readHex(2)
+		if err != nil {
+			return err
+		}
+		sb.WriteRune(r)
+	case 'u':
+		var r rune
+		var err error
+		if p.pos < len(p.src) && p.src[p.pos] == '{' {
+			end := strings.IndexByte(p.src[p.pos:], '}')
+			if end < 2 {
+				return errors.Errorf("位置 %d: \\u{} 转义不完整", start)
+			}
+			p.pos++
+			r, err = p.readHex(end - 1)
+			p.pos++
+		} else {
+			r, err = p.readHex(4)
+			// 处理 UTF-16 代理对，如 😀
+			if err == nil && r >= 0xD800 && r < 0xDC00 && strings.HasPrefix(p.src[p.pos:], "\\u") {
+				save := p.pos
+				p.pos += 2
+				low, lowErr := p.readHex(4)
+				if lowErr == nil && low >= 0xDC00 && low < 0xE000 {
+					r = (r-0xD800)<<10 + (low - 0xDC00) + 0x10000
+				} else {
+					p.pos = save
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+		sb.WriteRune(r)
+	default:
+		// 其余字符（如 \' \" \\ \/）转义后就是字符本身，多字节字符需要完整写入
+		p.pos--
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		sb.WriteRune(r)
+		p.pos += size
+	}
+	return nil
+}
+
+// readHex 读取 n 位十六进制数
+func (p *jsParser) readHex(n int) (rune, error) {
+	if n <= 0 || n > 6 || p.pos+n > len(p.src) {
+		return 0, errors.Errorf("位置 %d: 十六进制转义不完整", p.pos)
+	}
+	v, err := strconv.ParseUint(p.src[p.pos:p.pos+n], 16, 32)
+	if err != nil || v > unicode.MaxRune {
+		return 0, errors.Errorf("位置 %d: 无效的十六进制转义", p.pos)
+	}
+	p.pos += n
+	return rune(v), nil
+}
+
+// readNumber 读取十进制或十六进制数字
+func (p *jsParser) readNumber() (float64, error) {
+	start := p.pos
+	if strings.HasPrefix(p.src[p.pos:], "0x") || strings.HasPrefix(p.src[p.pos:], "0X") {
+		p.pos += 2
+		for p.pos < len(p.src) && isJSHexDigit(p.src[p.pos]) {
+			p.pos++
+		}
+		v, err := strconv.ParseUint(p.src[start+2:p.pos], 16, 64)
+		if err != nil {
+			return 0, errors.Errorf("位置 %d: 无效的十六进制数字", start)
+		}
+		return float64(v), nil
+	}
+
+	for p.pos < len(p.src) && isJSDigit(p.src[p.pos]) {
+		p.pos++
+	}
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		p.pos++
+		for p.pos < len(p.src) && isJSDigit(p.src[p.pos]) {
+			p.pos++
+		}
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		for p.pos < len(p.src) && isJSDigit(p.src[p.pos]) {
+			p.pos++
+		}
+	}
+	v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, errors.Errorf("位置 %d: 无效的数字 %q", start, p.src[start:p.pos])
+	}
+	return v, nil
+}
+
+// parseExpression 解析表达式，优先级从低到高依次为：三元表达式、||、&&、相等比较、加减、乘除、一元运算
+func (p *jsParser) parseExpression() (interface{}, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > jsMaxDepth {
+		return nil, p.errorf("嵌套层数超过 %d", jsMaxDepth)
+	}
+	return p.parseConditional()
+}
+
+func (p *jsParser) parseConditional() (interface{}, error) {
+	cond, err := p.parseLogicalOr()
+	if err != nil || !p.isPunct("?") {
+		return cond, err
+	}
+	if err = p.next(); err != nil {
+		return nil, err
+	}
+	consequent, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expectPunct(":"); err != nil {
+		return nil, err
+	}
+	alternate, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	// 两个分支都需要解析完，才能知道表达式在哪里结束
+	if jsTruthy(cond) {
+		return consequent, nil
+	}
+	return alternate, nil
+}
+
+func (p *jsParser) parseLogicalOr() (interface{}, error) {
+	left, err := p.parseLogicalAnd()
+	for err == nil && p.isPunct("||") {
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		var right interface{}
+		if right, err = p.parseLogicalAnd(); err == nil && !jsTruthy(left) {
+			left = right
+		}
+	}
+	return left, err
+}
+
+func (p *jsParser) parseLogicalAnd() (interface{}, error) {
+	left, err := p.parseEquality()
+	for err == nil && p.isPunct("&&") {
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		var right interface{}
+		if right, err = p.parseEquality(); err == nil && jsTruthy(left) {
+			left = right
+		}
+	}
+	return left, err
+}
+
+func (p *jsParser) parseEquality() (interface{}, error) {
+	left, err := p.parseAdditive()
+	for err == nil && (p.isPunct("===") || p.isPunct("!==") || p.isPunct("==") || p.isPunct("!=")) {
+		op := p.tok.text
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		var right interface{}
+		if right, err = p.parseAdditive(); err != nil {
+			return nil, err
+		}
+		switch op {
+		case "===":
+			left = jsStrictEquals(left, right)
+		case "!==":
+			left = !jsStrictEquals(left, right)
+		case "==":
+			left = jsLooseEquals(left, right)
+		case "!=":
+			left = !jsLooseEquals(left, right)
+		}
+	}
+	return left, err
+}
+
+func (p *jsParser) parseAdditive() (interface{}, error) {
+	left, err := p.parseMultiplicative()
+	for err == nil && (p.isPunct("+") || p.isPunct("-")) {
+		op := p.tok.text
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		var right interface{}
+		if right, err = p.parseMultiplicative(); err != nil {
+			return nil, err
+		}
+		if op == "+" {
+			left = jsAdd(left, right)
+		} else {
+			left = jsToNumber(left) - jsToNumber(right)
+		}
+	}
+	return left, err
+}
+
+func (p *jsParser) parseMultiplicative() (interface{}, error) {
+	left, err := p.parseUnary()
+	for err == nil && (p.isPunct("*") || p.isPunct("/")) {
+		op := p.tok.text
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		var right interface{}
+		if right, err = p.parseUnary(); err != nil {
+			return nil, err
+		}
+		if op == "*" {
+			left = jsToNumber(left) * jsToNumber(right)
+		} else {
+			left = jsToNumber(left) / jsToNumber(right)
+		}
+	}
+	return left, err
+}
+
+func (p *jsParser) parseUnary() (interface{}, error) {
+	if p.isPunct("!") || p.isPunct("-") || p.isPunct("+") {
+		op := p.tok.text
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > jsMaxDepth {
+			return nil, p.errorf("嵌套层数超过 %d", jsMaxDepth)
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "!":
+			return !jsTruthy(operand), nil
+		case "-":
+			return -jsToNumber(operand), nil
+		default:
+			return jsToNumber(operand), nil
+		}
+	}
+	return p.parsePostfix()
+}
+
+// parsePostfix 解析字符串之后的方法调用，只支持微信页面中用于 HTML 转义的方法，如 'xxx'.html(false)
+func (p *jsParser) parsePostfix() (interface{}, error) {
+	value, err := p.parsePrimary()
+	for err == nil && p.isPunct(".") {
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		method := p.tok
+		if method.kind != jsTokenIdent {
+			return nil, p.errorf("意外的符号 %q", method.text)
+		}
+		str, ok := value.(string)
+		if !ok {
+			return nil, p.errorf("不支持在非字符串上调用 %s 方法", method.text)
+		}
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		var args []interface{}
+		if args, err = p.parseArguments(); err != nil {
+			return nil, err
+		}
+		switch method.text {
+		case "html":
+			value = jsHTML(str, jsTruthy(jsArg(args, 0)))
+		case "htmlDecode":
+			value = jsHTML(str, false)
+		case "htmlEncode":
+			value = jsHTML(str, true)
+		default:
+			return nil, p.errorf("不支持的方法 %q", method.text)
+		}
+	}
+	return value, err
+}
+
+// parseArguments 解析函数调用的参数列表
+func (p *jsParser) parseArguments() ([]interface{}, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	args := make([]interface{}, 0, 1)
+	for !p.isPunct(")") {
+		arg, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if !p.isPunct(",") {
+			break
+		}
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+	}
+	return args, p.expectPunct(")")
+}
+
+func (p *jsParser) parsePrimary() (interface{}, error) {
+	tok := p.tok
+	switch tok.kind {
+	case jsTokenString:
+		return tok.text, p.next()
+	case jsTokenNumber:
+		return tok.num, p.next()
+	case jsTokenIdent:
+		var value interface{}
+		switch tok.text {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		case "undefined":
+			value = jsUndefined{}
+		case "NaN":
+			value = math.NaN()
+		case "Infinity":
+			value = math.Inf(1)
+		case "htmlDecode":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			args, err := p.parseArguments()
+			if err != nil {
+				return nil, err
+			}
+			return jsHTML(jsToString(jsArg(args, 0)), false), nil
+		default:
+			return nil, p.errorf("不支持的标识符 %q", tok.text)
+		}
+		return value, p.next()
+	case jsTokenPunct:
+		switch tok.text {
+		case "{":
+			return p.parseObject()
+		case "[":
+			return p.parseArray()
+		case "(":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			value, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			return value, p.expectPunct(")")
+		}
+	case jsTokenEOF:
+		return nil, p.errorf("意外的结尾")
+	}
+	return nil, p.errorf("意外的符号 %q", tok.text)
+}
+
+// parseObject 解析对象字面量，键可以是标识符、字符串或数字，允许末尾多余的逗号
+func (p *jsParser) parseObject() (interface{}, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > jsMaxDepth {
+		return nil, p.errorf("嵌套层数超过 %d", jsMaxDepth)
+	}
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+
+	obj := make(map[string]interface{})
+	for !p.isPunct("}") {
+		var key string
+		switch p.tok.kind {
+		case jsTokenIdent, jsTokenString:
+			key = p.tok.text
+		case jsTokenNumber:
+			key = strconv.FormatFloat(p.tok.num, 'f', -1, 64)
+		default:
+			return nil, p.errorf("期望对象的键，实际为 %q", p.tok.text)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		obj[key] = value
+
+		if !p.isPunct(",") {
+			break
+		}
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	return obj, p.expectPunct("}")
+}
+
+// parseArray 解析数组字面量，允许末尾多余的逗号
+func (p *jsParser) parseArray() (interface{}, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > jsMaxDepth {
+		return nil, p.errorf("嵌套层数超过 %d", jsMaxDepth)
+	}
+	if err := p.expectPunct("["); err != nil {
+		return nil, err
+	}
+
+	arr := make([]interface{}, 0)
+	for !p.isPunct("]") {
+		value, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, value)
+
+		if !p.isPunct(",") {
+			break
+		}
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	return arr, p.expectPunct("]")
+}
+
+// jsTruthy 按照 JavaScript 的规则判断值是否为真
+func jsTruthy(v interface{}) bool {
+	switch val := v.(type) {
+	case nil, jsUndefined:
+		return false
+	case bool:
+		return val
+	case float64:
+		return val != 0 && !math.IsNaN(val)
+	case string:
+		return val != ""
+	default:
+		return true
+	}
+}
+
+// jsToNumber 按照 JavaScript 的规则将值转换为数字，如 ” => 0，'490' => 490，'abc' => NaN
+func jsToNumber(v interface{}) float64 {
+	switch val := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		if val {
+			return 1
+		}
+		return 0
+	case float64:
+		return val
+	case string:
+		s := strings.TrimSpace(val)
+		if s == "" {
+			return 0
+		}
+		if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+			if n, err := strconv.ParseUint(s[2:], 16, 64); err == nil {
+				return float64(n)
+			}
+			return math.NaN()
+		}
+		switch s {
+		case "Infinity", "+Infinity":
+			return math.Inf(1)
+		case "-Infinity":
+			return math.Inf(-1)
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil && !errors.Is(err, strconv.ErrRange) {
+			return math.NaN()
+		}
+		// strconv 能解析 "inf"、"nan" 等写法，而 JavaScript 不能
+		if strings.ContainsAny(s, "iInN") {
+			return math.NaN()
+		}
+		return n
+	case []interface{}:
+		if len(val) == 0 {
+			return 0
+		}
+		if len(val) == 1 {
+			return jsToNumber(jsToString(val[0]))
+		}
+		return math.NaN()
+	default:
+		return math.NaN()
+	}
+}
+
+// jsArg 返回第 i 个参数，没有传入时为 undefined
+func jsArg(args []interface{}, i int) interface{} {
+	if i < len(args) {
+		return args[i]
+	}
+	return jsUndefined{}
+}
+
+var (
+	// jsHTMLDecodeReplace 与微信页面中 String.prototype.html 的替换顺序一致，每两项为一组（查找、替换）
+	jsHTMLDecodeReplace = []string{"&#39;", "'", "&quot;", `"`, "&nbsp;", " ", "&gt;", ">", "&lt;", "<", "&yen;", "¥", "&amp;", "&"}
+	jsHTMLEncodeReplace = []string{"&", "&amp;", "¥", "&yen;", "<", "&lt;", ">", "&gt;", " ", "&nbsp;", `"`, "&quot;", "'", "&#39;"}
+)
+
+// jsHTML 实现微信页面中的 String.prototype.html，encode 为 false 时解码 HTML 实体
+func jsHTML(s string, encode bool) string {
+	replace := jsHTMLDecodeReplace
+	if encode {
+		replace = jsHTMLEncodeReplace
+	}
+	for i := 0; i < len(replace); i += 2 {
+		s = strings.ReplaceAll(s, replace[i], replace[i+1])
+	}
+	return s
+}
+
+// jsToString 按照 JavaScript 的规则将值转换为字符串
+func jsToString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case jsUndefined:
+		return "undefined"
+	case bool:
+		return strconv.FormatBool(val)
+	case float64:
+		switch {
+		case math.IsNaN(val):
+			return "NaN"
+		case math.IsInf(val, 1):
+			return "Infinity"
+		case math.IsInf(val, -1):
+			return "-Infinity"
+		}
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case string:
+		return val
+	case []interface{}:
+		items := make([]string, len(val))
+		for i, item := range val {
+			if item != nil {
+				if _, ok := item.(jsUndefined); !ok {
+					items[i] = jsToString(item)
+				}
+			}
+		}
+		return strings.Join(items, ",")
+	default:
+		return "[object Object]"
+	}
+}
+
+// jsAdd 实现 JavaScript 的加法：任意一边为字符串、数组或对象时拼接字符串，否则按数字相加
+func jsAdd(left, right interface{}) interface{} {
+	isPrimitive := func(v interface{}) bool {
+		switch v.(type) {
+		case nil, jsUndefined, bool, float64:
+			return true
+		}
+		return false
+	}
+	if isPrimitive(left) && isPrimitive(right) {
+		return jsToNumber(left) + jsToNumber(right)
+	}
+	return jsToString(left) + jsToString(right)
+}
+
+// jsStrictEquals 实现 ===，对象和数组只有同一个引用时才相等，这里的字面量每次都是新对象，因此总是不相等
+func jsStrictEquals(left, right interface{}) bool {
+	switch l := left.(type) {
+	case nil:
+		return right == nil
+	case jsUndefined:
+		_, ok := right.(jsUndefined)
+		return ok
+	case bool:
+		r, ok := right.(bool)
+		return ok && l == r
+	case float64:
+		r, ok := right.(float64)
+		return ok && l == r
+	case string:
+		r, ok := right.(string)
+		return ok && l == r
+	}
+	return false
+}
+
+// jsLooseEquals 实现 ==，只处理字面量中可能出现的基本类型
+func jsLooseEquals(left, right interface{}) bool {
+	isNullish := func(v interface{}) bool {
+		if v == nil {
+			return true
+		}
+		_, ok := v.(jsUndefined)
+		return ok
+	}
+	if isNullish(left) || isNullish(right) {
+		return isNullish(left) && isNullish(right)
+	}
+	if jsStrictEquals(left, right) {
+		return true
+	}
+	_, leftIsString := left.(string)
+	_, rightIsString := right.(string)
+	if leftIsString && rightIsString {
+		return false
+	}
+	switch left.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	switch right.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return jsToNumber(left) == jsToNumber(right)
+}
+
+func isJSDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isJSHexDigit(c byte) bool {
+	return isJSDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isJSIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isJSIdentPart(c byte) bool {
+	return isJSIdentStart(c) || isJSDigit(c)
+}
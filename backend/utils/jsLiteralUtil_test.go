@@ -0,0 +1,265 @@
+package utils
+
+import (
+	"encoding/json"
+	"math/rand"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSLiteralToJSON(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"单引号字符串与不带引号的键", `{ret: '0', title: '干货分享集'}`, `{"ret":"0","title":"干货分享集"}`},
+		{"字符串乘以1", `{a: '490' * 1, b: '' * 1, c: 'abc' * 1}`, `{"a":490,"b":0,"c":null}`},
+		{"逻辑或", `{a: '一安未来' || '', b: '' || -1, c: '' * 1 || '' * 1 || 7}`, `{"a":"一安未来","b":-1,"c":7}`},
+		{"逻辑与", `{a: '1' && 'x', b: '' && 'x'}`, `{"a":"x","b":""}`},
+		{"三元表达式", `{a: '' ? '' * 1 : -1, b: '5' ? '5' * 1 : -1, c: 1 ? 2 ? 3 : 4 : 5}`, `{"a":-1,"b":5,"c":3}`},
+		{"比较", `{a: 'true' === 'true', b: '' === 'true', c: '1' == 1, d: '1' !== 1, e: null == undefined}`, `{"a":true,"b":false,"c":true,"d":true,"e":true}`},
+		{"末尾逗号", `{a: [1, 2, ], b: {c: 'd', }, }`, `{"a":[1,2],"b":{"c":"d"}}`},
+		{"注释", "{\n// 单行注释\na: 1, /* 多行\n注释 */ b: 'http://x.com/a' // 行尾注释\n}", `{"a":1,"b":"http://x.com/a"}`},
+		{"转义字符", `{a: 'it\'s', b: "say \"hi\"", c: 'a\x26amp;b', d: '中\u{6587}', e: 'line\nbreak'}`, `{"a":"it's","b":"say \"hi\"","c":"a&amp;b","d":"中文","e":"line\nbreak"}`},
+		{"引号键和数字键", `{'a-b': 1, "c": 2, 3: 4}`, `{"3":4,"a-b":1,"c":2}`},
+		{"undefined", `{a: undefined, b: [undefined], c: null}`, `{"b":[null],"c":null}`},
+		{"一元运算和括号", `{a: -1, b: !'', c: +'3', d: ('2' + 1) * 1, e: 1 + 2 * 3}`, `{"a":-1,"b":true,"c":3,"d":21,"e":7}`},
+		{"末尾分号", `{a: 1};`, `{"a":1}`},
+		{"空对象", `{ pay_subscribe_info: { }, list: [] }`, `{"list":[],"pay_subscribe_info":{}}`},
+		{"HTML解码", `{a: 'a &amp;lt; &quot;b&quot;'.html(false), b: htmlDecode("&#39;x&#39;&nbsp;&yen;"), c: '<a>'.html(true), d: 'x&gt;'.htmlDecode()}`,
+			`{"a":"a &lt; \"b\"","b":"'x' ¥","c":"&lt;a&gt;","d":"x>"}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := JSLiteralToJSON(c.src)
+			if err != nil {
+				t.Fatalf("JSLiteralToJSON(%q) 出错: %v", c.src, err)
+			}
+			assertSameJSON(t, got, c.want)
+		})
+	}
+}
+
+func TestJSLiteralToJSONInvalid(t *testing.T) {
+	invalid := []string{
+		``,
+		`{`,
+		`{a: 1`,
+		`{a 1}`,
+		`{a: 'x}`,
+		`{a: parseInt('1')}`,
+		`{a: cgiData.b}`,
+		`{a: 'x'.replace('x', 'y')}`,
+		`{a: 1 .html(false)}`,
+		`{a: {}.html(false)}`,
+		`{a: 'x'.html(false}`,
+		`{a: 'x'.}`,
+		`{a: 1} extra`,
+		`{a: /* 没有结束`,
+		`{a: '\u12'}`,
+		`[1, 2`,
+		`{a: 1 ? 2}`,
+		strings.Repeat("[", 10000),
+	}
+	for _, src := range invalid {
+		if got, err := JSLiteralToJSON(src); err == nil {
+			t.Errorf("JSLiteralToJSON(%q) 应该出错，实际返回 %s", src, got)
+		}
+	}
+}
+
+func TestExtractJSAssignmentJSONAlbumFixture(t *testing.T) {
+	html, err := os.ReadFile("../../downloads/wx_album_index.html")
+	if err != nil {
+		t.Skipf("读取专辑首页样例失败: %v", err)
+	}
+
+	jsonStr, err := ExtractJSAssignmentJSON(string(html), "window.cgiData")
+	if err != nil {
+		t.Fatalf("解析 window.cgiData 失败: %v", err)
+	}
+
+	var cgiData struct {
+		Ret          string `json:"ret"`
+		Title        string `json:"title"`
+		NickName     string `json:"nick_name"`
+		ArticleCount int    `json:"article_count"`
+		ReadCount    string `json:"read_count"`
+		TotalOnread  int    `json:"total_onread"`
+		IsNumbered   int    `json:"is_numbered"`
+		IsReverse    int    `json:"is_reverse"`
+		ContinueFlag int    `json:"continue_flag"`
+		ArticleList  []struct {
+			Title      string `json:"title"`
+			URL        string `json:"url"`
+			MsgID      string `json:"msgid"`
+			CreateTime string `json:"create_time"`
+			ReadCount  int    `json:"read_count"`
+			CoverColor struct {
+				R string `json:"r"`
+			} `json:"cover_theme_color"`
+		} `json:"articleList"`
+	}
+	if err = json.Unmarshal([]byte(jsonStr), &cgiData); err != nil {
+		t.Fatalf("反序列化 window.cgiData 失败: %v", err)
+	}
+
+	if cgiData.Ret != "0" || cgiData.Title != "干货分享集" || cgiData.NickName != "一安未来" {
+		t.Errorf("专辑信息不正确: %+v", cgiData)
+	}
+	if cgiData.ArticleCount != 490 || cgiData.ReadCount != "64214" || cgiData.TotalOnread != -1 {
+		t.Errorf("专辑数值不正确: article_count=%d read_count=%s total_onread=%d", cgiData.ArticleCount, cgiData.ReadCount, cgiData.TotalOnread)
+	}
+	if cgiData.IsNumbered != 1 || cgiData.IsReverse != 1 || cgiData.ContinueFlag != 1 {
+		t.Errorf("专辑标志不正确: %+v", cgiData)
+	}
+	if len(cgiData.ArticleList) != 3 {
+		t.Fatalf("期望3篇文章，实际为 %d", len(cgiData.ArticleList))
+	}
+	first := cgiData.ArticleList[0]
+	if first.MsgID != "2247522881" || first.CreateTime != "1759100400" || first.ReadCount != -1 || first.CoverColor.R != "6" {
+		t.Errorf("第一篇文章信息不正确: %+v", first)
+	}
+	if !strings.HasPrefix(first.URL, "http://mp.weixin.qq.com/s?__biz=Mzg5MzgxMTIyOQ==&amp;mid=2247522881") {
+		t.Errorf("第一篇文章地址不正确: %s", first.URL)
+	}
+}
+
+func TestExtractJSAssignmentJSONArticleFixture(t *testing.T) {
+	html, err := os.ReadFile("../../downloads/wx_article_01.html")
+	if err != nil {
+		t.Skipf("读取文章样例失败: %v", err)
+	}
+
+	jsonStr, err := ExtractJSAssignmentJSON(string(html), "window.picture_page_info_list")
+	if err != nil {
+		t.Fatalf("解析 window.picture_page_info_list 失败: %v", err)
+	}
+
+	var pictures []struct {
+		Width         float64 `json:"width"`
+		CdnURL        string  `json:"cdn_url"`
+		ShowWatermark bool    `json:"show_watermark"`
+		WatermarkInfo *struct {
+			CdnURL string `json:"cdn_url"`
+		} `json:"watermark_info"`
+	}
+	if err = json.Unmarshal([]byte(jsonStr), &pictures); err != nil {
+		t.Fatalf("反序列化 window.picture_page_info_list 失败: %v", err)
+	}
+	if len(pictures) < 2 {
+		t.Fatalf("期望至少2张图片，实际为 %d", len(pictures))
+	}
+	if pictures[0].Width != 656 || pictures[0].ShowWatermark || pictures[0].WatermarkInfo != nil {
+		t.Errorf("第一张图片信息不正确: %+v", pictures[0])
+	}
+	if !pictures[1].ShowWatermark || pictures[1].WatermarkInfo == nil {
+		t.Errorf("第二张图片应该带有水印信息: %+v", pictures[1])
+	}
+	if !strings.Contains(pictures[0].CdnURL, "wx_fmt=gif&amp;amp;from=appmsg") {
+		t.Errorf("\\x26 转义没有被正确处理: %s", pictures[0].CdnURL)
+	}
+}
+
+func TestExtractJSAssignmentJSONArticleTitle(t *testing.T) {
+	html, err := os.ReadFile("../../downloads/wx_article_01.html")
+	if err != nil {
+		t.Skipf("读取文章样例失败: %v", err)
+	}
+
+	cases := map[string]string{
+		"var msg_title": `"拥抱大航海时代，堡森三通邀您共赴第19届深圳物博会！"`,
+		"var msg_desc":  `"锁定1B513-1B516展位，我们不见不散！"`,
+	}
+	for name, want := range cases {
+		got, err := ExtractJSAssignmentJSON(string(html), name)
+		if err != nil || got != want {
+			t.Errorf("%s 解析结果为 %s, %v", name, got, err)
+		}
+	}
+}
+
+func TestExtractJSAssignmentJSONNotFound(t *testing.T) {
+	content := `if (window.cgiData == null) {}; data.window.cgiData = {a: 1};`
+	if _, err := ExtractJSAssignmentJSON(content, "window.cgiData"); err == nil {
+		t.Error("比较表达式和属性赋值不应该被当作赋值语句")
+	}
+}
+
+// TestJSLiteralMutatedFixture 对专辑首页中的 cgiData 做随机变异，解析器不能崩溃，解析成功时必须输出合法的 JSON
+func TestJSLiteralMutatedFixture(t *testing.T) {
+	html, err := os.ReadFile("../../downloads/wx_album_index.html")
+	if err != nil {
+		t.Skipf("读取专辑首页样例失败: %v", err)
+	}
+	content := string(html)
+	start := strings.Index(content, "window.cgiData = ") + len("window.cgiData = ")
+	end := strings.Index(content[start:], "};") + start + 2
+	src := content[start:end]
+
+	alphabet := []byte("{}[](),:;?*/+-!|&='\"\\ \nabcxu0123456789")
+	rg := rand.New(rand.NewSource(20251008))
+	for i := 0; i < 2000; i++ {
+		b := []byte(src)
+		for n := rg.Intn(5) + 1; n > 0; n-- {
+			pos := rg.Intn(len(b))
+			switch rg.Intn(3) {
+			case 0: // 替换
+				b[pos] = alphabet[rg.Intn(len(alphabet))]
+			case 1: // 删除
+				b = append(b[:pos], b[pos+1:]...)
+			case 2: // 截断
+				b = b[:pos]
+			}
+			if len(b) == 0 {
+				break
+			}
+		}
+		checkJSLiteralOutput(t, string(b))
+	}
+}
+
+func FuzzJSLiteralToJSON(f *testing.F) {
+	seeds := []string{
+		`{ret: '0', a: '490' * 1, b: '' || -1, c: '' ? 1 : 2, d: [1, 2, ], e: 'x' === 'x'}`,
+		`{a: '\x26amp;', b: "中", /* c */ d: undefined}`,
+		`[{}, [], null, true, false, NaN, Infinity, -0x1F, .5e3]`,
+		`{a: ('1' + 1) * 1 / 2 - 3}`,
+		`{a: 'a &amp; b'.html(false), b: htmlDecode("&lt;")}`,
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		checkJSLiteralOutput(t, src)
+	})
+}
+
+func checkJSLiteralOutput(t *testing.T, src string) {
+	t.Helper()
+	got, err := JSLiteralToJSON(src)
+	if err != nil {
+		return
+	}
+	if !json.Valid([]byte(got)) {
+		t.Fatalf("JSLiteralToJSON(%q) 输出了不合法的JSON: %s", src, got)
+	}
+}
+
+func assertSameJSON(t *testing.T, got, want string) {
+	t.Helper()
+	var gotValue, wantValue interface{}
+	if err := json.Unmarshal([]byte(got), &gotValue); err != nil {
+		t.Fatalf("输出不是合法的JSON: %s", got)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("期望值不是合法的JSON: %s", want)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("期望 %s，实际为 %s", want, got)
+	}
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"image"
+	"math"
+
+	"github.com/pudongping/wx-graph-crawl/backend/constant"
+)
+
+// WatermarkStrip 图片底部识别出的水印条
+type WatermarkStrip struct {
+	Height     int // 水印条高度（像素），即需要从底部裁掉的高度
+	TextHeight int // 水印文字的高度（像素）
+}
+
+type watermarkRowKind int
+
+const (
+	watermarkRowUniform watermarkRowKind = iota // 纯色行
+	watermarkRowText                            // 背景色上有少量文字笔画的行
+	watermarkRowContent                         // 图片内容
+)
+
+// DetectWatermarkStrip 识别图片底部的水印条
+// 微信的水印条为底部一段纯色背景，中间有一行文字：从底部往上依次是纯色留白、文字、纯色留白，再往上是图片内容
+// 只有底部的行足够均匀、文字高度和水印条高度都在合理范围内时才返回 true，无法确定时返回 false
+func DetectWatermarkStrip(img image.Image) (WatermarkStrip, bool) {
+	var strip WatermarkStrip
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 16 || height < 16 {
+		return strip, false
+	}
+
+	step := max(1, width/constant.WatermarkStripSampleWidth)
+	lumaRow := func(offset int) []float64 {
+		y := bounds.Max.Y - 1 - offset
+		row := make([]float64, 0, width/step+1)
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, _ := img.At(x, y).RGBA()
+			row = append(row, (0.299*float64(r)+0.587*float64(g)+0.114*float64(b))/257)
+		}
+		return row
+	}
+
+	// 最底部的行必须是纯色，用它的亮度作为水印条的背景
+	mean, stdDev := lumaStats(lumaRow(0))
+	if stdDev > constant.WatermarkStripUniformStdDev {
+		return strip, false
+	}
+	classify := func(offset int) watermarkRowKind {
+		row := lumaRow(offset)
+		bgCount := 0
+		for _, luma := range row {
+			if math.Abs(luma-mean) <= constant.WatermarkStripLumaTolerance {
+				bgCount++
+			}
+		}
+		ratio := float64(bgCount) / float64(len(row))
+		switch {
+		case ratio >= constant.WatermarkStripUniformRowRatio:
+			return watermarkRowUniform
+		case ratio >= constant.WatermarkStripTextRowMinBgRatio:
+			return watermarkRowText
+		default:
+			return watermarkRowContent
+		}
+	}
+
+	searchRows := int(float64(height) * constant.WatermarkStripSearchRatio)
+	// 1. 文字下方的留白
+	offset := 0
+	for offset < searchRows && classify(offset) == watermarkRowUniform {
+		offset++
+	}
+	bottomPadding := offset
+
+	// 2. 文字，笔画之间允许有少量纯色行
+	maxGap := max(2, height/200)
+	textStart, lastText := offset, -1
+	for ; offset < searchRows; offset++ {
+		kind := classify(offset)
+		if kind == watermarkRowText {
+			lastText = offset
+			continue
+		}
+		if kind == watermarkRowUniform && lastText >= 0 && offset-lastText <= maxGap {
+			continue
+		}
+		break
+	}
+	if lastText < 0 || offset >= searchRows {
+		return strip, false
+	}
+	strip.TextHeight = lastText + 1 - textStart
+	maxTextRows := max(constant.WatermarkStripMinTextRows*3, int(float64(height)*constant.WatermarkStripMaxTextRatio))
+	if strip.TextHeight < constant.WatermarkStripMinTextRows || strip.TextHeight > maxTextRows {
+		return strip, false
+	}
+
+	// 3. 文字上方的留白，与下方留白一样高，遇到图片内容时停止
+	offset = lastText + 1
+	topPadding := 0
+	for offset < searchRows && topPadding < max(bottomPadding, 1) && classify(offset) == watermarkRowUniform {
+		offset++
+		topPadding++
+	}
+
+	strip.Height = offset
+	if float64(strip.Height) > float64(height)*constant.WatermarkStripMaxRatio {
+		return strip, false
+	}
+	return strip, true
+}
+
+// lumaStats 计算亮度的平均值和标准差
+func lumaStats(row []float64) (mean, stdDev float64) {
+	for _, v := range row {
+		mean += v
+	}
+	mean /= float64(len(row))
+	for _, v := range row {
+		stdDev += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(stdDev / float64(len(row)))
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// newWatermarkTestImage 生成测试图片：上方为图片内容，底部 stripHeight 像素为白色水印条，水印条中间有一行文字
+func newWatermarkTestImage(width, height, stripHeight, textHeight int, whiteContent bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := white
+			if y < height-stripHeight {
+				if whiteContent {
+					// 白底的图片内容，底部留有和水印条相同颜色的空白
+					if y < height-stripHeight-40 {
+						c = color.NRGBA{R: 40, G: 40, B: 40, A: 255}
+					}
+				} else {
+					v := uint8((x*7 + y*13) % 256)
+					c = color.NRGBA{R: v, G: 255 - v, B: v / 2, A: 255}
+				}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	// 水印文字：靠右的一行笔画
+	textTop := height - stripHeight + (stripHeight-textHeight)/2
+	for y := textTop; y < textTop+textHeight && textHeight > 0; y++ {
+		for x := width * 2 / 3; x < width-20; x++ {
+			if (x/3)%3 == 0 {
+				img.Set(x, y, color.NRGBA{R: 90, G: 90, B: 90, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestDetectWatermarkStrip(t *testing.T) {
+	cases := []struct {
+		name       string
+		img        image.Image
+		wantOK     bool
+		wantHeight int
+	}{
+		{name: "照片底部的水印条", img: newWatermarkTestImage(600, 800, 60, 20, false), wantOK: true, wantHeight: 60},
+		{name: "白底图片底部的水印条", img: newWatermarkTestImage(600, 800, 60, 20, true), wantOK: true, wantHeight: 60},
+		{name: "没有水印条", img: newWatermarkTestImage(600, 800, 0, 0, false), wantOK: false},
+		{name: "底部只有留白没有文字", img: newWatermarkTestImage(600, 800, 60, 0, false), wantOK: false},
+		{name: "文字过高", img: newWatermarkTestImage(600, 800, 150, 120, false), wantOK: false},
+		{name: "纯色图片", img: newWatermarkTestImage(600, 800, 800, 0, false), wantOK: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			strip, ok := DetectWatermarkStrip(c.img)
+			if ok != c.wantOK {
+				t.Fatalf("识别结果为 %v（%+v），应为 %v", ok, strip, c.wantOK)
+			}
+			if ok && strip.Height != c.wantHeight {
+				t.Errorf("水印条高度为 %d，应为 %d", strip.Height, c.wantHeight)
+			}
+		})
+	}
+}
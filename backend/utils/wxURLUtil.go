@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	wxArticleHost      = "mp.weixin.qq.com"
+	wxArticlePath      = "/s"
+	wxArticleKeyPrefix = "wx:"       // 长链接的文章标识前缀
+	wxShortKeyPrefix   = "wx-short:" // 短链接的文章标识前缀
+)
+
+// WXArticleURL 规范化后的微信公众号文章地址
+type WXArticleURL struct {
+	Canonical string // 规范化后的地址，只保留 __biz、mid、idx、sn 参数，统一使用 https
+	Key       string // 文章的唯一标识，同一篇文章的不同地址得到的标识相同
+	Biz       string
+	Mid       string
+	Idx       string
+	Sn        string
+	ShortID   string // 短链接 /s/<id> 中的 id，长链接时为空
+}
+
+// IsShort 是否为还没有解析出长链接参数的短链接
+func (u WXArticleURL) IsShort() bool {
+	return u.ShortID != "" && u.Mid == ""
+}
+
+// NormalizeWXArticleURL 规范化微信公众号文章地址
+// 支持 /s/<id> 短链接和 /s?__biz=..&mid=..&idx=..&sn=.. 长链接，会还原 &amp; 转义，去掉 chksm、scene、sessionid 等跟踪参数和 #rd 锚点
+func NormalizeWXArticleURL(rawURL string) (WXArticleURL, error) {
+	var res WXArticleURL
+
+	rawURL = strings.TrimSpace(rawURL)
+	for strings.Contains(rawURL, "&amp;") {
+		rawURL = strings.ReplaceAll(rawURL, "&amp;", "&")
+	}
+	if strings.HasPrefix(rawURL, "//") {
+		rawURL = "https:" + rawURL
+	}
+
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return res, errors.Wrap(err, "解析文章地址失败")
+	}
+	if scheme := strings.ToLower(parsedURL.Scheme); scheme != "http" && scheme != "https" {
+		return res, errors.Errorf("不是 http/https 地址: %s", rawURL)
+	}
+	if strings.ToLower(parsedURL.Hostname()) != wxArticleHost {
+		return res, errors.Errorf("不是微信公众号文章地址: %s", rawURL)
+	}
+
+	path := strings.TrimSuffix(parsedURL.Path, "/")
+	switch {
+	case path == wxArticlePath || path == "/mp/appmsg/show":
+		query := parsedURL.Query()
+		// __biz 是 base64 编码，未转义的 + 会被当作空格解析
+		res.Biz = strings.ReplaceAll(query.Get("__biz"), " ", "+")
+		res.Mid = firstQueryValue(query, "mid", "appmsgid")
+		res.Idx = firstQueryValue(query, "idx", "itemidx")
+		res.Sn = firstQueryValue(query, "sn", "signature")
+		if res.Biz == "" || res.Mid == "" {
+			return res, errors.Errorf("文章地址缺少 __biz 或 mid 参数: %s", rawURL)
+		}
+		if res.Idx == "" {
+			res.Idx = "1"
+		}
+	case strings.HasPrefix(path, wxArticlePath+"/"):
+		res.ShortID = strings.TrimPrefix(path, wxArticlePath+"/")
+		if res.ShortID == "" || strings.Contains(res.ShortID, "/") {
+			return res, errors.Errorf("文章短链接格式不正确: %s", rawURL)
+		}
+	default:
+		return res, errors.Errorf("不是微信公众号文章地址: %s", rawURL)
+	}
+
+	res.fill()
+	return res, nil
+}
+
+// WXArticleKey 获取文章的唯一标识，无法规范化时返回去掉首尾空白的原地址
+func WXArticleKey(rawURL string) string {
+	if u, err := NormalizeWXArticleURL(rawURL); err == nil {
+		return u.Key
+	}
+	return strings.TrimSpace(rawURL)
+}
+
+// ResolveWXArticleURLFromHTML 从文章页面的 HTML 中读取 biz、mid、idx、sn，用于将短链接解析为长链接
+func ResolveWXArticleURLFromHTML(short WXArticleURL, html string) (WXArticleURL, error) {
+	res := short
+	values := make(map[string]string, 4)
+	for _, name := range []string{"biz", "mid", "idx", "sn"} {
+		jsonStr, err := ExtractJSAssignmentJSON(html, "var "+name)
+		if err != nil {
+			continue
+		}
+		var value interface{}
+		if err = json.Unmarshal([]byte(jsonStr), &value); err != nil {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			values[name] = v
+		case float64:
+			values[name] = jsonNumber(v)
+		}
+	}
+	if values["biz"] == "" || values["mid"] == "" {
+		return res, errors.New("页面中没有找到文章的 biz 和 mid 信息")
+	}
+
+	res.Biz, res.Mid, res.Idx, res.Sn = values["biz"], values["mid"], values["idx"], values["sn"]
+	if res.Idx == "" {
+		res.Idx = "1"
+	}
+	res.fill()
+	return res, nil
+}
+
+// fill 根据已解析的参数生成规范化地址和唯一标识
+func (u *WXArticleURL) fill() {
+	if u.Mid == "" {
+		u.Canonical = "https://" + wxArticleHost + wxArticlePath + "/" + u.ShortID
+		u.Key = wxShortKeyPrefix + u.ShortID
+		return
+	}
+
+	// 参数顺序固定，保证同一篇文章得到的地址完全相同
+	var sb strings.Builder
+	sb.WriteString("https://" + wxArticleHost + wxArticlePath + "?__biz=")
+	sb.WriteString(bizEscaper.Replace(u.Biz)) // 与微信的地址保持一致，不转义末尾的 =
+	sb.WriteString("&mid=" + url.QueryEscape(u.Mid))
+	sb.WriteString("&idx=" + url.QueryEscape(u.Idx))
+	if u.Sn != "" {
+		sb.WriteString("&sn=" + url.QueryEscape(u.Sn))
+	}
+	u.Canonical = sb.String()
+	u.Key = wxArticleKeyPrefix + u.Biz + "_" + u.Mid + "_" + u.Idx
+}
+
+var bizEscaper = strings.NewReplacer("+", "%2B", "/", "%2F", "&", "%26", "#", "%23")
+
+func firstQueryValue(query url.Values, keys ...string) string {
+	for _, key := range keys {
+		if v := query.Get(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func jsonNumber(f float64) string {
+	b, _ := json.Marshal(f)
+	return string(b)
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"os"
+	"testing"
+)
+
+func TestNormalizeWXArticleURL(t *testing.T) {
+	const (
+		canonical = "https://mp.weixin.qq.com/s?__biz=Mzg5MzgxMTIyOQ==&mid=2247522881&idx=1&sn=5a6c2c1ec214ec411fcff1a2a9a7053c"
+		key       = "wx:Mzg5MzgxMTIyOQ==_2247522881_1"
+	)
+	longForms := []string{
+		canonical,
+		"http://mp.weixin.qq.com/s?__biz=Mzg5MzgxMTIyOQ==&amp;mid=2247522881&amp;idx=1&amp;sn=5a6c2c1ec214ec411fcff1a2a9a7053c&amp;chksm=c02bf533f75c7c25#rd",
+		"https://mp.weixin.qq.com/s?__biz=Mzg5MzgxMTIyOQ%3D%3D&mid=2247522881&idx=1&sn=5a6c2c1ec214ec411fcff1a2a9a7053c&scene=21&sessionid=1759862015&subscene=&clicktime=1#wechat_redirect",
+		"  https://MP.weixin.qq.com/s/?idx=1&sn=5a6c2c1ec214ec411fcff1a2a9a7053c&mid=2247522881&__biz=Mzg5MzgxMTIyOQ==&amp;amp;chksm=1  ",
+		"https://mp.weixin.qq.com/mp/appmsg/show?__biz=Mzg5MzgxMTIyOQ==&appmsgid=2247522881&itemidx=1&sn=5a6c2c1ec214ec411fcff1a2a9a7053c",
+	}
+	for _, rawURL := range longForms {
+		u, err := NormalizeWXArticleURL(rawURL)
+		if err != nil {
+			t.Errorf("NormalizeWXArticleURL(%q) 出错: %v", rawURL, err)
+			continue
+		}
+		if u.Canonical != canonical || u.Key != key || u.IsShort() {
+			t.Errorf("NormalizeWXArticleURL(%q) = %+v", rawURL, u)
+		}
+	}
+
+	short, err := NormalizeWXArticleURL("http://mp.weixin.qq.com/s/w-lFW8AjuHRKSY74UIGwxQ?scene=1#rd")
+	if err != nil {
+		t.Fatalf("解析短链接出错: %v", err)
+	}
+	if short.Canonical != "https://mp.weixin.qq.com/s/w-lFW8AjuHRKSY74UIGwxQ" || short.Key != "wx-short:w-lFW8AjuHRKSY74UIGwxQ" || !short.IsShort() {
+		t.Errorf("短链接解析结果不正确: %+v", short)
+	}
+
+	invalid := []string{
+		"",
+		"ftp://mp.weixin.qq.com/s/abc",
+		"https://example.com/s/abc",
+		"https://mp.weixin.qq.com/mp/appmsgalbum?__biz=Mzg5MzgxMTIyOQ==&action=getalbum",
+		"https://mp.weixin.qq.com/s?__biz=Mzg5MzgxMTIyOQ==",
+		"https://mp.weixin.qq.com/s/",
+	}
+	for _, rawURL := range invalid {
+		if u, err := NormalizeWXArticleURL(rawURL); err == nil {
+			t.Errorf("NormalizeWXArticleURL(%q) 应该出错，实际返回 %+v", rawURL, u)
+		}
+	}
+
+	if got := WXArticleKey(" https://example.com/a "); got != "https://example.com/a" {
+		t.Errorf("无法规范化的地址应使用原地址作为标识，实际为 %s", got)
+	}
+}
+
+func TestResolveWXArticleURLFromHTML(t *testing.T) {
+	html, err := os.ReadFile("../../downloads/wx_article_01.html")
+	if err != nil {
+		t.Skipf("读取文章样例失败: %v", err)
+	}
+
+	short, err := NormalizeWXArticleURL("https://mp.weixin.qq.com/s/w-lFW8AjuHRKSY74UIGwxQ")
+	if err != nil {
+		t.Fatalf("解析短链接出错: %v", err)
+	}
+	resolved, err := ResolveWXArticleURLFromHTML(short, string(html))
+	if err != nil {
+		t.Fatalf("从页面解析长链接失败: %v", err)
+	}
+	want := "https://mp.weixin.qq.com/s?__biz=MzIwMzQwODg3MQ==&mid=2247507554&idx=2&sn=f21300108eeb95a8dca484cd3bc0a4c3"
+	if resolved.Canonical != want || resolved.Key != "wx:MzIwMzQwODg3MQ==_2247507554_2" || resolved.IsShort() {
+		t.Errorf("长链接解析结果不正确: %+v", resolved)
+	}
+	if WXArticleKey(want+"&chksm=abc#rd") != resolved.Key {
+		t.Error("短链接解析后的标识应与长链接一致")
+	}
+}
@@ -3,10 +3,28 @@
 import {types} from '../models';
 import {context} from '../models';
 
+export function ConvertImagesToJPEG(arg1:string,arg2:Array<types.ImageFormat>):Promise<types.ImagePipelineResponse>;
+
 export function Crawling(arg1:types.CrawlingRequest):Promise<types.CrawlingResponse>;
 
 export function Cropping(arg1:types.CroppingRequest):Promise<types.CroppingResponse>;
 
+export function PreviewCrop(arg1:string,arg2:types.PreviewCropRequest):Promise<types.PreviewCropResponse>;
+
+export function ProcessImages(arg1:types.ImagePipelineRequest):Promise<types.ImagePipelineResponse>;
+
+export function RecompressImages(arg1:string,arg2:number,arg3:types.PNGCompression):Promise<types.ImagePipelineResponse>;
+
+export function ResizeImages(arg1:string,arg2:number):Promise<types.ImagePipelineResponse>;
+
 export function SetContext(arg1:context.Context):Promise<void>;
 
 export function Shuffling(arg1:types.ShufflingRequest):Promise<types.ShufflingResponse>;
+
+export function StripImageMetadata(arg1:string,arg2:boolean):Promise<types.ImagePipelineResponse>;
+
+export function UndoCrop(arg1:string):Promise<types.UndoCropResponse>;
+
+export function UndoShuffle(arg1:string):Promise<types.UndoShuffleResponse>;
+
+export function Watermarking(arg1:types.WatermarkingRequest):Promise<types.WatermarkingResponse>;
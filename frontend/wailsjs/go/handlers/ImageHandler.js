@@ -2,6 +2,10 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function ConvertImagesToJPEG(arg1, arg2) {
+  return window['go']['handlers']['ImageHandler']['ConvertImagesToJPEG'](arg1, arg2);
+}
+
 export function Crawling(arg1) {
   return window['go']['handlers']['ImageHandler']['Crawling'](arg1);
 }
@@ -10,6 +14,22 @@ export function Cropping(arg1) {
   return window['go']['handlers']['ImageHandler']['Cropping'](arg1);
 }
 
+export function PreviewCrop(arg1, arg2) {
+  return window['go']['handlers']['ImageHandler']['PreviewCrop'](arg1, arg2);
+}
+
+export function ProcessImages(arg1) {
+  return window['go']['handlers']['ImageHandler']['ProcessImages'](arg1);
+}
+
+export function RecompressImages(arg1, arg2, arg3) {
+  return window['go']['handlers']['ImageHandler']['RecompressImages'](arg1, arg2, arg3);
+}
+
+export function ResizeImages(arg1, arg2) {
+  return window['go']['handlers']['ImageHandler']['ResizeImages'](arg1, arg2);
+}
+
 export function SetContext(arg1) {
   return window['go']['handlers']['ImageHandler']['SetContext'](arg1);
 }
@@ -17,3 +37,19 @@ export function SetContext(arg1) {
 export function Shuffling(arg1) {
   return window['go']['handlers']['ImageHandler']['Shuffling'](arg1);
 }
+
+export function StripImageMetadata(arg1, arg2) {
+  return window['go']['handlers']['ImageHandler']['StripImageMetadata'](arg1, arg2);
+}
+
+export function UndoCrop(arg1) {
+  return window['go']['handlers']['ImageHandler']['UndoCrop'](arg1);
+}
+
+export function UndoShuffle(arg1) {
+  return window['go']['handlers']['ImageHandler']['UndoShuffle'](arg1);
+}
+
+export function Watermarking(arg1) {
+  return window['go']['handlers']['ImageHandler']['Watermarking'](arg1);
+}
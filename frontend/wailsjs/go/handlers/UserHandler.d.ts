@@ -5,6 +5,12 @@ import {context} from '../models';
 
 export function GetPreferenceInfo():Promise<types.GetPreferenceInfoResponse>;
 
+export function GetSettingsSchema():Promise<Array<types.SettingDefinition>>;
+
+export function ListPreferenceHistory():Promise<Array<types.SettingHistoryItem>>;
+
+export function RollbackPreferenceInfo(arg1:number):Promise<types.RollbackPreferenceInfoResponse>;
+
 export function SetContext(arg1:context.Context):Promise<void>;
 
 export function SetPreferenceInfo(arg1:types.SetPreferenceInfoRequest):Promise<types.SetPreferenceInfoResponse>;
@@ -6,6 +6,18 @@ export function GetPreferenceInfo() {
   return window['go']['handlers']['UserHandler']['GetPreferenceInfo']();
 }
 
+export function GetSettingsSchema() {
+  return window['go']['handlers']['UserHandler']['GetSettingsSchema']();
+}
+
+export function ListPreferenceHistory() {
+  return window['go']['handlers']['UserHandler']['ListPreferenceHistory']();
+}
+
+export function RollbackPreferenceInfo(arg1) {
+  return window['go']['handlers']['UserHandler']['RollbackPreferenceInfo'](arg1);
+}
+
 export function SetContext(arg1) {
   return window['go']['handlers']['UserHandler']['SetContext'](arg1);
 }
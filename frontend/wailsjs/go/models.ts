@@ -1,9 +1,365 @@
 export namespace types {
 	
+	export class AlbumArticleInfo {
+	    index: number;
+	    title: string;
+	    url: string;
+	    status: string;
+	    msgid: string;
+	    itemidx: string;
+	    create_time: number;
+	    cover_url: string;
+	    read_count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlbumArticleInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.index = source["index"];
+	        this.title = source["title"];
+	        this.url = source["url"];
+	        this.status = source["status"];
+	        this.msgid = source["msgid"];
+	        this.itemidx = source["itemidx"];
+	        this.create_time = source["create_time"];
+	        this.cover_url = source["cover_url"];
+	        this.read_count = source["read_count"];
+	    }
+	}
+	export class AlbumFetchResult {
+	    album_title: string;
+	    nick_name: string;
+	    article_count: number;
+	    is_numbered: boolean;
+	    is_reverse: boolean;
+	    urls: string[];
+	    articles: AlbumArticleInfo[];
+	    page_count: number;
+	    truncated: boolean;
+	    truncated_reason: string;
+	    next_begin_msgid: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlbumFetchResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.album_title = source["album_title"];
+	        this.nick_name = source["nick_name"];
+	        this.article_count = source["article_count"];
+	        this.is_numbered = source["is_numbered"];
+	        this.is_reverse = source["is_reverse"];
+	        this.urls = source["urls"];
+	        this.articles = this.convertValues(source["articles"], AlbumArticleInfo);
+	        this.page_count = source["page_count"];
+	        this.truncated = source["truncated"];
+	        this.truncated_reason = source["truncated_reason"];
+	        this.next_begin_msgid = source["next_begin_msgid"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AlbumPagingOptions {
+	    page_size: number;
+	    interval_millis: number;
+	    max_pages: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlbumPagingOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.page_size = source["page_size"];
+	        this.interval_millis = source["interval_millis"];
+	        this.max_pages = source["max_pages"];
+	    }
+	}
+	export class AlbumSubscription {
+	    id: number;
+	    album_url: string;
+	    biz: string;
+	    album_id: string;
+	    title: string;
+	    nick_name: string;
+	    interval_minutes: number;
+	    enabled: boolean;
+	    crawl_existing: boolean;
+	    last_synced_at: number;
+	    next_sync_at: number;
+	    created_at: number;
+	    updated_at: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlbumSubscription(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.album_url = source["album_url"];
+	        this.biz = source["biz"];
+	        this.album_id = source["album_id"];
+	        this.title = source["title"];
+	        this.nick_name = source["nick_name"];
+	        this.interval_minutes = source["interval_minutes"];
+	        this.enabled = source["enabled"];
+	        this.crawl_existing = source["crawl_existing"];
+	        this.last_synced_at = source["last_synced_at"];
+	        this.next_sync_at = source["next_sync_at"];
+	        this.created_at = source["created_at"];
+	        this.updated_at = source["updated_at"];
+	    }
+	}
+	export class AlbumSubscriptionRun {
+	    id: number;
+	    subscription_id: number;
+	    status: string;
+	    article_count: number;
+	    new_article_count: number;
+	    crawl_img_count: number;
+	    save_path: string;
+	    err_content: string;
+	    started_at: number;
+	    finished_at: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlbumSubscriptionRun(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.subscription_id = source["subscription_id"];
+	        this.status = source["status"];
+	        this.article_count = source["article_count"];
+	        this.new_article_count = source["new_article_count"];
+	        this.crawl_img_count = source["crawl_img_count"];
+	        this.save_path = source["save_path"];
+	        this.err_content = source["err_content"];
+	        this.started_at = source["started_at"];
+	        this.finished_at = source["finished_at"];
+	    }
+	}
+	export class BuildBundlesRequest {
+	    img_save_path: string;
+	    output_dir: string;
+	    article_keys: string[];
+	    max_images: number;
+	    hashtags: string[];
+	    cover_rule: string;
+	    zip: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new BuildBundlesRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.img_save_path = source["img_save_path"];
+	        this.output_dir = source["output_dir"];
+	        this.article_keys = source["article_keys"];
+	        this.max_images = source["max_images"];
+	        this.hashtags = source["hashtags"];
+	        this.cover_rule = source["cover_rule"];
+	        this.zip = source["zip"];
+	    }
+	}
+	export class PublishBundle {
+	    name: string;
+	    dir: string;
+	    zip_path: string;
+	    article_url: string;
+	    title: string;
+	    image_count: number;
+	    cover_path: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PublishBundle(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.dir = source["dir"];
+	        this.zip_path = source["zip_path"];
+	        this.article_url = source["article_url"];
+	        this.title = source["title"];
+	        this.image_count = source["image_count"];
+	        this.cover_path = source["cover_path"];
+	    }
+	}
+	export class BuildBundlesResponse {
+	    output_dir: string;
+	    bundles: PublishBundle[];
+	    skipped_image_count: number;
+	    err_content: string;
+	    cast_time_str: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BuildBundlesResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.output_dir = source["output_dir"];
+	        this.bundles = this.convertValues(source["bundles"], PublishBundle);
+	        this.skipped_image_count = source["skipped_image_count"];
+	        this.err_content = source["err_content"];
+	        this.cast_time_str = source["cast_time_str"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class BundleArticle {
+	    article_key: string;
+	    url: string;
+	    seq: number;
+	    title: string;
+	    image_count: number;
+	    crawled_at: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BundleArticle(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.article_key = source["article_key"];
+	        this.url = source["url"];
+	        this.seq = source["seq"];
+	        this.title = source["title"];
+	        this.image_count = source["image_count"];
+	        this.crawled_at = source["crawled_at"];
+	    }
+	}
+	export class CrawlRun {
+	    id: number;
+	    status: string;
+	    save_path: string;
+	    url_count: number;
+	    success_count: number;
+	    failed_count: number;
+	    img_count: number;
+	    processed_img_count: number;
+	    err_content: string;
+	    duration_ms: number;
+	    started_at: number;
+	    finished_at: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CrawlRun(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.status = source["status"];
+	        this.save_path = source["save_path"];
+	        this.url_count = source["url_count"];
+	        this.success_count = source["success_count"];
+	        this.failed_count = source["failed_count"];
+	        this.img_count = source["img_count"];
+	        this.processed_img_count = source["processed_img_count"];
+	        this.err_content = source["err_content"];
+	        this.duration_ms = source["duration_ms"];
+	        this.started_at = source["started_at"];
+	        this.finished_at = source["finished_at"];
+	    }
+	}
+	export class CrawlRunArticle {
+	    id: number;
+	    run_id: number;
+	    article_key: string;
+	    url: string;
+	    title: string;
+	    status: string;
+	    img_count: number;
+	    img_dir: string;
+	    err_content: string;
+	    created_at: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CrawlRunArticle(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.run_id = source["run_id"];
+	        this.article_key = source["article_key"];
+	        this.url = source["url"];
+	        this.title = source["title"];
+	        this.status = source["status"];
+	        this.img_count = source["img_count"];
+	        this.img_dir = source["img_dir"];
+	        this.err_content = source["err_content"];
+	        this.created_at = source["created_at"];
+	    }
+	}
+	export class ImagePipelineOptions {
+	    max_dimension: number;
+	    jpeg_quality: number;
+	    png_compression: string;
+	    convert_to_jpeg: string[];
+	    auto_orient: boolean;
+	    strip_metadata: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImagePipelineOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.max_dimension = source["max_dimension"];
+	        this.jpeg_quality = source["jpeg_quality"];
+	        this.png_compression = source["png_compression"];
+	        this.convert_to_jpeg = source["convert_to_jpeg"];
+	        this.auto_orient = source["auto_orient"];
+	        this.strip_metadata = source["strip_metadata"];
+	    }
+	}
 	export class CrawlingRequest {
 	    img_save_path: string;
 	    img_urls: string[];
 	    timeout_seconds: number;
+	    album_articles: AlbumArticleInfo[];
+	    post_process?: ImagePipelineOptions;
 	
 	    static createFrom(source: any = {}) {
 	        return new CrawlingRequest(source);
@@ -14,8 +370,105 @@ export namespace types {
 	        this.img_save_path = source["img_save_path"];
 	        this.img_urls = source["img_urls"];
 	        this.timeout_seconds = source["timeout_seconds"];
+	        this.album_articles = this.convertValues(source["album_articles"], AlbumArticleInfo);
+	        this.post_process = this.convertValues(source["post_process"], ImagePipelineOptions);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CrawlRunDetail {
+	    id: number;
+	    status: string;
+	    save_path: string;
+	    url_count: number;
+	    success_count: number;
+	    failed_count: number;
+	    img_count: number;
+	    processed_img_count: number;
+	    err_content: string;
+	    duration_ms: number;
+	    started_at: number;
+	    finished_at: number;
+	    requested_urls: string[];
+	    request: CrawlingRequest;
+	    articles: CrawlRunArticle[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CrawlRunDetail(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.status = source["status"];
+	        this.save_path = source["save_path"];
+	        this.url_count = source["url_count"];
+	        this.success_count = source["success_count"];
+	        this.failed_count = source["failed_count"];
+	        this.img_count = source["img_count"];
+	        this.processed_img_count = source["processed_img_count"];
+	        this.err_content = source["err_content"];
+	        this.duration_ms = source["duration_ms"];
+	        this.started_at = source["started_at"];
+	        this.finished_at = source["finished_at"];
+	        this.requested_urls = source["requested_urls"];
+	        this.request = this.convertValues(source["request"], CrawlingRequest);
+	        this.articles = this.convertValues(source["articles"], CrawlRunArticle);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CrawlRunFilter {
+	    status: string;
+	    keyword: string;
+	    started_from: number;
+	    started_to: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CrawlRunFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.status = source["status"];
+	        this.keyword = source["keyword"];
+	        this.started_from = source["started_from"];
+	        this.started_to = source["started_to"];
 	    }
 	}
+	
 	export class CrawlingResponse {
 	    text_content_save_dir: string;
 	    text_content_save_path: string;
@@ -23,6 +476,8 @@ export namespace types {
 	    crawl_url_count: number;
 	    crawl_img_count: number;
 	    word_docs_count: number;
+	    processed_img_count: number;
+	    succeeded_urls: string[];
 	    err_content: string;
 	    cast_time_str: string;
 	
@@ -38,13 +493,120 @@ export namespace types {
 	        this.crawl_url_count = source["crawl_url_count"];
 	        this.crawl_img_count = source["crawl_img_count"];
 	        this.word_docs_count = source["word_docs_count"];
+	        this.processed_img_count = source["processed_img_count"];
+	        this.succeeded_urls = source["succeeded_urls"];
 	        this.err_content = source["err_content"];
 	        this.cast_time_str = source["cast_time_str"];
 	    }
 	}
+	export class CreateAlbumSubscriptionRequest {
+	    album_url: string;
+	    interval_minutes: number;
+	    crawl_existing: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new CreateAlbumSubscriptionRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.album_url = source["album_url"];
+	        this.interval_minutes = source["interval_minutes"];
+	        this.crawl_existing = source["crawl_existing"];
+	    }
+	}
+	export class CropPreviewImage {
+	    width: number;
+	    height: number;
+	    data_uri: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CropPreviewImage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.data_uri = source["data_uri"];
+	    }
+	}
+	export class CropPreviewItem {
+	    img_path: string;
+	    before: CropPreviewImage;
+	    after: CropPreviewImage;
+	    bottom_pixel: number;
+	    auto_detected: boolean;
+	    err: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CropPreviewItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.img_path = source["img_path"];
+	        this.before = this.convertValues(source["before"], CropPreviewImage);
+	        this.after = this.convertValues(source["after"], CropPreviewImage);
+	        this.bottom_pixel = source["bottom_pixel"];
+	        this.auto_detected = source["auto_detected"];
+	        this.err = source["err"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ImageFileFilter {
+	    include: string[];
+	    exclude: string[];
+	    no_default_excludes: boolean;
+	    max_depth: number;
+	    dirs: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageFileFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.include = source["include"];
+	        this.exclude = source["exclude"];
+	        this.no_default_excludes = source["no_default_excludes"];
+	        this.max_depth = source["max_depth"];
+	        this.dirs = source["dirs"];
+	    }
+	}
 	export class CroppingRequest {
 	    img_save_path: string;
 	    bottom_pixel: number;
+	    top: number;
+	    bottom: number;
+	    left: number;
+	    right: number;
+	    unit: string;
+	    auto_bottom: boolean;
+	    aspect_ratio: string;
+	    anchor: string;
+	    max_width: number;
+	    max_height: number;
+	    mode: string;
+	    output_dir: string;
+	    filter: ImageFileFilter;
 	
 	    static createFrom(source: any = {}) {
 	        return new CroppingRequest(source);
@@ -54,11 +616,45 @@ export namespace types {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.img_save_path = source["img_save_path"];
 	        this.bottom_pixel = source["bottom_pixel"];
+	        this.top = source["top"];
+	        this.bottom = source["bottom"];
+	        this.left = source["left"];
+	        this.right = source["right"];
+	        this.unit = source["unit"];
+	        this.auto_bottom = source["auto_bottom"];
+	        this.aspect_ratio = source["aspect_ratio"];
+	        this.anchor = source["anchor"];
+	        this.max_width = source["max_width"];
+	        this.max_height = source["max_height"];
+	        this.mode = source["mode"];
+	        this.output_dir = source["output_dir"];
+	        this.filter = this.convertValues(source["filter"], ImageFileFilter);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class CroppingResponse {
 	    crop_img_path: string;
+	    job_id: string;
+	    backup_dir: string;
 	    crop_img_count: number;
+	    auto_detected: number;
 	    err_content: string;
 	    cast_time_str: string;
 	
@@ -69,96 +665,1202 @@ export namespace types {
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.crop_img_path = source["crop_img_path"];
+	        this.job_id = source["job_id"];
+	        this.backup_dir = source["backup_dir"];
 	        this.crop_img_count = source["crop_img_count"];
+	        this.auto_detected = source["auto_detected"];
 	        this.err_content = source["err_content"];
 	        this.cast_time_str = source["cast_time_str"];
 	    }
 	}
-	export class GetPreferenceInfoResponse {
-	    save_img_path: string;
-	    download_timeout: number;
-	    crop_img_bottom_pixel: number;
-	    updated_time: number;
+	export class DeleteCrawlRunResponse {
+	    id: number;
+	    deleted_files: string[];
+	    kept_files: string[];
+	    err_content: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new GetPreferenceInfoResponse(source);
+	        return new DeleteCrawlRunResponse(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.save_img_path = source["save_img_path"];
-	        this.download_timeout = source["download_timeout"];
-	        this.crop_img_bottom_pixel = source["crop_img_bottom_pixel"];
-	        this.updated_time = source["updated_time"];
+	        this.id = source["id"];
+	        this.deleted_files = source["deleted_files"];
+	        this.kept_files = source["kept_files"];
+	        this.err_content = source["err_content"];
 	    }
 	}
-	export class SelectFileResponse {
-	    file_path: string;
-	    valid_urls: string[];
+	export class DuplicateImage {
+	    path: string;
+	    width: number;
+	    height: number;
+	    size: number;
+	    distance: number;
+	    moved_to: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new SelectFileResponse(source);
+	        return new DuplicateImage(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.file_path = source["file_path"];
-	        this.valid_urls = source["valid_urls"];
+	        this.path = source["path"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.size = source["size"];
+	        this.distance = source["distance"];
+	        this.moved_to = source["moved_to"];
 	    }
 	}
-	export class SetPreferenceInfoRequest {
+	export class DuplicateImageGroup {
+	    keep: DuplicateImage;
+	    duplicates: DuplicateImage[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DuplicateImageGroup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.keep = this.convertValues(source["keep"], DuplicateImage);
+	        this.duplicates = this.convertValues(source["duplicates"], DuplicateImage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ExportAlbumArticlesRequest {
+	    album: AlbumFetchResult;
+	    formats: string[];
+	    export_dir: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExportAlbumArticlesRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.album = this.convertValues(source["album"], AlbumFetchResult);
+	        this.formats = source["formats"];
+	        this.export_dir = source["export_dir"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ExportAlbumArticlesResponse {
+	    export_dir: string;
+	    file_paths: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ExportAlbumArticlesResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.export_dir = source["export_dir"];
+	        this.file_paths = source["file_paths"];
+	    }
+	}
+	export class ExportDataRequest {
+	    path: string;
+	    format: string;
 	    save_img_path: string;
-	    download_timeout: number;
-	    crop_img_bottom_pixel: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new SetPreferenceInfoRequest(source);
+	        return new ExportDataRequest(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.format = source["format"];
 	        this.save_img_path = source["save_img_path"];
-	        this.download_timeout = source["download_timeout"];
-	        this.crop_img_bottom_pixel = source["crop_img_bottom_pixel"];
 	    }
 	}
-	export class SetPreferenceInfoResponse {
-	    updated_time: number;
+	export class ExportDataResponse {
+	    path: string;
+	    subscription_count: number;
+	    crawl_history_count: number;
+	    crawl_run_count: number;
+	    failure_count: number;
+	    cast_time_str: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new SetPreferenceInfoResponse(source);
+	        return new ExportDataResponse(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.updated_time = source["updated_time"];
+	        this.path = source["path"];
+	        this.subscription_count = source["subscription_count"];
+	        this.crawl_history_count = source["crawl_history_count"];
+	        this.crawl_run_count = source["crawl_run_count"];
+	        this.failure_count = source["failure_count"];
+	        this.cast_time_str = source["cast_time_str"];
 	    }
 	}
-	export class ShufflingRequest {
+	export class FindDuplicateImagesRequest {
 	    img_save_path: string;
-	    max_num_image: number;
+	    algorithm: string;
+	    threshold: number;
+	    action: string;
+	    move_dir: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new ShufflingRequest(source);
+	        return new FindDuplicateImagesRequest(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.img_save_path = source["img_save_path"];
-	        this.max_num_image = source["max_num_image"];
+	        this.algorithm = source["algorithm"];
+	        this.threshold = source["threshold"];
+	        this.action = source["action"];
+	        this.move_dir = source["move_dir"];
 	    }
 	}
-	export class ShufflingResponse {
-	    shuffle_img_path: string;
+	export class FindDuplicateImagesResponse {
+	    img_path: string;
+	    algorithm: string;
+	    threshold: number;
+	    action: string;
+	    scanned_count: number;
+	    hashed_count: number;
+	    groups: DuplicateImageGroup[];
+	    duplicate_count: number;
+	    handled_count: number;
+	    err_content: string;
 	    cast_time_str: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new ShufflingResponse(source);
+	        return new FindDuplicateImagesResponse(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.shuffle_img_path = source["shuffle_img_path"];
+	        this.img_path = source["img_path"];
+	        this.algorithm = source["algorithm"];
+	        this.threshold = source["threshold"];
+	        this.action = source["action"];
+	        this.scanned_count = source["scanned_count"];
+	        this.hashed_count = source["hashed_count"];
+	        this.groups = this.convertValues(source["groups"], DuplicateImageGroup);
+	        this.duplicate_count = source["duplicate_count"];
+	        this.handled_count = source["handled_count"];
+	        this.err_content = source["err_content"];
+	        this.cast_time_str = source["cast_time_str"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class GetAlbumArticlesRequest {
+	    album_url: string;
+	    begin_msgid: string;
+	    paging: AlbumPagingOptions;
+	
+	    static createFrom(source: any = {}) {
+	        return new GetAlbumArticlesRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.album_url = source["album_url"];
+	        this.begin_msgid = source["begin_msgid"];
+	        this.paging = this.convertValues(source["paging"], AlbumPagingOptions);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class GetAlbumArticlesResponse {
+	    album_title: string;
+	    nick_name: string;
+	    article_count: number;
+	    is_numbered: boolean;
+	    is_reverse: boolean;
+	    urls: string[];
+	    articles: AlbumArticleInfo[];
+	    page_count: number;
+	    truncated: boolean;
+	    truncated_reason: string;
+	    next_begin_msgid: string;
+	    err_content: string;
+	    cast_time_str: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new GetAlbumArticlesResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.album_title = source["album_title"];
+	        this.nick_name = source["nick_name"];
+	        this.article_count = source["article_count"];
+	        this.is_numbered = source["is_numbered"];
+	        this.is_reverse = source["is_reverse"];
+	        this.urls = source["urls"];
+	        this.articles = this.convertValues(source["articles"], AlbumArticleInfo);
+	        this.page_count = source["page_count"];
+	        this.truncated = source["truncated"];
+	        this.truncated_reason = source["truncated_reason"];
+	        this.next_begin_msgid = source["next_begin_msgid"];
+	        this.err_content = source["err_content"];
+	        this.cast_time_str = source["cast_time_str"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class GetPreferenceInfoResponse {
+	    save_img_path: string;
+	    download_timeout: number;
+	    crop_img_bottom_pixel: number;
+	    post_process?: ImagePipelineOptions;
+	    updated_time: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new GetPreferenceInfoResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.save_img_path = source["save_img_path"];
+	        this.download_timeout = source["download_timeout"];
+	        this.crop_img_bottom_pixel = source["crop_img_bottom_pixel"];
+	        this.post_process = this.convertValues(source["post_process"], ImagePipelineOptions);
+	        this.updated_time = source["updated_time"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class ImagePipelineRequest {
+	    img_save_path: string;
+	    options: ImagePipelineOptions;
+	    mode: string;
+	    filter: ImageFileFilter;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImagePipelineRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.img_save_path = source["img_save_path"];
+	        this.options = this.convertValues(source["options"], ImagePipelineOptions);
+	        this.mode = source["mode"];
+	        this.filter = this.convertValues(source["filter"], ImageFileFilter);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ImagePipelineResponse {
+	    img_path: string;
+	    job_id: string;
+	    backup_dir: string;
+	    processed_count: number;
+	    skipped_count: number;
+	    bytes_before: number;
+	    bytes_after: number;
+	    err_content: string;
+	    cast_time_str: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImagePipelineResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.img_path = source["img_path"];
+	        this.job_id = source["job_id"];
+	        this.backup_dir = source["backup_dir"];
+	        this.processed_count = source["processed_count"];
+	        this.skipped_count = source["skipped_count"];
+	        this.bytes_before = source["bytes_before"];
+	        this.bytes_after = source["bytes_after"];
+	        this.err_content = source["err_content"];
+	        this.cast_time_str = source["cast_time_str"];
+	    }
+	}
+	export class ImportDataRequest {
+	    path: string;
+	    mode: string;
+	    save_img_path: string;
+	    path_remap: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportDataRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.mode = source["mode"];
+	        this.save_img_path = source["save_img_path"];
+	        this.path_remap = source["path_remap"];
+	    }
+	}
+	export class PathRemap {
+	    from: string;
+	    to: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PathRemap(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from = source["from"];
+	        this.to = source["to"];
+	    }
+	}
+	export class ImportDataResponse {
+	    mode: string;
+	    preferences_saved: boolean;
+	    subscription_count: number;
+	    article_count: number;
+	    crawl_history_count: number;
+	    crawl_run_count: number;
+	    failure_count: number;
+	    remapped_paths: PathRemap[];
+	    missing_paths: string[];
+	    warnings: string[];
+	    cast_time_str: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportDataResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mode = source["mode"];
+	        this.preferences_saved = source["preferences_saved"];
+	        this.subscription_count = source["subscription_count"];
+	        this.article_count = source["article_count"];
+	        this.crawl_history_count = source["crawl_history_count"];
+	        this.crawl_run_count = source["crawl_run_count"];
+	        this.failure_count = source["failure_count"];
+	        this.remapped_paths = this.convertValues(source["remapped_paths"], PathRemap);
+	        this.missing_paths = source["missing_paths"];
+	        this.warnings = source["warnings"];
+	        this.cast_time_str = source["cast_time_str"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ImportedURL {
+	    url: string;
+	    title: string;
+	    index: number;
+	    line: number;
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportedURL(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.title = source["title"];
+	        this.index = source["index"];
+	        this.line = source["line"];
+	        this.reason = source["reason"];
+	    }
+	}
+	export class IntakeEvent {
+	    source: string;
+	    file_paths: string[];
+	    accepted: ImportedURL[];
+	    rejected: ImportedURL[];
+	    duplicates: ImportedURL[];
+	    already_crawled: ImportedURL[];
+	    err_content: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new IntakeEvent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.file_paths = source["file_paths"];
+	        this.accepted = this.convertValues(source["accepted"], ImportedURL);
+	        this.rejected = this.convertValues(source["rejected"], ImportedURL);
+	        this.duplicates = this.convertValues(source["duplicates"], ImportedURL);
+	        this.already_crawled = this.convertValues(source["already_crawled"], ImportedURL);
+	        this.err_content = source["err_content"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ListAlbumSubscriptionRunsRequest {
+	    subscription_id: number;
+	    limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ListAlbumSubscriptionRunsRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.subscription_id = source["subscription_id"];
+	        this.limit = source["limit"];
+	    }
+	}
+	export class ListCrawlRunsResponse {
+	    runs: CrawlRun[];
+	    total: number;
+	    page: number;
+	    page_size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ListCrawlRunsResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.runs = this.convertValues(source["runs"], CrawlRun);
+	        this.total = source["total"];
+	        this.page = source["page"];
+	        this.page_size = source["page_size"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MergePendingURLsRequest {
+	    queued: string[];
+	    incoming: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new MergePendingURLsRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.queued = source["queued"];
+	        this.incoming = source["incoming"];
+	    }
+	}
+	export class MergePendingURLsResponse {
+	    urls: string[];
+	    added: string[];
+	    duplicates: string[];
+	    already_crawled: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new MergePendingURLsResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.urls = source["urls"];
+	        this.added = source["added"];
+	        this.duplicates = source["duplicates"];
+	        this.already_crawled = source["already_crawled"];
+	    }
+	}
+	export class PageRequest {
+	    page: number;
+	    page_size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PageRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.page = source["page"];
+	        this.page_size = source["page_size"];
+	    }
+	}
+	
+	export class PreviewCropRequest {
+	    img_save_path: string;
+	    bottom_pixel: number;
+	    top: number;
+	    bottom: number;
+	    left: number;
+	    right: number;
+	    unit: string;
+	    auto_bottom: boolean;
+	    aspect_ratio: string;
+	    anchor: string;
+	    max_width: number;
+	    max_height: number;
+	    mode: string;
+	    output_dir: string;
+	    filter: ImageFileFilter;
+	    limit: number;
+	    thumbnail_size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PreviewCropRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.img_save_path = source["img_save_path"];
+	        this.bottom_pixel = source["bottom_pixel"];
+	        this.top = source["top"];
+	        this.bottom = source["bottom"];
+	        this.left = source["left"];
+	        this.right = source["right"];
+	        this.unit = source["unit"];
+	        this.auto_bottom = source["auto_bottom"];
+	        this.aspect_ratio = source["aspect_ratio"];
+	        this.anchor = source["anchor"];
+	        this.max_width = source["max_width"];
+	        this.max_height = source["max_height"];
+	        this.mode = source["mode"];
+	        this.output_dir = source["output_dir"];
+	        this.filter = this.convertValues(source["filter"], ImageFileFilter);
+	        this.limit = source["limit"];
+	        this.thumbnail_size = source["thumbnail_size"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PreviewCropResponse {
+	    items: CropPreviewItem[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PreviewCropResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.items = this.convertValues(source["items"], CropPreviewItem);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class RollbackPreferenceInfoResponse {
+	    version: number;
+	    updated_time: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RollbackPreferenceInfoResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.updated_time = source["updated_time"];
+	    }
+	}
+	export class SelectFileResponse {
+	    file_path: string;
+	    format: string;
+	    accepted: ImportedURL[];
+	    rejected: ImportedURL[];
+	    duplicates: ImportedURL[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SelectFileResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.file_path = source["file_path"];
+	        this.format = source["format"];
+	        this.accepted = this.convertValues(source["accepted"], ImportedURL);
+	        this.rejected = this.convertValues(source["rejected"], ImportedURL);
+	        this.duplicates = this.convertValues(source["duplicates"], ImportedURL);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SetPreferenceInfoRequest {
+	    save_img_path: string;
+	    download_timeout: number;
+	    crop_img_bottom_pixel: number;
+	    post_process?: ImagePipelineOptions;
+	
+	    static createFrom(source: any = {}) {
+	        return new SetPreferenceInfoRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.save_img_path = source["save_img_path"];
+	        this.download_timeout = source["download_timeout"];
+	        this.crop_img_bottom_pixel = source["crop_img_bottom_pixel"];
+	        this.post_process = this.convertValues(source["post_process"], ImagePipelineOptions);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SettingFieldError {
+	    field: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SettingFieldError(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.field = source["field"];
+	        this.message = source["message"];
+	    }
+	}
+	export class SetPreferenceInfoResponse {
+	    updated_time: number;
+	    version: number;
+	    field_errors: SettingFieldError[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SetPreferenceInfoResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.updated_time = source["updated_time"];
+	        this.version = source["version"];
+	        this.field_errors = this.convertValues(source["field_errors"], SettingFieldError);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SettingDefinition {
+	    key: string;
+	    label: string;
+	    type: string;
+	    default: any;
+	    min?: number;
+	    max?: number;
+	    description: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SettingDefinition(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.label = source["label"];
+	        this.type = source["type"];
+	        this.default = source["default"];
+	        this.min = source["min"];
+	        this.max = source["max"];
+	        this.description = source["description"];
+	    }
+	}
+	
+	export class SettingHistoryItem {
+	    version: number;
+	    value?: GetPreferenceInfoResponse;
+	    changed: string[];
+	    created_at: number;
+	    current: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SettingHistoryItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.value = this.convertValues(source["value"], GetPreferenceInfoResponse);
+	        this.changed = source["changed"];
+	        this.created_at = source["created_at"];
+	        this.current = source["current"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ShuffleMove {
+	    from: string;
+	    to: string;
+	    tmp: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ShuffleMove(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.tmp = source["tmp"];
+	    }
+	}
+	export class ShufflingRequest {
+	    img_save_path: string;
+	    max_num_image: number;
+	    order: string;
+	    seed: number;
+	    split_strategy: string;
+	    split_dir_template: string;
+	    filter: ImageFileFilter;
+	    dry_run: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ShufflingRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.img_save_path = source["img_save_path"];
+	        this.max_num_image = source["max_num_image"];
+	        this.order = source["order"];
+	        this.seed = source["seed"];
+	        this.split_strategy = source["split_strategy"];
+	        this.split_dir_template = source["split_dir_template"];
+	        this.filter = this.convertValues(source["filter"], ImageFileFilter);
+	        this.dry_run = source["dry_run"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ShufflingResponse {
+	    shuffle_img_path: string;
+	    dry_run: boolean;
+	    job_id: string;
+	    order: string;
+	    seed: number;
+	    journal_path: string;
+	    moved_count: number;
+	    moves: ShuffleMove[];
+	    cast_time_str: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ShufflingResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.shuffle_img_path = source["shuffle_img_path"];
+	        this.dry_run = source["dry_run"];
+	        this.job_id = source["job_id"];
+	        this.order = source["order"];
+	        this.seed = source["seed"];
+	        this.journal_path = source["journal_path"];
+	        this.moved_count = source["moved_count"];
+	        this.moves = this.convertValues(source["moves"], ShuffleMove);
+	        this.cast_time_str = source["cast_time_str"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UndoCropResponse {
+	    job_id: string;
+	    restored_count: number;
+	    err_content: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new UndoCropResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.job_id = source["job_id"];
+	        this.restored_count = source["restored_count"];
+	        this.err_content = source["err_content"];
+	    }
+	}
+	export class UndoShuffleResponse {
+	    journal_path: string;
+	    restored_count: number;
+	    cast_time_str: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new UndoShuffleResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.journal_path = source["journal_path"];
+	        this.restored_count = source["restored_count"];
+	        this.cast_time_str = source["cast_time_str"];
+	    }
+	}
+	export class UpdateAlbumSubscriptionRequest {
+	    id: number;
+	    interval_minutes: number;
+	    enabled: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new UpdateAlbumSubscriptionRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.interval_minutes = source["interval_minutes"];
+	        this.enabled = source["enabled"];
+	    }
+	}
+	export class WatermarkOptions {
+	    text: string;
+	    font_path: string;
+	    text_color: string;
+	    logo_path: string;
+	    position: string;
+	    margin: number;
+	    opacity: number;
+	    scale: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new WatermarkOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.text = source["text"];
+	        this.font_path = source["font_path"];
+	        this.text_color = source["text_color"];
+	        this.logo_path = source["logo_path"];
+	        this.position = source["position"];
+	        this.margin = source["margin"];
+	        this.opacity = source["opacity"];
+	        this.scale = source["scale"];
+	    }
+	}
+	export class WatermarkingRequest {
+	    img_save_path: string;
+	    options: WatermarkOptions;
+	    mode: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new WatermarkingRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.img_save_path = source["img_save_path"];
+	        this.options = this.convertValues(source["options"], WatermarkOptions);
+	        this.mode = source["mode"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class WatermarkingResponse {
+	    img_path: string;
+	    job_id: string;
+	    backup_dir: string;
+	    watermark_img_count: number;
+	    skipped_img_count: number;
+	    err_content: string;
+	    cast_time_str: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new WatermarkingResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.img_path = source["img_path"];
+	        this.job_id = source["job_id"];
+	        this.backup_dir = source["backup_dir"];
+	        this.watermark_img_count = source["watermark_img_count"];
+	        this.skipped_img_count = source["skipped_img_count"];
+	        this.err_content = source["err_content"];
 	        this.cast_time_str = source["cast_time_str"];
 	    }
 	}
@@ -76,16 +76,25 @@ func main() {
 			Assets: assets, // 应用程序的前端资产
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1}, // 窗口的默认背景颜色
+		DragAndDrop: &options.DragAndDrop{
+			EnableFileDrop:     true, // 允许将文件拖拽到窗口中导入文章地址
+			DisableWebViewDrop: true, // 禁止 WebView 默认的拖拽行为（直接打开文件）
+		},
 		OnStartup: func(ctx context.Context) {
 			app.setContext(ctx) // 设置上下文
 			app.startup(ctx)    // 启动时的回调
 
 			// 将应用启动时的上下文传递给业务逻辑（方便在业务逻辑代码中使用运行时函数）
 			backendBoot.SetContext(ctx)
+			// 启动后台任务（专辑订阅的定时同步等）
+			backendBoot.Startup(ctx)
 
 		}, // 创建窗口并即将开始加载前端资源时的回调
-		OnDomReady:    app.domready,    // 前端 Dom 加载完成回调
-		OnShutdown:    app.shutdown,    // 应用程序即将退出时的回调
+		OnDomReady: app.domready, // 前端 Dom 加载完成回调
+		OnShutdown: func(ctx context.Context) {
+			backendBoot.Shutdown() // 停止后台任务
+			app.shutdown(ctx)
+		}, // 应用程序即将退出时的回调
 		OnBeforeClose: app.beforeClose, // 应用关闭前的回调
 		Mac: &mac.Options{
 			About: &mac.AboutInfo{